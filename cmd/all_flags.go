@@ -6,6 +6,8 @@ import (
 	execclient "github.com/kilnfi/go-utils/ethereum/execution/client/jsonrpc"
 	"github.com/kilnfi/go-utils/hashicorp"
 	gethkeystore "github.com/kilnfi/go-utils/keystore/geth"
+	hdkeystore "github.com/kilnfi/go-utils/keystore/hd"
+	vaultkeystore "github.com/kilnfi/go-utils/keystore/vault"
 	"github.com/kilnfi/go-utils/sql"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -25,6 +27,11 @@ func NewCmdAllFlags() *cobra.Command {
 	execclient.Flags(v, cmd.Flags())
 	gethkeystore.Flags(v, cmd.Flags())
 	hashicorp.Flags(v, cmd.Flags())
+	hdkeystore.Flags(v, cmd.Flags())
+	vaultkeystore.KeystorePasswordFlag(v, cmd.Flags())
+	vaultkeystore.TransitMountFlag(v, cmd.Flags())
+	vaultkeystore.KeystoreSignerFlag(v, cmd.Flags())
+	KeystoreTypeFlag(v, cmd.Flags())
 	sql.NewFlagPrefixer("mysql", "My Service").Flags(v, cmd.Flags())
 
 	return cmd
@@ -2,11 +2,22 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethhexutil "github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/kilnfi/go-utils/cmd/utils"
+	"github.com/kilnfi/go-utils/hashicorp"
 	"github.com/kilnfi/go-utils/keystore"
 	gethkeystore "github.com/kilnfi/go-utils/keystore/geth"
+	hdkeystore "github.com/kilnfi/go-utils/keystore/hd"
+	vaultkeystore "github.com/kilnfi/go-utils/keystore/vault"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -23,8 +34,8 @@ func NewCmdKeystore(
 	keystoreCtx := &keystoreContext{Context: ctx}
 
 	if newKeystore == nil {
-		newKeystore = func(v *viper.Viper) (keystore.Store, error) { //nolint
-			return gethkeystore.New(gethkeystore.ConfigFromViper(v).SetDefault()), nil
+		newKeystore = func(v *viper.Viper) (keystore.Store, error) {
+			return NewKeystoreFromViper(ctx, v)
 		}
 	}
 
@@ -41,14 +52,93 @@ func NewCmdKeystore(
 	}
 
 	// Register flags
+	KeystoreTypeFlag(v, cmds.PersistentFlags())
 	gethkeystore.Flags(v, cmds.PersistentFlags())
+	vaultkeystore.Flags(v, cmds.PersistentFlags())
+	vaultkeystore.TransitMountFlag(v, cmds.PersistentFlags())
+	hdkeystore.Flags(v, cmds.PersistentFlags())
 
 	cmds.AddCommand(newCmdGenerateEth1Key(keystoreCtx)) //nolint:contextcheck // command runtime context is sourced from cmd.Context() inside RunE
 	cmds.AddCommand(newCmdImportEth1Key(keystoreCtx))   //nolint:contextcheck // command runtime context is sourced from cmd.Context() inside RunE
+	cmds.AddCommand(newCmdListEth1Keys(keystoreCtx))    //nolint:contextcheck // command runtime context is sourced from cmd.Context() inside RunE
+	cmds.AddCommand(newCmdDeleteEth1Key(keystoreCtx))   //nolint:contextcheck // command runtime context is sourced from cmd.Context() inside RunE
+	cmds.AddCommand(newCmdExportEth1Key(keystoreCtx))   //nolint:contextcheck // command runtime context is sourced from cmd.Context() inside RunE
+	cmds.AddCommand(newCmdChangePassword(keystoreCtx))  //nolint:contextcheck // command runtime context is sourced from cmd.Context() inside RunE
+	cmds.AddCommand(newCmdSignerAddress(keystoreCtx))   //nolint:contextcheck // command runtime context is sourced from cmd.Context() inside RunE
+	cmds.AddCommand(newCmdSign(keystoreCtx))            //nolint:contextcheck // command runtime context is sourced from cmd.Context() inside RunE
 
 	return cmds
 }
 
+// Supported keystore types
+const (
+	KeystoreTypeGeth         = "geth"
+	KeystoreTypeVault        = "vault"
+	KeystoreTypeVaultTransit = "vault-transit"
+	KeystoreTypeHD           = "hd"
+)
+
+// NewKeystoreFromViper creates the keystore selected by the keystore type flag
+func NewKeystoreFromViper(ctx context.Context, v *viper.Viper) (keystore.Store, error) {
+	switch typ := GetKeystoreType(v); typ {
+	case KeystoreTypeGeth:
+		return gethkeystore.New(gethkeystore.ConfigFromViper(v).SetDefault()), nil
+	case KeystoreTypeVault:
+		cfg := vaultkeystore.ConfigFromViper(v).SetDefault()
+
+		client, err := hashicorp.NewKVv2Client(cfg.Vault)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := client.Init(ctx); err != nil {
+			return nil, err
+		}
+
+		return vaultkeystore.New(cfg, client), nil
+	case KeystoreTypeVaultTransit:
+		cfg := vaultkeystore.TransitConfigFromViper(v).SetDefault()
+
+		client, err := hashicorp.NewKVv2Client(cfg.Vault)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := client.Authenticate(ctx); err != nil {
+			return nil, err
+		}
+
+		return vaultkeystore.NewTransit(cfg, client.Logical()), nil
+	case KeystoreTypeHD:
+		return hdkeystore.New(hdkeystore.ConfigFromViper(v).SetDefault())
+	default:
+		return nil, fmt.Errorf("unknown keystore type %q (expected one of %q, %q, %q, %q)", typ, KeystoreTypeGeth, KeystoreTypeVault, KeystoreTypeVaultTransit, KeystoreTypeHD)
+	}
+}
+
+const (
+	keystoreTypeFlag     = "keystore-type"
+	keystoreTypeViperKey = "keystore.type"
+	keystoreTypeEnv      = "KEYSTORE_TYPE"
+)
+
+// KeystoreTypeFlag register flag for the type of keystore
+func KeystoreTypeFlag(v *viper.Viper, f *pflag.FlagSet) {
+	desc := utils.FlagDesc(
+		fmt.Sprintf("Type of keystore (one of %q, %q, %q, %q)", KeystoreTypeGeth, KeystoreTypeVault, KeystoreTypeVaultTransit, KeystoreTypeHD),
+		keystoreTypeEnv,
+	)
+
+	f.String(keystoreTypeFlag, KeystoreTypeGeth, desc)
+	_ = v.BindPFlag(keystoreTypeViperKey, f.Lookup(keystoreTypeFlag))
+	_ = v.BindEnv(keystoreTypeViperKey, keystoreTypeEnv)
+	v.SetDefault(keystoreTypeViperKey, KeystoreTypeGeth)
+}
+
+func GetKeystoreType(v *viper.Viper) string {
+	return v.GetString(keystoreTypeViperKey)
+}
+
 func newCmdGenerateEth1Key(ctx *keystoreContext) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "generate",
@@ -85,3 +175,209 @@ func newCmdImportEth1Key(ctx *keystoreContext) *cobra.Command {
 
 	return cmd
 }
+
+func newCmdListEth1Keys(ctx *keystoreContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List Ethereum execution layer accounts",
+		RunE: utils.PrintJSON(func(cmd *cobra.Command, _ []string) (res interface{}, err error) {
+			reqCtx := cmd.Context()
+			if reqCtx == nil {
+				reqCtx = ctx
+			}
+			return ctx.keys.ListAccounts(reqCtx)
+		}),
+	}
+
+	return cmd
+}
+
+func newCmdDeleteEth1Key(ctx *keystoreContext) *cobra.Command {
+	var addr string
+
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Permanently delete the key of the given account",
+		RunE: utils.PrintJSON(func(cmd *cobra.Command, _ []string) (res interface{}, err error) {
+			reqCtx := cmd.Context()
+			if reqCtx == nil {
+				reqCtx = ctx
+			}
+
+			account, err := parseAddress(addr)
+			if err != nil {
+				return nil, err
+			}
+
+			return nil, ctx.keys.DeleteAccount(reqCtx, account)
+		}),
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "", "Address of the account")
+	_ = cmd.MarkFlagRequired("addr")
+
+	return cmd
+}
+
+func newCmdExportEth1Key(ctx *keystoreContext) *cobra.Command {
+	var addr, password string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the key of the given account as encrypted JSON",
+		RunE: utils.PrintJSON(func(cmd *cobra.Command, _ []string) (res interface{}, err error) {
+			reqCtx := cmd.Context()
+			if reqCtx == nil {
+				reqCtx = ctx
+			}
+
+			account, err := parseAddress(addr)
+			if err != nil {
+				return nil, err
+			}
+
+			keyJSON, err := ctx.keys.ExportAccount(reqCtx, account, password)
+			if err != nil {
+				return nil, err
+			}
+
+			return json.RawMessage(keyJSON), nil
+		}),
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "", "Address of the account")
+	cmd.Flags().StringVar(&password, "password", "", "Password used to encrypt the exported key")
+	_ = cmd.MarkFlagRequired("addr")
+	_ = cmd.MarkFlagRequired("password")
+
+	return cmd
+}
+
+func newCmdChangePassword(ctx *keystoreContext) *cobra.Command {
+	var password string
+
+	cmd := &cobra.Command{
+		Use:   "change-password",
+		Short: "Re-encrypt all keys with a new password",
+		RunE: utils.PrintJSON(func(cmd *cobra.Command, _ []string) (res interface{}, err error) {
+			reqCtx := cmd.Context()
+			if reqCtx == nil {
+				reqCtx = ctx
+			}
+			return nil, ctx.keys.ChangePassword(reqCtx, password)
+		}),
+	}
+
+	cmd.Flags().StringVar(&password, "new-password", "", "New password used to encrypt keys")
+	_ = cmd.MarkFlagRequired("new-password")
+
+	return cmd
+}
+
+func newCmdSignerAddress(ctx *keystoreContext) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "signer",
+		Short: "Get the address of the default signer",
+		RunE: utils.PrintJSON(func(cmd *cobra.Command, _ []string) (res interface{}, err error) {
+			reqCtx := cmd.Context()
+			if reqCtx == nil {
+				reqCtx = ctx
+			}
+			return ctx.keys.SignerAddress(reqCtx)
+		}),
+	}
+
+	return cmd
+}
+
+type signature struct {
+	Address   gethcommon.Address `json:"address"`
+	Signature gethhexutil.Bytes  `json:"signature"`
+}
+
+func newCmdSign(ctx *keystoreContext) *cobra.Command {
+	var (
+		addr, message, typedDataPath string
+		isHex                        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sign",
+		Short: "Sign a message (EIP-191 personal_sign) or typed data (EIP-712 eth_signTypedData_v4)",
+		RunE: utils.PrintJSON(func(cmd *cobra.Command, _ []string) (res interface{}, err error) {
+			reqCtx := cmd.Context()
+			if reqCtx == nil {
+				reqCtx = ctx
+			}
+
+			var account gethcommon.Address
+			if addr != "" {
+				account, err = parseAddress(addr)
+			} else {
+				account, err = ctx.keys.SignerAddress(reqCtx)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			var sig []byte
+			switch {
+			case message != "" && typedDataPath != "":
+				return nil, errors.New("only one of --message and --typed-data can be set")
+			case message != "":
+				msg := []byte(message)
+				if isHex {
+					if msg, err = gethhexutil.Decode(message); err != nil {
+						return nil, fmt.Errorf("invalid hex message: %w", err)
+					}
+				}
+				sig, err = ctx.keys.SignMessage(reqCtx, account, msg)
+			case typedDataPath != "":
+				var data apitypes.TypedData
+				if data, err = readTypedData(typedDataPath); err != nil {
+					return nil, err
+				}
+				sig, err = ctx.keys.SignTypedData(reqCtx, account, data)
+			default:
+				return nil, errors.New("one of --message and --typed-data must be set")
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			return &signature{
+				Address:   account,
+				Signature: sig,
+			}, nil
+		}),
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "", "Address of the signing account (defaults to the default signer)")
+	cmd.Flags().StringVar(&message, "message", "", "Message to sign")
+	cmd.Flags().BoolVar(&isHex, "hex", false, "Whether the message is in hexadecimal format")
+	cmd.Flags().StringVar(&typedDataPath, "typed-data", "", "Path to a JSON file holding EIP-712 typed data to sign")
+
+	return cmd
+}
+
+func readTypedData(pth string) (apitypes.TypedData, error) {
+	var data apitypes.TypedData
+
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		return data, err
+	}
+
+	if err := json.Unmarshal(b, &data); err != nil {
+		return data, fmt.Errorf("invalid typed data: %w", err)
+	}
+
+	return data, nil
+}
+
+func parseAddress(addr string) (gethcommon.Address, error) {
+	if !gethcommon.IsHexAddress(addr) {
+		return gethcommon.Address{}, fmt.Errorf("invalid address %q", addr)
+	}
+	return gethcommon.HexToAddress(addr), nil
+}
@@ -262,3 +262,91 @@ func NewFoundryServiceConfig(opts *FoundryServiceOpts) (*ServiceConfig, error) {
 		IsReady:   isReady,
 	}, nil
 }
+
+type VaultServiceOpts struct {
+	Version, Port, RootToken string
+}
+
+func (opts *VaultServiceOpts) SetDefault() *VaultServiceOpts {
+	if opts.Version == "" {
+		opts.Version = "latest"
+	}
+
+	if opts.Port == "" {
+		opts.Port = "0" // expose on random available port
+	}
+
+	if opts.RootToken == "" {
+		opts.RootToken = "root"
+	}
+
+	return opts
+}
+
+// Addr returns address to connect to the Vault container from the host
+func (opts *VaultServiceOpts) Addr(container *dockercontainer.InspectResponse) (string, error) {
+	portBindings, err := GetPortBindings("8200", container)
+	if err != nil {
+		return "", err
+	}
+
+	//revive:disable-next-line:unsecure-url-scheme
+	return fmt.Sprintf("http://%v:%v", portBindings[0].HostIP, portBindings[0].HostPort), nil
+}
+
+// NewVaultServiceConfig returns the config of a Vault server in dev mode, authenticating with the root token
+func NewVaultServiceConfig(opts *VaultServiceOpts) (*ServiceConfig, error) {
+	ports, portBindings, err := nat.ParsePortSpecs([]string{
+		fmt.Sprintf("%v:8200", opts.Port),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	containerCfg := &dockercontainer.Config{
+		Image:        fmt.Sprintf("hashicorp/vault:%v", opts.Version),
+		Cmd:          []string{"server", "-dev"},
+		ExposedPorts: ports,
+		Env: []string{
+			fmt.Sprintf("VAULT_DEV_ROOT_TOKEN_ID=%v", opts.RootToken),
+			"VAULT_DEV_LISTEN_ADDRESS=0.0.0.0:8200",
+		},
+	}
+
+	hostCfg := &dockercontainer.HostConfig{
+		PortBindings: portBindings,
+		CapAdd:       []string{"IPC_LOCK"},
+	}
+
+	isReady := func(ctx context.Context, container *dockercontainer.InspectResponse) error {
+		addr, err := opts.Addr(container)
+		if err != nil {
+			return err
+		}
+
+		req, _ := http.NewRequestWithContext(
+			ctx,
+			http.MethodGet,
+			fmt.Sprintf("%v/v1/sys/health", addr),
+			http.NoBody,
+		)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("invalid status: %v", resp.Status)
+		}
+
+		return nil
+	}
+
+	return &ServiceConfig{
+		Container: containerCfg,
+		Host:      hostCfg,
+		IsReady:   isReady,
+	}, nil
+}
@@ -62,3 +62,10 @@ func TestFoundryService(t *testing.T) {
 
 	testService(t, "foundry", svcCfg)
 }
+
+func TestVaultService(t *testing.T) {
+	svcCfg, err := NewVaultServiceConfig(new(VaultServiceOpts).SetDefault())
+	require.NoError(t, err)
+
+	testService(t, "vault", svcCfg)
+}
@@ -51,3 +51,29 @@ func Network(v beaconcommon.Version) (string, error) {
 	}
 	return "", fmt.Errorf("unknown fork version %v", v)
 }
+
+// electraForkEpochs are the Electra (Pectra) fork epochs of networks, from which
+// EIP-7251 compounding validators are supported
+var electraForkEpochs = map[string]Epoch{
+	MainnetForkVersion.String():  364032,
+	SepoliaForkVersion.String():  222464,
+	HoleskyForkVersion.String():  115968,
+	HoodiForkVersion.String():    2048,
+	KurtosisForkVersion.String(): 0,
+}
+
+// ElectraForkEpoch returns the Electra fork epoch of the network of genesis fork version v
+//
+// It errors for networks that are unknown or were deprecated before Electra (e.g. prater)
+func ElectraForkEpoch(v beaconcommon.Version) (Epoch, error) {
+	if epoch, ok := electraForkEpochs[v.String()]; ok {
+		return epoch, nil
+	}
+	return 0, fmt.Errorf("unknown Electra fork epoch for fork version %v", v)
+}
+
+// IsElectra returns true if Electra is active at epoch on the network of genesis fork version v
+func IsElectra(v beaconcommon.Version, epoch Epoch) bool {
+	electraEpoch, err := ElectraForkEpoch(v)
+	return err == nil && epoch >= electraEpoch
+}
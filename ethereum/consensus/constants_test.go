@@ -0,0 +1,22 @@
+//revive:disable-next-line:package-directory-mismatch
+package ethcl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElectraForkEpoch(t *testing.T) {
+	epoch, err := ElectraForkEpoch(MainnetForkVersion)
+	require.NoError(t, err)
+	assert.Equal(t, Epoch(364032), epoch)
+
+	_, err = ElectraForkEpoch(PraterForkVersion)
+	assert.Error(t, err)
+
+	assert.True(t, IsElectra(HoodiForkVersion, 2048))
+	assert.False(t, IsElectra(HoodiForkVersion, 2047))
+	assert.False(t, IsElectra(PraterForkVersion, 1000000))
+}
@@ -0,0 +1,401 @@
+//revive:disable-next-line:package-directory-mismatch
+package ethel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	gethbind "github.com/ethereum/go-ethereum/accounts/abi/bind"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethhexutil "github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kilnfi/go-utils/net/jsonrpc"
+)
+
+var (
+	// ErrUnknownEvent is returned when no registered event matches a log
+	ErrUnknownEvent = errors.New("unknown event")
+
+	// ErrUnknownError is returned when no registered error matches revert data
+	ErrUnknownError = errors.New("unknown error")
+
+	// ErrNoRevertData is returned when an error carries no revert data
+	ErrNoRevertData = errors.New("no revert data")
+)
+
+// Selectors of errors emitted by Solidity require/revert with a reason and by failed assertions
+var (
+	errorStringSelector = [4]byte{0x08, 0xc3, 0x79, 0xa0}
+	panicSelector       = [4]byte{0x4e, 0x48, 0x7b, 0x71}
+)
+
+// ABIRegistry decodes logs and revert data using registered ABIs
+type ABIRegistry struct {
+	mu     sync.RWMutex
+	events map[gethcommon.Hash][]gethabi.Event
+	errors map[[4]byte]gethabi.Error
+}
+
+// NewABIRegistry creates an empty registry
+func NewABIRegistry() *ABIRegistry {
+	return &ABIRegistry{
+		events: make(map[gethcommon.Hash][]gethabi.Event),
+		errors: make(map[[4]byte]gethabi.Error),
+	}
+}
+
+// Register registers events and errors of contractABI
+func (r *ABIRegistry) Register(contractABI *gethabi.ABI) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, event := range contractABI.Events {
+		if event.Anonymous {
+			continue
+		}
+		if !r.hasEvent(event) {
+			// events with the same signature can differ by their indexed arguments
+			r.events[event.ID] = append(r.events[event.ID], event)
+		}
+	}
+
+	for _, abiErr := range contractABI.Errors {
+		var selector [4]byte
+		copy(selector[:], abiErr.ID[:4])
+		r.errors[selector] = abiErr
+	}
+}
+
+func (r *ABIRegistry) hasEvent(event gethabi.Event) bool {
+	for _, registered := range r.events[event.ID] {
+		if reflect.DeepEqual(registered.Inputs, event.Inputs) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterJSON registers a JSON ABI
+func (r *ABIRegistry) RegisterJSON(abiJSON string) error {
+	contractABI, err := gethabi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return fmt.Errorf("invalid ABI: %w", err)
+	}
+
+	r.Register(&contractABI)
+
+	return nil
+}
+
+// RegisterMetaData registers the ABI of generated bindings
+func (r *ABIRegistry) RegisterMetaData(metadata *gethbind.MetaData) error {
+	contractABI, err := metadata.GetAbi()
+	if err != nil {
+		return fmt.Errorf("invalid ABI: %w", err)
+	}
+
+	r.Register(contractABI)
+
+	return nil
+}
+
+// Argument is a decoded event or error argument
+type Argument struct {
+	Name  string
+	Type  string
+	Value interface{}
+}
+
+func (arg *Argument) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Name  string      `json:"name"`
+		Type  string      `json:"type"`
+		Value interface{} `json:"value"`
+	}{
+		Name:  arg.Name,
+		Type:  arg.Type,
+		Value: jsonValue(reflect.ValueOf(arg.Value)),
+	})
+}
+
+// DecodedEvent is a log decoded into a named event
+type DecodedEvent struct {
+	Name      string             `json:"name"`
+	Signature string             `json:"signature"`
+	Address   gethcommon.Address `json:"address"`
+	TxHash    gethcommon.Hash    `json:"transactionHash"`
+	Block     uint64             `json:"blockNumber"`
+	Index     uint               `json:"logIndex"`
+	Args      []*Argument        `json:"args"`
+}
+
+// Arg returns the value of the argument with the given name (nil if there is none)
+func (e *DecodedEvent) Arg(name string) interface{} {
+	return argValue(e.Args, name)
+}
+
+// DecodeLog decodes log into one of the registered events
+func (r *ABIRegistry) DecodeLog(log *gethtypes.Log) (*DecodedEvent, error) {
+	if len(log.Topics) == 0 {
+		return nil, fmt.Errorf("%w: anonymous log", ErrUnknownEvent)
+	}
+
+	r.mu.RLock()
+	events := r.events[log.Topics[0]]
+	r.mu.RUnlock()
+
+	var errs []error
+	for i := range events {
+		args, err := decodeLog(&events[i], log)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		return &DecodedEvent{
+			Name:      events[i].Name,
+			Signature: events[i].Sig,
+			Address:   log.Address,
+			TxHash:    log.TxHash,
+			Block:     log.BlockNumber,
+			Index:     log.Index,
+			Args:      args,
+		}, nil
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to decode log with topic %v: %w", log.Topics[0], errors.Join(errs...))
+	}
+
+	return nil, fmt.Errorf("%w: topic %v", ErrUnknownEvent, log.Topics[0])
+}
+
+func decodeLog(event *gethabi.Event, log *gethtypes.Log) ([]*Argument, error) {
+	var indexed gethabi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+
+	if len(log.Topics)-1 != len(indexed) {
+		return nil, fmt.Errorf("event %v expects %v indexed arguments, got %v topics", event.Sig, len(indexed), len(log.Topics)-1)
+	}
+
+	values := make(map[string]interface{})
+	if err := gethabi.ParseTopicsIntoMap(values, indexed, log.Topics[1:]); err != nil {
+		return nil, err
+	}
+
+	if err := event.Inputs.NonIndexed().UnpackIntoMap(values, log.Data); err != nil {
+		return nil, err
+	}
+
+	args := make([]*Argument, len(event.Inputs))
+	for i, input := range event.Inputs {
+		args[i] = &Argument{
+			Name:  input.Name,
+			Type:  input.Type.String(),
+			Value: values[input.Name],
+		}
+	}
+
+	return args, nil
+}
+
+// DecodedError is revert data decoded into a Solidity error
+//
+// Reasons of require/revert statements are decoded as Error(string) and failed assertions as Panic(uint256).
+type DecodedError struct {
+	Name      string            `json:"name"`
+	Signature string            `json:"signature"`
+	Args      []*Argument       `json:"args"`
+	Data      gethhexutil.Bytes `json:"data"`
+}
+
+// Arg returns the value of the argument with the given name (nil if there is none)
+func (e *DecodedError) Arg(name string) interface{} {
+	return argValue(e.Args, name)
+}
+
+func (e *DecodedError) Error() string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		b, _ := json.Marshal(jsonValue(reflect.ValueOf(arg.Value)))
+		args[i] = fmt.Sprintf("%v=%s", arg.Name, b)
+	}
+	return fmt.Sprintf("execution reverted: %v(%v)", e.Name, strings.Join(args, ", "))
+}
+
+// DecodeRevert decodes revert data into a registered custom error, Error(string) or Panic(uint256)
+func (r *ABIRegistry) DecodeRevert(data []byte) (*DecodedError, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("%w: %#x", ErrNoRevertData, data)
+	}
+
+	var selector [4]byte
+	copy(selector[:], data[:4])
+
+	var abiErr gethabi.Error
+	switch selector {
+	case errorStringSelector:
+		abiErr = gethabi.NewError("Error", gethabi.Arguments{{Name: "reason", Type: mustNewType("string")}})
+	case panicSelector:
+		abiErr = gethabi.NewError("Panic", gethabi.Arguments{{Name: "code", Type: mustNewType("uint256")}})
+	default:
+		var ok bool
+		r.mu.RLock()
+		abiErr, ok = r.errors[selector]
+		r.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("%w: selector %#x", ErrUnknownError, selector)
+		}
+	}
+
+	values, err := abiErr.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode error %v: %w", abiErr.Sig, err)
+	}
+
+	args := make([]*Argument, len(abiErr.Inputs))
+	for i, input := range abiErr.Inputs {
+		args[i] = &Argument{
+			Name:  input.Name,
+			Type:  input.Type.String(),
+			Value: values[i],
+		}
+	}
+
+	return &DecodedError{
+		Name:      abiErr.Name,
+		Signature: abiErr.Sig,
+		Args:      args,
+		Data:      data,
+	}, nil
+}
+
+// DecodeCallError decodes the revert data carried by an error returned by CallContract or EstimateGas
+func (r *ABIRegistry) DecodeCallError(err error) (*DecodedError, error) {
+	data, ok := RevertData(err)
+	if !ok {
+		return nil, ErrNoRevertData
+	}
+
+	return r.DecodeRevert(data)
+}
+
+// RevertData extracts revert data from an error returned by a JSON-RPC or geth client
+func RevertData(err error) ([]byte, bool) {
+	var raw string
+
+	var errMsg *jsonrpc.ErrorMsg
+	var dataErr interface{ ErrorData() interface{} }
+	switch {
+	case errors.As(err, &errMsg):
+		if errMsg.Data == nil || json.Unmarshal(*errMsg.Data, &raw) != nil {
+			return nil, false
+		}
+	case errors.As(err, &dataErr):
+		s, ok := dataErr.ErrorData().(string)
+		if !ok {
+			return nil, false
+		}
+		raw = s
+	default:
+		return nil, false
+	}
+
+	// some nodes prefix revert data
+	raw = strings.TrimPrefix(raw, "Reverted ")
+
+	data, decodeErr := gethhexutil.Decode(raw)
+	if decodeErr != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+func argValue(args []*Argument, name string) interface{} {
+	for _, arg := range args {
+		if arg.Name == name {
+			return arg.Value
+		}
+	}
+	return nil
+}
+
+func mustNewType(t string) gethabi.Type {
+	typ, err := gethabi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+var (
+	bigIntType  = reflect.TypeOf((*big.Int)(nil))
+	addressType = reflect.TypeOf(gethcommon.Address{})
+	hashType    = reflect.TypeOf(gethcommon.Hash{})
+)
+
+// jsonValue converts a decoded ABI value into a JSON friendly value:
+// integers as decimal strings, bytes as hex strings and tuples as objects
+func jsonValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch {
+	case v.Type() == bigIntType:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Interface().(*big.Int).String()
+	case v.Type() == addressType, v.Type() == hashType:
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%d", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d", v.Uint())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return gethhexutil.Bytes(b)
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = jsonValue(v.Index(i))
+		}
+		return values
+	case reflect.Struct:
+		// tuples are decoded into anonymous structs with json tags holding ABI names
+		values := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name := field.Tag.Get("json")
+			if name == "" {
+				name = field.Name
+			}
+			values[name] = jsonValue(v.Field(i))
+		}
+		return values
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return jsonValue(v.Elem())
+	default:
+		return v.Interface()
+	}
+}
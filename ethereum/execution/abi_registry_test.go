@@ -0,0 +1,177 @@
+//go:build !integration
+
+//revive:disable-next-line:package-directory-mismatch
+package ethel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/kilnfi/go-utils/net/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testABI = `[
+	{"type":"event","name":"Transfer","inputs":[
+		{"name":"from","type":"address","indexed":true},
+		{"name":"to","type":"address","indexed":true},
+		{"name":"value","type":"uint256","indexed":false}
+	]},
+	{"type":"event","name":"Deposit","inputs":[
+		{"name":"pubkey","type":"bytes","indexed":false},
+		{"name":"info","type":"tuple","indexed":false,"components":[
+			{"name":"amount","type":"uint64"},
+			{"name":"index","type":"bytes32"}
+		]}
+	]},
+	{"type":"error","name":"InsufficientBalance","inputs":[
+		{"name":"account","type":"address"},
+		{"name":"needed","type":"uint256"}
+	]}
+]`
+
+var (
+	testFrom = gethcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	testTo   = gethcommon.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+func newTestRegistry(t *testing.T) (*ABIRegistry, gethabi.ABI) {
+	t.Helper()
+	r := NewABIRegistry()
+	require.NoError(t, r.RegisterJSON(testABI))
+	parsed, err := gethabi.JSON(strings.NewReader(testABI))
+	require.NoError(t, err)
+	return r, parsed
+}
+
+func TestDecodeLog(t *testing.T) {
+	r, parsed := newTestRegistry(t)
+
+	data, err := parsed.Events["Transfer"].Inputs.NonIndexed().Pack(big.NewInt(1000))
+	require.NoError(t, err)
+
+	log := &gethtypes.Log{
+		Address: testTo,
+		Topics: []gethcommon.Hash{
+			parsed.Events["Transfer"].ID,
+			gethcommon.BytesToHash(testFrom.Bytes()),
+			gethcommon.BytesToHash(testTo.Bytes()),
+		},
+		Data:        data,
+		BlockNumber: 10,
+	}
+
+	event, err := r.DecodeLog(log)
+	require.NoError(t, err)
+	assert.Equal(t, "Transfer", event.Name)
+	assert.Equal(t, testFrom, event.Arg("from"))
+	assert.Equal(t, int64(1000), event.Arg("value").(*big.Int).Int64())
+
+	b, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"name":"Transfer",
+		"signature":"Transfer(address,address,uint256)",
+		"address":"%v",
+		"transactionHash":"0x0000000000000000000000000000000000000000000000000000000000000000",
+		"blockNumber":10,
+		"logIndex":0,
+		"args":[
+			{"name":"from","type":"address","value":"%v"},
+			{"name":"to","type":"address","value":"%v"},
+			{"name":"value","type":"uint256","value":"1000"}
+		]
+	}`, testTo.Hex(), testFrom.Hex(), testTo.Hex()), string(b))
+
+	_, err = r.DecodeLog(&gethtypes.Log{Topics: []gethcommon.Hash{gethcrypto.Keccak256Hash([]byte("Unknown()"))}})
+	assert.ErrorIs(t, err, ErrUnknownEvent)
+}
+
+func TestDecodeLogTuple(t *testing.T) {
+	r, parsed := newTestRegistry(t)
+
+	info := struct {
+		Amount uint64
+		Index  [32]byte
+	}{Amount: 32, Index: [32]byte{0x1}}
+	data, err := parsed.Events["Deposit"].Inputs.Pack([]byte{0xab, 0xcd}, info)
+	require.NoError(t, err)
+
+	event, err := r.DecodeLog(&gethtypes.Log{Topics: []gethcommon.Hash{parsed.Events["Deposit"].ID}, Data: data})
+	require.NoError(t, err)
+
+	b, err := json.Marshal(event.Args)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"name":"pubkey","type":"bytes","value":"0xabcd"},
+		{"name":"info","type":"(uint64,bytes32)","value":{
+			"amount":"32",
+			"index":"0x0100000000000000000000000000000000000000000000000000000000000000"
+		}}
+	]`, string(b))
+}
+
+func TestDecodeCallError(t *testing.T) {
+	r, parsed := newTestRegistry(t)
+
+	t.Run("custom error", func(t *testing.T) {
+		args, err := parsed.Errors["InsufficientBalance"].Inputs.Pack(testFrom, big.NewInt(5))
+		require.NoError(t, err)
+		errID := parsed.Errors["InsufficientBalance"].ID
+		data := append(errID[:4:4], args...)
+
+		raw := json.RawMessage(fmt.Sprintf(`"0x%x"`, data))
+		decoded, err := r.DecodeCallError(&jsonrpc.ErrorMsg{Code: 3, Message: "execution reverted", Data: &raw})
+		require.NoError(t, err)
+		assert.Equal(t, "InsufficientBalance", decoded.Name)
+		assert.Equal(t, testFrom, decoded.Arg("account"))
+		assert.Equal(t, fmt.Sprintf(`execution reverted: InsufficientBalance(account="%v", needed="5")`, testFrom.Hex()), decoded.Error())
+	})
+
+	t.Run("reason", func(t *testing.T) {
+		args, err := gethabi.Arguments{{Type: mustNewType("string")}}.Pack("not allowed")
+		require.NoError(t, err)
+
+		decoded, err := r.DecodeCallError(testDataError{data: fmt.Sprintf("0x%x%x", errorStringSelector, args)})
+		require.NoError(t, err)
+		assert.Equal(t, "Error", decoded.Name)
+		assert.Equal(t, "not allowed", decoded.Arg("reason"))
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		args, err := gethabi.Arguments{{Type: mustNewType("uint256")}}.Pack(big.NewInt(0x11))
+		require.NoError(t, err)
+
+		decoded, err := r.DecodeRevert(append(panicSelector[:], args...))
+		require.NoError(t, err)
+		assert.Equal(t, "Panic", decoded.Name)
+		assert.Equal(t, int64(0x11), decoded.Arg("code").(*big.Int).Int64())
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		_, err := r.DecodeCallError(errors.New("connection refused"))
+		assert.ErrorIs(t, err, ErrNoRevertData)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := r.DecodeRevert([]byte{0xde, 0xad, 0xbe, 0xef})
+		assert.ErrorIs(t, err, ErrUnknownError)
+	})
+}
+
+type testDataError struct {
+	data string
+}
+
+func (err testDataError) Error() string { return "execution reverted" }
+
+func (err testDataError) ErrorData() interface{} { return err.data }
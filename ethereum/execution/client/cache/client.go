@@ -0,0 +1,382 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/kilnfi/go-utils/ethereum/execution/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// Ensure Client interface is fully implemented
+var _ client.Client = (*Client)(nil)
+
+// entryOverhead is the approximate number of bytes used by a cache entry in addition to its key and value
+const entryOverhead = 64
+
+// Client is a client caching results of immutable queries
+//
+// Results are cached if they are pinned to a block hash (blocks, headers and transactions by block hash)
+// or to a finalized block number (blocks, headers, receipts, CallContract and CodeAt).
+// Queries at latest, pending, safe or non-finalized blocks are always forwarded to the underlying client,
+// as well as errors that are never cached.
+type Client struct {
+	client.Client
+
+	cfg   *Config
+	cache *lru
+
+	// mu protects the finalized block number, it is not held while the number is refreshed
+	mu          sync.Mutex
+	finalized   uint64
+	finalizedAt time.Time
+	refreshing  bool
+
+	hits    *prometheus.CounterVec
+	misses  *prometheus.CounterVec
+	size    prometheus.GaugeFunc
+	entries prometheus.GaugeFunc
+
+	logger logrus.FieldLogger
+}
+
+// New creates a client caching results of cli
+func New(cfg *Config, cli client.Client) *Client {
+	c := &Client{
+		Client: cli,
+		cfg:    cfg,
+		cache:  newLRU(cfg.MaxSize),
+		hits: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "eth_el_cache_hits_total",
+				Help: "Number of execution layer queries served from cache",
+			},
+			[]string{"method"},
+		),
+		misses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "eth_el_cache_misses_total",
+				Help: "Number of cacheable execution layer queries not found in cache",
+			},
+			[]string{"method"},
+		),
+	}
+
+	c.size = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "eth_el_cache_size_bytes",
+			Help: "Approximate number of bytes held by the execution layer cache",
+		},
+		func() float64 { return float64(c.cache.bytes()) },
+	)
+	c.entries = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "eth_el_cache_entries",
+			Help: "Number of entries held by the execution layer cache",
+		},
+		func() float64 { return float64(c.cache.len()) },
+	)
+
+	c.SetLogger(logrus.StandardLogger())
+
+	return c
+}
+
+func (c *Client) Logger() logrus.FieldLogger {
+	return c.logger
+}
+
+func (c *Client) SetLogger(logger logrus.FieldLogger) {
+	c.logger = logger.WithField("component", "eth-el-cache")
+}
+
+// RegisterMetrics registers cache metrics
+func (c *Client) RegisterMetrics(reg prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{c.hits, c.misses, c.size, c.entries} {
+		if err := reg.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cached returns the value cached under key or fetches it, caching it if fetch succeeds
+func (c *Client) cached(method, key string, fetch func() (value interface{}, size int, err error)) (interface{}, error) {
+	key = method + ":" + key
+	if value, ok := c.cache.get(key); ok {
+		c.hits.WithLabelValues(method).Inc()
+		return value, nil
+	}
+	c.misses.WithLabelValues(method).Inc()
+
+	value, size, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.add(key, value, len(key)+size+entryOverhead)
+
+	return value, nil
+}
+
+// isFinalized indicates whether number is a specific block number at or below the finalized block
+//
+// The finalized block number is refreshed at most every cfg.FinalizedRefreshInterval and
+// only if number is above the last known finalized block.
+func (c *Client) isFinalized(ctx context.Context, number *big.Int) bool {
+	if number == nil || number.Sign() < 0 || !number.IsUint64() {
+		return false
+	}
+
+	c.mu.Lock()
+	if !c.finalizedAt.IsZero() && number.Uint64() <= c.finalized {
+		c.mu.Unlock()
+		return true
+	}
+
+	// a single caller refreshes the finalized block, others consider number is not finalized meanwhile
+	if c.refreshing || time.Since(c.finalizedAt) < c.cfg.FinalizedRefreshInterval.Duration {
+		c.mu.Unlock()
+		return false
+	}
+	c.refreshing = true
+	c.mu.Unlock()
+
+	header, err := c.Client.HeaderByNumber(ctx, big.NewInt(int64(gethrpc.FinalizedBlockNumber)))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = false
+
+	if err != nil {
+		c.logger.WithError(err).Debugf("failed to get finalized block")
+		return false
+	}
+
+	c.finalized = header.Number.Uint64()
+	c.finalizedAt = time.Now()
+
+	return number.Uint64() <= c.finalized
+}
+
+func (c *Client) BlockByHash(ctx context.Context, hash gethcommon.Hash) (*gethtypes.Block, error) {
+	value, err := c.cached("BlockByHash", hash.Hex(), func() (interface{}, int, error) {
+		block, err := c.Client.BlockByHash(ctx, hash)
+		if err != nil {
+			return nil, 0, err
+		}
+		return block, int(block.Size()), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*gethtypes.Block), nil
+}
+
+func (c *Client) BlockByNumber(ctx context.Context, number *big.Int) (*gethtypes.Block, error) {
+	if !c.isFinalized(ctx, number) {
+		return c.Client.BlockByNumber(ctx, number)
+	}
+
+	value, err := c.cached("BlockByNumber", number.String(), func() (interface{}, int, error) {
+		block, err := c.Client.BlockByNumber(ctx, number)
+		if err != nil {
+			return nil, 0, err
+		}
+		return block, int(block.Size()), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*gethtypes.Block), nil
+}
+
+func (c *Client) HeaderByHash(ctx context.Context, hash gethcommon.Hash) (*gethtypes.Header, error) {
+	value, err := c.cached("HeaderByHash", hash.Hex(), func() (interface{}, int, error) {
+		header, err := c.Client.HeaderByHash(ctx, hash)
+		if err != nil {
+			return nil, 0, err
+		}
+		return header, int(header.Size()), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// headers are mutable so callers get their own copy
+	return gethtypes.CopyHeader(value.(*gethtypes.Header)), nil
+}
+
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*gethtypes.Header, error) {
+	if !c.isFinalized(ctx, number) {
+		return c.Client.HeaderByNumber(ctx, number)
+	}
+
+	value, err := c.cached("HeaderByNumber", number.String(), func() (interface{}, int, error) {
+		header, err := c.Client.HeaderByNumber(ctx, number)
+		if err != nil {
+			return nil, 0, err
+		}
+		return header, int(header.Size()), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gethtypes.CopyHeader(value.(*gethtypes.Header)), nil
+}
+
+func (c *Client) TransactionCount(ctx context.Context, blockHash gethcommon.Hash) (uint, error) {
+	value, err := c.cached("TransactionCount", blockHash.Hex(), func() (interface{}, int, error) {
+		count, err := c.Client.TransactionCount(ctx, blockHash)
+		return count, 8, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return value.(uint), nil
+}
+
+func (c *Client) TransactionInBlock(ctx context.Context, blockHash gethcommon.Hash, index uint) (*gethtypes.Transaction, error) {
+	value, err := c.cached("TransactionInBlock", fmt.Sprintf("%v:%v", blockHash.Hex(), index), func() (interface{}, int, error) {
+		tx, err := c.Client.TransactionInBlock(ctx, blockHash, index)
+		if err != nil {
+			return nil, 0, err
+		}
+		return tx, int(tx.Size()), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*gethtypes.Transaction), nil
+}
+
+// TransactionReceipt returns the receipt of a transaction, receipts are only cached once their block is finalized
+func (c *Client) TransactionReceipt(ctx context.Context, txHash gethcommon.Hash) (*gethtypes.Receipt, error) {
+	key := "TransactionReceipt:" + txHash.Hex()
+	if value, ok := c.cache.get(key); ok {
+		c.hits.WithLabelValues("TransactionReceipt").Inc()
+		return copyReceipt(value.(*gethtypes.Receipt)), nil
+	}
+	c.misses.WithLabelValues("TransactionReceipt").Inc()
+
+	receipt, err := c.Client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.isFinalized(ctx, receipt.BlockNumber) {
+		// receipts are mutable so the cache keeps its own copy
+		c.cache.add(key, copyReceipt(receipt), len(key)+receiptSize(receipt)+entryOverhead)
+	}
+
+	return receipt, nil
+}
+
+func (c *Client) CallContract(ctx context.Context, msg geth.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if !c.isFinalized(ctx, blockNumber) {
+		return c.Client.CallContract(ctx, msg, blockNumber)
+	}
+
+	msgKey, err := callMsgKey(&msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.cachedBytes("CallContract", fmt.Sprintf("%v:%v", blockNumber, msgKey), func() ([]byte, error) {
+		return c.Client.CallContract(ctx, msg, blockNumber)
+	})
+}
+
+// CallContractAtHash executes a contract call at the block with the given hash
+//
+// It requires the underlying client to implement CallContractAtHash.
+func (c *Client) CallContractAtHash(ctx context.Context, msg geth.CallMsg, blockHash gethcommon.Hash) ([]byte, error) {
+	caller, ok := c.Client.(interface {
+		CallContractAtHash(ctx context.Context, msg geth.CallMsg, blockHash gethcommon.Hash) ([]byte, error)
+	})
+	if !ok {
+		return nil, errors.New("client does not support calls at block hash")
+	}
+
+	msgKey, err := callMsgKey(&msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.cachedBytes("CallContractAtHash", fmt.Sprintf("%v:%v", blockHash.Hex(), msgKey), func() ([]byte, error) {
+		return caller.CallContractAtHash(ctx, msg, blockHash)
+	})
+}
+
+func (c *Client) CodeAt(ctx context.Context, account gethcommon.Address, blockNumber *big.Int) ([]byte, error) {
+	if !c.isFinalized(ctx, blockNumber) {
+		return c.Client.CodeAt(ctx, account, blockNumber)
+	}
+
+	return c.cachedBytes("CodeAt", fmt.Sprintf("%v:%v", blockNumber, account.Hex()), func() ([]byte, error) {
+		return c.Client.CodeAt(ctx, account, blockNumber)
+	})
+}
+
+func (c *Client) cachedBytes(method, key string, fetch func() ([]byte, error)) ([]byte, error) {
+	value, err := c.cached(method, key, func() (interface{}, int, error) {
+		b, err := fetch()
+		return b, len(b), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// byte slices are mutable so callers get their own copy
+	return gethcommon.CopyBytes(value.([]byte)), nil
+}
+
+// callMsgKey returns a key identifying all fields of msg
+func callMsgKey(msg *geth.CallMsg) (string, error) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute cache key of call: %w", err)
+	}
+	return gethcrypto.Keccak256Hash(b).Hex(), nil
+}
+
+// copyReceipt returns a deep copy of receipt and of its logs
+func copyReceipt(receipt *gethtypes.Receipt) *gethtypes.Receipt {
+	cpy := *receipt
+	cpy.PostState = gethcommon.CopyBytes(receipt.PostState)
+	for _, b := range []**big.Int{&cpy.EffectiveGasPrice, &cpy.BlobGasPrice, &cpy.BlockNumber} {
+		if *b != nil {
+			*b = new(big.Int).Set(*b)
+		}
+	}
+
+	if receipt.Logs != nil {
+		cpy.Logs = make([]*gethtypes.Log, len(receipt.Logs))
+		for i, log := range receipt.Logs {
+			logCpy := *log
+			logCpy.Topics = append([]gethcommon.Hash(nil), log.Topics...)
+			logCpy.Data = gethcommon.CopyBytes(log.Data)
+			cpy.Logs[i] = &logCpy
+		}
+	}
+
+	return &cpy
+}
+
+// receiptSize returns the approximate number of bytes held by receipt
+func receiptSize(receipt *gethtypes.Receipt) int {
+	size := 512 // fixed size fields and bloom
+	for _, log := range receipt.Logs {
+		size += 160 + len(log.Data) + len(log.Topics)*gethcommon.HashLength
+	}
+	return size
+}
@@ -0,0 +1,222 @@
+//go:build !integration
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang/mock/gomock"
+	kilntypes "github.com/kilnfi/go-utils/common/types"
+	"github.com/kilnfi/go-utils/ethereum/execution/client/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testAddr      = gethcommon.HexToAddress("0x4592d8f8d7b001e72cb26a73e4fa1806a51ac79d")
+	finalizedArg  = big.NewInt(int64(gethrpc.FinalizedBlockNumber))
+	testBlockHash = gethcommon.HexToHash("0x0fb6d5bd7c8e5a7bd9d2e1ad5e2ac3d5b3b6c8c5b0e5e8c2b7e1c1d3c9f5a6b7")
+)
+
+func newTestClient(t *testing.T, cfg *Config) (*Client, *mock.MockClient) {
+	t.Helper()
+	cli := mock.NewMockClient(gomock.NewController(t))
+	return New(cfg.SetDefault(), cli), cli
+}
+
+func TestCacheByHash(t *testing.T) {
+	c, cli := newTestClient(t, &Config{})
+
+	header := &gethtypes.Header{Number: big.NewInt(100), Difficulty: big.NewInt(0)}
+	cli.EXPECT().HeaderByHash(gomock.Any(), testBlockHash).Return(header, nil).Times(1)
+
+	for i := 0; i < 2; i++ {
+		res, err := c.HeaderByHash(t.Context(), testBlockHash)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), res.Number.Int64())
+		// callers get a copy
+		res.Number = big.NewInt(0)
+	}
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.hits.WithLabelValues("HeaderByHash")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.misses.WithLabelValues("HeaderByHash")))
+}
+
+func TestCacheErrorsNotCached(t *testing.T) {
+	c, cli := newTestClient(t, &Config{})
+
+	cli.EXPECT().BlockByHash(gomock.Any(), testBlockHash).Return(nil, geth.NotFound).Times(2)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.BlockByHash(t.Context(), testBlockHash)
+		assert.True(t, errors.Is(err, geth.NotFound))
+	}
+}
+
+func TestCacheFinalized(t *testing.T) {
+	c, cli := newTestClient(t, &Config{})
+
+	cli.EXPECT().HeaderByNumber(gomock.Any(), finalizedArg).Return(&gethtypes.Header{Number: big.NewInt(100)}, nil).Times(1)
+
+	code := []byte{0x60, 0x80}
+	cli.EXPECT().CodeAt(gomock.Any(), testAddr, big.NewInt(90)).Return(code, nil).Times(1)
+	for i := 0; i < 2; i++ {
+		res, err := c.CodeAt(t.Context(), testAddr, big.NewInt(90))
+		require.NoError(t, err)
+		assert.Equal(t, code, res)
+	}
+
+	// blocks above finalized are not cached, and the finalized block is not refreshed before the interval
+	cli.EXPECT().CodeAt(gomock.Any(), testAddr, big.NewInt(101)).Return(code, nil).Times(2)
+	for i := 0; i < 2; i++ {
+		_, err := c.CodeAt(t.Context(), testAddr, big.NewInt(101))
+		require.NoError(t, err)
+	}
+
+	// latest and pending are never cached
+	cli.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Nil()).Return(code, nil).Times(2)
+	cli.EXPECT().CallContract(gomock.Any(), gomock.Any(), big.NewInt(-1)).Return(code, nil).Times(2)
+	for i := 0; i < 2; i++ {
+		_, err := c.CallContract(t.Context(), geth.CallMsg{To: &testAddr}, nil)
+		require.NoError(t, err)
+		_, err = c.CallContract(t.Context(), geth.CallMsg{To: &testAddr}, big.NewInt(-1))
+		require.NoError(t, err)
+	}
+
+	// calls are cached per message
+	cli.EXPECT().CallContract(gomock.Any(), geth.CallMsg{To: &testAddr, Data: []byte{0x1}}, big.NewInt(50)).Return([]byte{0x1}, nil).Times(1)
+	cli.EXPECT().CallContract(gomock.Any(), geth.CallMsg{To: &testAddr, Data: []byte{0x2}}, big.NewInt(50)).Return([]byte{0x2}, nil).Times(1)
+	for i := 0; i < 2; i++ {
+		res, err := c.CallContract(t.Context(), geth.CallMsg{To: &testAddr, Data: []byte{0x1}}, big.NewInt(50))
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x1}, res)
+		res, err = c.CallContract(t.Context(), geth.CallMsg{To: &testAddr, Data: []byte{0x2}}, big.NewInt(50))
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x2}, res)
+	}
+}
+
+func TestCacheFinalizedRefreshDoesNotBlock(t *testing.T) {
+	c, cli := newTestClient(t, &Config{FinalizedRefreshInterval: &kilntypes.Duration{}})
+
+	refreshing, release := make(chan struct{}), make(chan struct{})
+	gomock.InOrder(
+		cli.EXPECT().HeaderByNumber(gomock.Any(), finalizedArg).Return(&gethtypes.Header{Number: big.NewInt(100)}, nil),
+		cli.EXPECT().HeaderByNumber(gomock.Any(), finalizedArg).DoAndReturn(
+			func(context.Context, *big.Int) (*gethtypes.Header, error) {
+				close(refreshing)
+				<-release
+				return &gethtypes.Header{Number: big.NewInt(110)}, nil
+			},
+		),
+	)
+
+	code := []byte{0x60, 0x80}
+	cli.EXPECT().CodeAt(gomock.Any(), testAddr, big.NewInt(90)).Return(code, nil).Times(1)
+	cli.EXPECT().CodeAt(gomock.Any(), testAddr, big.NewInt(105)).Return(code, nil).Times(2)
+
+	_, err := c.CodeAt(t.Context(), testAddr, big.NewInt(90))
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := c.CodeAt(t.Context(), testAddr, big.NewInt(105))
+		done <- err
+	}()
+	<-refreshing
+
+	// finalized blocks are served from cache and other blocks are not stalled while the finalized block is refreshed
+	res, err := c.CodeAt(t.Context(), testAddr, big.NewInt(90))
+	require.NoError(t, err)
+	assert.Equal(t, code, res)
+	_, err = c.CodeAt(t.Context(), testAddr, big.NewInt(105))
+	require.NoError(t, err)
+
+	close(release)
+	require.NoError(t, <-done)
+}
+
+func TestCacheReceipt(t *testing.T) {
+	// the finalized block is refreshed on each query of a non-finalized block
+	c, cli := newTestClient(t, &Config{FinalizedRefreshInterval: &kilntypes.Duration{}})
+
+	finalizedTx := gethcommon.HexToHash("0x1")
+	recentTx := gethcommon.HexToHash("0x2")
+
+	cli.EXPECT().HeaderByNumber(gomock.Any(), finalizedArg).Return(&gethtypes.Header{Number: big.NewInt(100)}, nil).AnyTimes()
+	cli.EXPECT().TransactionReceipt(gomock.Any(), finalizedTx).Return(&gethtypes.Receipt{TxHash: finalizedTx, BlockNumber: big.NewInt(99)}, nil).Times(1)
+	cli.EXPECT().TransactionReceipt(gomock.Any(), recentTx).Return(&gethtypes.Receipt{TxHash: recentTx, BlockNumber: big.NewInt(101)}, nil).Times(2)
+
+	for i := 0; i < 2; i++ {
+		receipt, err := c.TransactionReceipt(t.Context(), finalizedTx)
+		require.NoError(t, err)
+		assert.Equal(t, finalizedTx, receipt.TxHash)
+
+		receipt, err = c.TransactionReceipt(t.Context(), recentTx)
+		require.NoError(t, err)
+		assert.Equal(t, recentTx, receipt.TxHash)
+	}
+}
+
+func TestCacheReceiptCopy(t *testing.T) {
+	c, cli := newTestClient(t, &Config{})
+
+	txHash := gethcommon.HexToHash("0x1")
+	cli.EXPECT().HeaderByNumber(gomock.Any(), finalizedArg).Return(&gethtypes.Header{Number: big.NewInt(100)}, nil).Times(1)
+	cli.EXPECT().TransactionReceipt(gomock.Any(), txHash).Return(&gethtypes.Receipt{
+		TxHash:      txHash,
+		BlockNumber: big.NewInt(99),
+		Logs:        []*gethtypes.Log{{Address: testAddr, Topics: []gethcommon.Hash{{0x1}}, Data: []byte{0x1}}},
+	}, nil).Times(1)
+
+	// callers mutating receipts do not corrupt the cache
+	for i := 0; i < 2; i++ {
+		receipt, err := c.TransactionReceipt(t.Context(), txHash)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(99), receipt.BlockNumber)
+		require.Len(t, receipt.Logs, 1)
+		assert.Equal(t, []gethcommon.Hash{{0x1}}, receipt.Logs[0].Topics)
+		assert.Equal(t, []byte{0x1}, receipt.Logs[0].Data)
+
+		receipt.BlockNumber.SetInt64(1)
+		receipt.Logs[0].Topics[0] = gethcommon.Hash{0x2}
+		receipt.Logs[0].Data[0] = 0x2
+		receipt.Logs = append(receipt.Logs, &gethtypes.Log{})
+	}
+}
+
+func TestLRU(t *testing.T) {
+	l := newLRU(10)
+
+	l.add("a", 1, 4)
+	l.add("b", 2, 4)
+	_, ok := l.get("a")
+	require.True(t, ok)
+
+	// b is the least recently used entry
+	l.add("c", 3, 4)
+	_, ok = l.get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, l.len())
+	assert.Equal(t, 8, l.bytes())
+
+	// too large values are not cached
+	l.add("d", 4, 11)
+	_, ok = l.get("d")
+	assert.False(t, ok)
+
+	// replacing a value updates size
+	l.add("a", 5, 2)
+	value, ok := l.get("a")
+	require.True(t, ok)
+	assert.Equal(t, 5, value)
+	assert.Equal(t, 6, l.bytes())
+}
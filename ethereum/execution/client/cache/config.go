@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"time"
+
+	kilntypes "github.com/kilnfi/go-utils/common/types"
+)
+
+// Config for the caching client
+type Config struct {
+	// MaxSize is the approximate maximum number of bytes held by the cache
+	MaxSize int
+
+	// FinalizedRefreshInterval is the minimum interval between two queries of the finalized block number
+	FinalizedRefreshInterval *kilntypes.Duration
+}
+
+func (cfg *Config) SetDefault() *Config {
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = 64 * 1024 * 1024
+	}
+
+	if cfg.FinalizedRefreshInterval == nil {
+		cfg.FinalizedRefreshInterval = &kilntypes.Duration{Duration: 12 * time.Second}
+	}
+
+	return cfg
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lru is a least recently used cache bounded by the accumulated size of its entries
+type lru struct {
+	maxSize int
+
+	mu      sync.Mutex
+	size    int
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type entry struct {
+	key   string
+	value interface{}
+	size  int
+}
+
+func newLRU(maxSize int) *lru {
+	return &lru{
+		maxSize: maxSize,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *lru) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.ll.MoveToFront(elem)
+
+	return elem.Value.(*entry).value, true
+}
+
+// add inserts value, evicting least recently used entries until the cache fits in maxSize.
+// Values larger than maxSize are not cached.
+func (c *lru) add(key string, value interface{}, size int) {
+	if size > c.maxSize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.size -= elem.Value.(*entry).size
+		c.ll.Remove(elem)
+		delete(c.entries, key)
+	}
+
+	c.entries[key] = c.ll.PushFront(&entry{key: key, value: value, size: size})
+	c.size += size
+
+	for c.size > c.maxSize {
+		oldest := c.ll.Back()
+		e := oldest.Value.(*entry)
+		c.ll.Remove(oldest)
+		delete(c.entries, e.key)
+		c.size -= e.size
+	}
+}
+
+func (c *lru) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *lru) bytes() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
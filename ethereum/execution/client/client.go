@@ -42,6 +42,7 @@ type Client interface {
 	BlockNumber(ctx context.Context) (uint64, error)
 	ChainID(ctx context.Context) (*big.Int, error)
 	NetworkID(ctx context.Context) (*big.Int, error)
+	BlobBaseFee(ctx context.Context) (*big.Int, error)
 
 	// PrepareContextForOutbound returns a context that carries the current trace ID as HTTP headers
 	// for outbound RPC (e.g. so X-Trace-ID is sent to eth-proxy). Call before passing ctx to any Client method.
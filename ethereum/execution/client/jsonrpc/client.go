@@ -20,15 +20,22 @@ import (
 	"github.com/kilnfi/go-utils/net/jsonrpc"
 	jsonrpchttp "github.com/kilnfi/go-utils/net/jsonrpc/http"
 	"github.com/kilnfi/go-utils/tracing"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
 // Ensure Client interface is fully implemented
 var _ client.Client = (*Client)(nil)
 
+// ErrBatchNotSupported is returned by batch methods when the JSON-RPC client does not support batches
+var ErrBatchNotSupported = errors.New("JSON-RPC client does not support batch calls")
+
 // Client provides methods to interface with a JSON-RPC Ethereum 1.0 node
 type Client struct {
 	client jsonrpc.Client
+	batch  jsonrpc.BatchClient
+
+	metrics *jsonrpc.Metrics
 
 	chainID *big.Int
 	mu      sync.Mutex
@@ -36,9 +43,13 @@ type Client struct {
 
 // New creates a new client
 func NewFromClient(cli jsonrpc.Client) *Client {
-	return &Client{
+	c := &Client{
 		client: cli,
 	}
+	if batch, ok := cli.(jsonrpc.BatchClient); ok {
+		c.batch = batch
+	}
+	return c
 }
 
 // NewFromAddress creates a new client connecting to an Ethereum node at addr
@@ -48,7 +59,39 @@ func New(cfg *jsonrpchttp.Config) (*Client, error) {
 		return nil, err
 	}
 
-	return NewFromClient(jsonrpc.WithIncrementalID()(jsonrpc.WithVersion("2.0")(jsonrpcc))), nil
+	metrics := jsonrpc.NewMetrics()
+
+	decorators := []jsonrpc.ClientDecorator{
+		jsonrpc.WithIncrementalID(),
+		jsonrpc.WithVersion("2.0"),
+	}
+	if cfg.Retry != nil {
+		decorators = append(decorators, jsonrpc.WithRetry(cfg.Retry))
+	}
+	if cfg.RateLimit != nil {
+		decorators = append(decorators, jsonrpc.WithRateLimit(cfg.RateLimit))
+	}
+	if cfg.Log != nil {
+		decorators = append(decorators, jsonrpc.WithLogging(jsonrpcc.Logger(), cfg.Log))
+	}
+	decorators = append(decorators, jsonrpc.WithMetrics(metrics))
+	if cfg.Timeout != nil && cfg.Timeout.Duration > 0 {
+		decorators = append(decorators, jsonrpc.WithTimeout(cfg.Timeout.Duration))
+	}
+
+	// decorators preserve batch support so batches are also retried, rate limited, logged, measured and bounded
+	c := NewFromClient(jsonrpc.Chain(jsonrpcc, decorators...))
+	c.metrics = metrics
+
+	return c, nil
+}
+
+// RegisterMetrics registers metrics of JSON-RPC calls (only available for clients created with New)
+func (c *Client) RegisterMetrics(reg prometheus.Registerer) error {
+	if c.metrics == nil {
+		return nil
+	}
+	return c.metrics.RegisterMetrics(reg)
 }
 
 func (c *Client) Logger() logrus.FieldLogger {
@@ -85,6 +128,20 @@ func (c *Client) call(ctx context.Context, res interface{}, method string, param
 	)
 }
 
+// batchCall sends requests in a single JSON-RPC batch.
+// It fails if the underlying JSON-RPC client does not support batches.
+func (c *Client) batchCall(ctx context.Context, elems []*jsonrpc.BatchElem) error {
+	if c.batch == nil {
+		return ErrBatchNotSupported
+	}
+
+	for _, elem := range elems {
+		elem.Req.Version = "2.0"
+	}
+
+	return c.batch.CallBatch(ctx, elems)
+}
+
 // ChainID retrieves the current chain ID
 func (c *Client) ChainID(ctx context.Context) (*big.Int, error) {
 	c.mu.Lock()
@@ -170,7 +227,7 @@ func (c *Client) CallContract(ctx context.Context, msg geth.CallMsg, blockNumber
 // the block by block hash instead of block height.
 func (c *Client) CallContractAtHash(ctx context.Context, msg geth.CallMsg, blockHash gethcommon.Hash) ([]byte, error) {
 	var res gethhexutil.Bytes
-	err := c.call(ctx, res, "eth_call", toCallArg(&msg), gethrpc.BlockNumberOrHashWithHash(blockHash, false))
+	err := c.call(ctx, &res, "eth_call", toCallArg(&msg), gethrpc.BlockNumberOrHashWithHash(blockHash, false))
 	if err != nil {
 		return nil, err
 	}
@@ -236,6 +293,17 @@ func (c *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 	return (*big.Int)(res), nil
 }
 
+// BlobBaseFee returns the blob base fee for a blob transaction to be included in the next block
+func (c *Client) BlobBaseFee(ctx context.Context) (*big.Int, error) {
+	res := new(gethhexutil.Big)
+	err := c.call(ctx, res, "eth_blobBaseFee")
+	if err != nil {
+		return nil, err
+	}
+
+	return (*big.Int)(res), nil
+}
+
 // EstimateGas tries to estimate the gas needed to execute a specific transaction based on
 // the current pending state of the chain.
 func (c *Client) EstimateGas(ctx context.Context, msg geth.CallMsg) (uint64, error) {
@@ -479,6 +547,24 @@ func toCallArg(msg *geth.CallMsg) interface{} {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*gethhexutil.Big)(msg.GasPrice)
 	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*gethhexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*gethhexutil.Big)(msg.GasTipCap)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
+	if msg.BlobGasFeeCap != nil {
+		arg["maxFeePerBlobGas"] = (*gethhexutil.Big)(msg.BlobGasFeeCap)
+	}
+	if msg.BlobHashes != nil {
+		arg["blobVersionedHashes"] = msg.BlobHashes
+	}
+	if msg.AuthorizationList != nil {
+		arg["authorizationList"] = msg.AuthorizationList
+	}
 	return arg
 }
 
@@ -575,3 +661,35 @@ func (c *Client) getBlock(ctx context.Context, method string, args ...interface{
 			Withdrawals:  body.Withdrawals,
 		}), nil
 }
+
+// BatchCallContract executes several contract calls in a single JSON-RPC batch.
+// The block number can be nil, in which case calls are executed at the latest block.
+//
+// It returns an error only if the batch as a whole failed, the error of each call is set on its result.
+func (c *Client) BatchCallContract(ctx context.Context, msgs []geth.CallMsg, blockNumber *big.Int) ([]*types.CallResult, error) {
+	res := make([]gethhexutil.Bytes, len(msgs))
+	elems := make([]*jsonrpc.BatchElem, len(msgs))
+	for i := range msgs {
+		elems[i] = &jsonrpc.BatchElem{
+			Req: &jsonrpc.Request{
+				Method: "eth_call",
+				Params: []interface{}{toCallArg(&msgs[i]), types.ToBlockNumArg(blockNumber)},
+			},
+			Result: &res[i],
+		}
+	}
+
+	if err := c.batchCall(ctx, elems); err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.CallResult, len(msgs))
+	for i, elem := range elems {
+		results[i] = &types.CallResult{
+			ReturnData: res[i],
+			Err:        elem.Error,
+		}
+	}
+
+	return results, nil
+}
@@ -4,6 +4,7 @@ package jsonrpc
 
 import (
 	"embed"
+	"fmt"
 	"math/big"
 	"testing"
 
@@ -13,6 +14,7 @@ import (
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/golang/mock/gomock"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
 	httptestutils "github.com/kilnfi/go-utils/net/http/testutils"
 	jsonrpchttp "github.com/kilnfi/go-utils/net/jsonrpc/http"
 	"github.com/stretchr/testify/assert"
@@ -46,8 +48,18 @@ func TestClient(t *testing.T) {
 	t.Run("PendingNonceAt", func(t *testing.T) { testPendingNonceAt(t, c, mockCli) })
 	t.Run("SuggestGasPrice", func(t *testing.T) { testSuggestGasPrice(t, c, mockCli) })
 	t.Run("SuggestGasTipCap", func(t *testing.T) { testSuggestGasTipCap(t, c, mockCli) })
+	t.Run("BlobBaseFee", func(t *testing.T) { testBlobBaseFee(t, c, mockCli) })
 	t.Run("EstimateGas", func(t *testing.T) { testEstimateGas(t, c, mockCli) })
 	t.Run("SendTransaction", func(t *testing.T) { testSendTransaction(t, c, mockCli) })
+	t.Run("GetProof", func(t *testing.T) { testGetProof(t, c, mockCli) })
+	t.Run("CallContractWithOverrides", func(t *testing.T) { testCallContractWithOverrides(t, c, mockCli) })
+	t.Run("EstimateGasWithOverrides", func(t *testing.T) { testEstimateGasWithOverrides(t, c, mockCli) })
+	t.Run("BatchCallContract", func(t *testing.T) { testBatchCallContract(t, c, mockCli) })
+	t.Run("BlockReceipts", func(t *testing.T) { testBlockReceipts(t, c, mockCli) })
+	t.Run("FullBlockByNumberOrHash_Fallback", func(t *testing.T) { testFullBlockByNumberOrHashFallback(t, c, mockCli) })
+	t.Run("TraceTransactionCallTracer", func(t *testing.T) { testTraceTransactionCallTracer(t, c, mockCli) })
+	t.Run("TraceBlockByNumberCallTracer", func(t *testing.T) { testTraceBlockByNumberCallTracer(t, c, mockCli) })
+	t.Run("TraceTransactionPrestateTracer", func(t *testing.T) { testTraceTransactionPrestateTracer(t, c, mockCli) })
 }
 
 func testBlockNumber(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
@@ -320,6 +332,22 @@ func testSuggestGasTipCap(t *testing.T, c *Client, mockCli *httptestutils.MockSe
 	assert.Equal(t, big.NewInt(205014543003), p)
 }
 
+func testBlobBaseFee(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
+	t.Helper()
+	req := httptestutils.NewGockRequest()
+	req.Post("/").
+		JSON([]byte(`{"jsonrpc":"","method":"eth_blobBaseFee","params":null,"id":null}`)).
+		Reply(200).
+		JSON([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x3b9aca00"}`))
+
+	mockCli.EXPECT().Gock(req)
+
+	fee, err := c.BlobBaseFee(t.Context())
+
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000000000), fee)
+}
+
 func testEstimateGas(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
 	t.Helper()
 	req := httptestutils.NewGockRequest()
@@ -362,3 +390,243 @@ func testSendTransaction(t *testing.T, c *Client, mockCli *httptestutils.MockSen
 
 	require.NoError(t, err)
 }
+
+func testGetProof(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
+	t.Helper()
+	req := httptestutils.NewGockRequest()
+	req.Post("/").
+		JSON([]byte(`{"jsonrpc":"","method":"eth_getProof","params":["0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5",["0x0000000000000000000000000000000000000000000000000000000000000001"],"0xd6e166"],"id":null}`)).
+		Reply(200).
+		JSON([]byte(`{"jsonrpc":"2.0","id":1,"result":{"address":"0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5","accountProof":["0xf8718080"],"balance":"0x2a","codeHash":"0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470","nonce":"0x3","storageHash":"0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421","storageProof":[{"key":"0x1","value":"0x0","proof":[]}]}}`))
+
+	mockCli.EXPECT().Gock(req)
+
+	res, err := c.GetProof(
+		t.Context(),
+		gethcommon.HexToAddress("0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5"),
+		[]gethcommon.Hash{gethcommon.BigToHash(big.NewInt(1))},
+		big.NewInt(14082406),
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, gethcommon.HexToAddress("0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5"), res.Address)
+	assert.Equal(t, [][]byte{{0xf8, 0x71, 0x80, 0x80}}, res.AccountProof)
+	assert.Equal(t, big.NewInt(42), res.Balance)
+	assert.Equal(t, uint64(3), res.Nonce)
+	require.Len(t, res.StorageProof, 1)
+	assert.Equal(t, gethcommon.BigToHash(big.NewInt(1)), res.StorageProof[0].Key)
+	assert.Equal(t, int64(0), res.StorageProof[0].Value.Int64())
+}
+
+func testTraceTransactionCallTracer(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
+	t.Helper()
+	req := httptestutils.NewGockRequest()
+	req.Post("/").
+		JSON([]byte(`{"jsonrpc":"","method":"debug_traceTransaction","params":["0x0fb6d5609c9edab75bf587ea7449e6e6940d6e3df1992a1bd96ca8b74ffd16fc",{"tracer":"callTracer"}],"id":null}`)).
+		Reply(200).
+		JSON([]byte(`{"jsonrpc":"2.0","id":1,"result":{"type":"CALL","from":"0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5","to":"0x0000000000000000000000000000000000000001","value":"0x2a","gas":"0x5208","gasUsed":"0x5208","input":"0x","calls":[{"type":"STATICCALL","from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","gas":"0x0","gasUsed":"0x0","input":"0x01"}]}}`))
+
+	mockCli.EXPECT().Gock(req)
+
+	frame, err := c.TraceTransactionCallTracer(t.Context(), gethcommon.HexToHash("0x0fb6d5609c9edab75bf587ea7449e6e6940d6e3df1992a1bd96ca8b74ffd16fc"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "CALL", frame.Type)
+	assert.Equal(t, gethcommon.HexToAddress("0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5"), frame.From)
+	assert.Equal(t, big.NewInt(42), frame.Value.ToInt())
+	assert.Equal(t, uint64(21000), uint64(frame.GasUsed))
+	require.Len(t, frame.Calls, 1)
+	assert.Equal(t, "STATICCALL", frame.Calls[0].Type)
+	assert.Equal(t, []byte{0x01}, []byte(frame.Calls[0].Input))
+}
+
+func testTraceBlockByNumberCallTracer(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
+	t.Helper()
+	req := httptestutils.NewGockRequest()
+	req.Post("/").
+		JSON([]byte(`{"jsonrpc":"","method":"debug_traceBlockByNumber","params":["0xd6e166",{"tracer":"callTracer"}],"id":null}`)).
+		Reply(200).
+		JSON([]byte(`{"jsonrpc":"2.0","id":1,"result":[{"txHash":"0x0fb6d5609c9edab75bf587ea7449e6e6940d6e3df1992a1bd96ca8b74ffd16fc","result":{"type":"CALL","from":"0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5","to":"0x0000000000000000000000000000000000000001","gas":"0x5208","gasUsed":"0x5208","input":"0x"}}]}`))
+
+	mockCli.EXPECT().Gock(req)
+
+	frames, err := c.TraceBlockByNumberCallTracer(t.Context(), big.NewInt(14082406))
+
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	assert.Equal(t, gethcommon.HexToAddress("0x0000000000000000000000000000000000000001"), *frames[0].To)
+}
+
+func testTraceTransactionPrestateTracer(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
+	t.Helper()
+	req := httptestutils.NewGockRequest()
+	req.Post("/").
+		JSON([]byte(`{"jsonrpc":"","method":"debug_traceTransaction","params":["0x0fb6d5609c9edab75bf587ea7449e6e6940d6e3df1992a1bd96ca8b74ffd16fc",{"tracer":"prestateTracer","tracerConfig":{"diffMode":true}}],"id":null}`)).
+		Reply(200).
+		JSON([]byte(`{"jsonrpc":"2.0","id":1,"result":{"pre":{"0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5":{"balance":"0x2a","nonce":1}},"post":{"0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5":{"balance":"0x1","nonce":2}}}}`))
+
+	mockCli.EXPECT().Gock(req)
+
+	trace, err := c.TraceTransactionPrestateTracer(t.Context(), gethcommon.HexToHash("0x0fb6d5609c9edab75bf587ea7449e6e6940d6e3df1992a1bd96ca8b74ffd16fc"), true)
+
+	require.NoError(t, err)
+	addr := gethcommon.HexToAddress("0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5")
+	require.Contains(t, trace.Pre, addr)
+	require.Contains(t, trace.Post, addr)
+	assert.Equal(t, big.NewInt(42), trace.Pre[addr].Balance.ToInt())
+	assert.Equal(t, uint64(2), trace.Post[addr].Nonce)
+}
+
+func testCallContractWithOverrides(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
+	t.Helper()
+	req := httptestutils.NewGockRequest()
+	req.Post("/").
+		JSON([]byte(`{"jsonrpc":"","method":"eth_call","params":[{"data":"0x0123456789","from":"0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5","to":"0x0000000000000000000000000000000000000001"},"latest",{"0x0000000000000000000000000000000000000001":{"nonce":"0x2","code":"0x6000","balance":"0x2a","stateDiff":{"0x0000000000000000000000000000000000000000000000000000000000000001":"0x0000000000000000000000000000000000000000000000000000000000000002"}}},{"number":"0x64","time":"0x3e8","baseFeePerGas":"0x7"}],"id":null}`)).
+		Reply(200).
+		JSON([]byte(`{"jsonrpc":"2.0","id":1,"result":"0xabcd"}`))
+
+	mockCli.EXPECT().Gock(req)
+
+	to := gethcommon.HexToAddress("0x0000000000000000000000000000000000000001")
+	nonce, timestamp := uint64(2), uint64(1000)
+	res, err := c.CallContractWithOverrides(
+		t.Context(),
+		geth.CallMsg{
+			From: gethcommon.HexToAddress("0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5"),
+			To:   &to,
+			Data: gethcommon.FromHex("0x0123456789"),
+		},
+		nil,
+		types.StateOverride{
+			to: {
+				Nonce:   &nonce,
+				Code:    []byte{0x60, 0x00},
+				Balance: big.NewInt(42),
+				StateDiff: map[gethcommon.Hash]gethcommon.Hash{
+					gethcommon.BigToHash(big.NewInt(1)): gethcommon.BigToHash(big.NewInt(2)),
+				},
+			},
+		},
+		&types.BlockOverrides{
+			Number:  big.NewInt(100),
+			Time:    &timestamp,
+			BaseFee: big.NewInt(7),
+		},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xab, 0xcd}, res)
+}
+
+func testEstimateGasWithOverrides(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
+	t.Helper()
+	req := httptestutils.NewGockRequest()
+	req.Post("/").
+		JSON([]byte(`{"jsonrpc":"","method":"eth_estimateGas","params":[{"from":"0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5","to":null},"0xd6e166",{"0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5":{"balance":"0x2a"}}],"id":null}`)).
+		Reply(200).
+		JSON([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x5208"}`))
+
+	mockCli.EXPECT().Gock(req)
+
+	from := gethcommon.HexToAddress("0x52bc44d5378309ee2abf1539bf71de1b7d7be3b5")
+	gas, err := c.EstimateGasWithOverrides(
+		t.Context(),
+		geth.CallMsg{From: from},
+		big.NewInt(14082406),
+		types.StateOverride{from: {Balance: big.NewInt(42)}},
+		nil,
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, uint64(21000), gas)
+}
+
+func testBatchCallContract(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
+	t.Helper()
+	req := httptestutils.NewGockRequest()
+	req.Post("/").
+		JSON([]byte(`[{"jsonrpc":"2.0","method":"eth_call","params":[{"data":"0x01","from":"0x0000000000000000000000000000000000000000","to":"0x0000000000000000000000000000000000000001"},"latest"],"id":0},{"jsonrpc":"2.0","method":"eth_call","params":[{"data":"0x02","from":"0x0000000000000000000000000000000000000000","to":"0x0000000000000000000000000000000000000001"},"latest"],"id":1}]`)).
+		Reply(200).
+		JSON([]byte(`[{"jsonrpc":"2.0","id":0,"result":"0xabcd"},{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted"}}]`))
+
+	mockCli.EXPECT().Gock(req)
+
+	to := gethcommon.HexToAddress("0x0000000000000000000000000000000000000001")
+	res, err := c.BatchCallContract(
+		t.Context(),
+		[]geth.CallMsg{{To: &to, Data: []byte{0x01}}, {To: &to, Data: []byte{0x02}}},
+		nil,
+	)
+
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.NoError(t, res[0].Err)
+	assert.Equal(t, []byte{0xab, 0xcd}, res[0].ReturnData)
+	require.Error(t, res[1].Err)
+}
+
+func testReceipt(txHash string, index int) string {
+	return fmt.Sprintf(
+		`{"blockHash":"0x0fb6d5609c9edab75bf587ea7449e6e6940d6e3df1992a1bd96ca8b74ffd16fc","blockNumber":"0xd6e166","contractAddress":null,"cumulativeGasUsed":"0x5208","effectiveGasPrice":"0x1c30017ca8","from":"0x38563699560e4512c7574c8cc5cf89fd43923bca","gasUsed":"0x5208","logs":[],"logsBloom":"0x%0512x","status":"0x1","to":"0x000000000035b5e5ad9019092c665357240f594e","transactionHash":"%v","transactionIndex":"0x%x","type":"0x2"}`,
+		0, txHash, index,
+	)
+}
+
+func testBlockReceipts(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
+	t.Helper()
+	req := httptestutils.NewGockRequest()
+	req.Post("/").
+		JSON([]byte(`{"jsonrpc":"","method":"eth_getBlockReceipts","params":["0xd6e166"],"id":null}`)).
+		Reply(200).
+		JSON([]byte(`{"jsonrpc":"2.0","id":1,"result":[` + testReceipt("0x1024b01a4af12c4de946cbdfe2e12a3123ad1689a1eebe1c9549efb92fe2ae20", 0) + `]}`))
+
+	mockCli.EXPECT().Gock(req)
+
+	receipts, err := c.BlockReceipts(t.Context(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(14082406)))
+
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	assert.Equal(t, gethcommon.HexToHash("0x1024b01a4af12c4de946cbdfe2e12a3123ad1689a1eebe1c9549efb92fe2ae20"), receipts[0].TxHash)
+	assert.Equal(t, uint64(1), receipts[0].Status)
+}
+
+func testFullBlockByNumberOrHashFallback(t *testing.T, c *Client, mockCli *httptestutils.MockSender) {
+	t.Helper()
+	res, _ := testdataFS.ReadFile("testdata/eth_getBlockByNumber_0xd6e166_true_2txs.json")
+	require.NotEmpty(t, res, "response should not be empty (check typo in testdata filename)")
+
+	blockReq := httptestutils.NewGockRequest()
+	blockReq.Post("/").
+		JSON([]byte(`{"jsonrpc":"","method":"eth_getBlockByNumber","params":["0xd6e166",true],"id":null}`)).
+		Reply(200).
+		JSON(res)
+
+	receiptsReq := httptestutils.NewGockRequest()
+	receiptsReq.Post("/").
+		JSON([]byte(`{"jsonrpc":"","method":"eth_getBlockReceipts","params":["0x0fb6d5609c9edab75bf587ea7449e6e6940d6e3df1992a1bd96ca8b74ffd16fc"],"id":null}`)).
+		Reply(200).
+		JSON([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method eth_getBlockReceipts does not exist/is not available"}}`))
+
+	batchReq := httptestutils.NewGockRequest()
+	batchReq.Post("/").
+		JSON([]byte(`[{"jsonrpc":"2.0","method":"eth_getTransactionReceipt","params":["0x1024b01a4af12c4de946cbdfe2e12a3123ad1689a1eebe1c9549efb92fe2ae20"],"id":0},{"jsonrpc":"2.0","method":"eth_getTransactionReceipt","params":["0xcee5afd9510cd7bd2e41d7633883d53df65c0b630cc3a7a14be4fca887523b65"],"id":1}]`)).
+		Reply(200).
+		JSON([]byte(`[{"jsonrpc":"2.0","id":1,"result":` + testReceipt("0xcee5afd9510cd7bd2e41d7633883d53df65c0b630cc3a7a14be4fca887523b65", 1) + `},{"jsonrpc":"2.0","id":0,"result":` + testReceipt("0x1024b01a4af12c4de946cbdfe2e12a3123ad1689a1eebe1c9549efb92fe2ae20", 0) + `}]`))
+
+	gomock.InOrder(
+		mockCli.EXPECT().Gock(blockReq),
+		mockCli.EXPECT().Gock(receiptsReq),
+		mockCli.EXPECT().Gock(batchReq),
+	)
+
+	block, err := c.FullBlockByNumberOrHash(t.Context(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(14082406)))
+
+	require.NoError(t, err)
+	assert.Equal(t, gethcommon.HexToHash("0x0fb6d5609c9edab75bf587ea7449e6e6940d6e3df1992a1bd96ca8b74ffd16fc"), block.Hash())
+	require.Len(t, block.Receipts, 2)
+	assert.Equal(t, block.Transactions()[1].Hash(), block.Receipts[1].TxHash)
+
+	sender, err := block.Sender(0)
+	require.NoError(t, err)
+	assert.Equal(t, gethcommon.HexToAddress("0x38563699560e4512c7574c8cc5cf89fd43923bca"), sender)
+}
@@ -0,0 +1,54 @@
+package jsonrpc
+
+import (
+	"context"
+	"math/big"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethhexutil "github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
+)
+
+// CallContractWithOverrides is the same as CallContract except that the given state and block overrides
+// are applied before executing the call. Both overrides can be nil.
+func (c *Client) CallContractWithOverrides(
+	ctx context.Context,
+	msg geth.CallMsg,
+	blockNumber *big.Int,
+	overrides types.StateOverride,
+	blockOverrides *types.BlockOverrides,
+) ([]byte, error) {
+	res := new(gethhexutil.Bytes)
+	err := c.call(ctx, res, "eth_call", toOverridesArgs(&msg, blockNumber, overrides, blockOverrides)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(*res), nil
+}
+
+// EstimateGasWithOverrides is the same as EstimateGas except that gas is estimated on top of the given block
+// with state and block overrides applied. The block number can be nil, in which case the latest block is used.
+func (c *Client) EstimateGasWithOverrides(
+	ctx context.Context,
+	msg geth.CallMsg,
+	blockNumber *big.Int,
+	overrides types.StateOverride,
+	blockOverrides *types.BlockOverrides,
+) (uint64, error) {
+	res := new(gethhexutil.Uint64)
+	err := c.call(ctx, res, "eth_estimateGas", toOverridesArgs(&msg, blockNumber, overrides, blockOverrides)...)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(*res), nil
+}
+
+func toOverridesArgs(msg *geth.CallMsg, blockNumber *big.Int, overrides types.StateOverride, blockOverrides *types.BlockOverrides) []interface{} {
+	args := []interface{}{toCallArg(msg), types.ToBlockNumArg(blockNumber), overrides}
+	if blockOverrides != nil {
+		args = append(args, blockOverrides)
+	}
+	return args
+}
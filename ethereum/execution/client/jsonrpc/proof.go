@@ -0,0 +1,109 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethhexutil "github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
+)
+
+type storageResult struct {
+	Key   string              `json:"key"`
+	Value *gethhexutil.Big    `json:"value"`
+	Proof []gethhexutil.Bytes `json:"proof"`
+}
+
+type accountResult struct {
+	Address      gethcommon.Address  `json:"address"`
+	AccountProof []gethhexutil.Bytes `json:"accountProof"`
+	Balance      *gethhexutil.Big    `json:"balance"`
+	CodeHash     gethcommon.Hash     `json:"codeHash"`
+	Nonce        gethhexutil.Uint64  `json:"nonce"`
+	StorageHash  gethcommon.Hash     `json:"storageHash"`
+	StorageProof []storageResult     `json:"storageProof"`
+}
+
+// GetProof returns the account and storage values of the given account including the Merkle-Patricia proofs.
+// The block number can be nil, in which case the values are taken from the latest known block.
+//
+// Returned proofs are not verified, use GetVerifiedProof to verify them against the block state root.
+func (c *Client) GetProof(ctx context.Context, account gethcommon.Address, storageKeys []gethcommon.Hash, blockNumber *big.Int) (*types.AccountResult, error) {
+	// Avoid keys being 'null'
+	if storageKeys == nil {
+		storageKeys = []gethcommon.Hash{}
+	}
+
+	res := new(accountResult)
+	err := c.call(ctx, res, "eth_getProof", account, storageKeys, types.ToBlockNumArg(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.AccountResult{
+		Address:      res.Address,
+		AccountProof: toBytesSlice(res.AccountProof),
+		Balance:      (*big.Int)(res.Balance),
+		CodeHash:     res.CodeHash,
+		Nonce:        uint64(res.Nonce),
+		StorageHash:  res.StorageHash,
+		StorageProof: make([]*types.StorageResult, len(res.StorageProof)),
+	}
+	for i, st := range res.StorageProof {
+		result.StorageProof[i] = &types.StorageResult{
+			// Some nodes return keys as quantities so they are padded
+			Key:   gethcommon.HexToHash(st.Key),
+			Value: (*big.Int)(st.Value),
+			Proof: toBytesSlice(st.Proof),
+		}
+	}
+
+	return result, nil
+}
+
+// GetVerifiedProof is the same as GetProof except that the proofs are verified against the state root
+// of the block header. It allows to read state from an untrusted node, as long as the header is trusted.
+//
+// The header is fetched first and proofs are requested at the header number
+// so that a new head can not be produced between both calls.
+func (c *Client) GetVerifiedProof(ctx context.Context, account gethcommon.Address, storageKeys []gethcommon.Hash, blockNumber *big.Int) (*types.AccountResult, error) {
+	header, err := c.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.GetProof(ctx, account, storageKeys, header.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Address != account {
+		return nil, fmt.Errorf("proof is for account %v but %v was requested", res.Address, account)
+	}
+
+	if len(res.StorageProof) != len(storageKeys) {
+		return nil, fmt.Errorf("expected %v storage proofs but got %v", len(storageKeys), len(res.StorageProof))
+	}
+
+	for i, key := range storageKeys {
+		if res.StorageProof[i].Key != key {
+			return nil, fmt.Errorf("storage proof %v is for slot %v but %v was requested", i, res.StorageProof[i].Key, key)
+		}
+	}
+
+	if err := res.Verify(header.Root); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func toBytesSlice(b []gethhexutil.Bytes) [][]byte {
+	res := make([][]byte, len(b))
+	for i := range b {
+		res[i] = b[i]
+	}
+	return res
+}
@@ -0,0 +1,151 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/kilnfi/go-utils/net/jsonrpc"
+)
+
+// codeMethodNotFound is the JSON-RPC error code returned for unsupported methods
+const codeMethodNotFound = -32601
+
+// FullBlock is a block along with the receipts of its transactions
+type FullBlock struct {
+	*gethtypes.Block
+
+	Receipts []*gethtypes.Receipt
+}
+
+// Sender returns the sender of the i-th transaction of the block, as returned by the node
+func (b *FullBlock) Sender(i int) (gethcommon.Address, error) {
+	txs := b.Transactions()
+	if i < 0 || i >= len(txs) {
+		return gethcommon.Address{}, fmt.Errorf("transaction index %v out of range", i)
+	}
+	return gethtypes.Sender(&senderFromServer{blockhash: b.Hash()}, txs[i])
+}
+
+// BlockReceipts returns the receipts of all transactions in a block.
+//
+// It uses eth_getBlockReceipts and falls back to batched eth_getTransactionReceipt
+// if the node does not support it.
+func (c *Client) BlockReceipts(ctx context.Context, blockNrOrHash gethrpc.BlockNumberOrHash) ([]*gethtypes.Receipt, error) {
+	receipts, err := c.blockReceipts(ctx, blockNrOrHash.String())
+	if !isMethodNotFound(err) {
+		return receipts, err
+	}
+
+	block, err := c.blockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.transactionReceipts(ctx, block)
+}
+
+// FullBlockByNumberOrHash returns a block with all its transactions, their senders and receipts
+// in at most two round trips.
+func (c *Client) FullBlockByNumberOrHash(ctx context.Context, blockNrOrHash gethrpc.BlockNumberOrHash) (*FullBlock, error) {
+	block, err := c.blockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// receipts are queried by hash so they are consistent with the block in case of re-org
+	receipts, err := c.blockReceipts(ctx, block.Hash())
+	if isMethodNotFound(err) {
+		receipts, err = c.transactionReceipts(ctx, block)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(receipts) != block.Transactions().Len() {
+		return nil, fmt.Errorf("got %v receipts for %v transactions in block %v", len(receipts), block.Transactions().Len(), block.Hash())
+	}
+
+	return &FullBlock{
+		Block:    block,
+		Receipts: receipts,
+	}, nil
+}
+
+func (c *Client) blockReceipts(ctx context.Context, blockNrOrHash interface{}) ([]*gethtypes.Receipt, error) {
+	var receipts []*gethtypes.Receipt
+	err := c.call(ctx, &receipts, "eth_getBlockReceipts", blockNrOrHash)
+	if err == nil && receipts == nil {
+		return nil, geth.NotFound
+	}
+	return receipts, err
+}
+
+func (c *Client) blockByNumberOrHash(ctx context.Context, blockNrOrHash gethrpc.BlockNumberOrHash) (*gethtypes.Block, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return c.getBlock(ctx, "eth_getBlockByHash", hash, true)
+	}
+	if number, ok := blockNrOrHash.Number(); ok {
+		return c.getBlock(ctx, "eth_getBlockByNumber", number.String(), true)
+	}
+	return nil, errors.New("invalid block number or hash")
+}
+
+// transactionReceipts returns the receipts of all transactions in block using eth_getTransactionReceipt
+func (c *Client) transactionReceipts(ctx context.Context, block *gethtypes.Block) ([]*gethtypes.Receipt, error) {
+	txs := block.Transactions()
+	receipts := make([]*gethtypes.Receipt, len(txs))
+	if len(txs) == 0 {
+		return receipts, nil
+	}
+
+	elems := make([]*jsonrpc.BatchElem, len(txs))
+	for i, tx := range txs {
+		elems[i] = &jsonrpc.BatchElem{
+			Req: &jsonrpc.Request{
+				Method: "eth_getTransactionReceipt",
+				Params: []interface{}{tx.Hash()},
+			},
+			Result: &receipts[i],
+		}
+	}
+
+	err := c.batchCall(ctx, elems)
+	switch {
+	case errors.Is(err, ErrBatchNotSupported):
+		for i, tx := range txs {
+			receipts[i], err = c.TransactionReceipt(ctx, tx.Hash())
+			if err != nil {
+				return nil, err
+			}
+		}
+	case err != nil:
+		return nil, err
+	default:
+		for i, elem := range elems {
+			if elem.Error != nil {
+				return nil, elem.Error
+			}
+			if receipts[i] == nil {
+				return nil, fmt.Errorf("receipt of transaction %v: %w", txs[i].Hash(), geth.NotFound)
+			}
+		}
+	}
+
+	for _, receipt := range receipts {
+		if receipt.BlockHash != block.Hash() {
+			return nil, fmt.Errorf("receipt of transaction %v is not in block %v (re-org?)", receipt.TxHash, block.Hash())
+		}
+	}
+
+	return receipts, nil
+}
+
+func isMethodNotFound(err error) bool {
+	var errMsg *jsonrpc.ErrorMsg
+	return errors.As(err, &errMsg) && errMsg.Code == codeMethodNotFound
+}
@@ -0,0 +1,131 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
+)
+
+// TraceTransaction replays a transaction with debug_traceTransaction and decodes the tracer output into res.
+//
+// It is the raw mode to be used with custom JS tracers, see TraceTransactionCallTracer and
+// TraceTransactionPrestateTracer for typed results of built-in tracers.
+func (c *Client) TraceTransaction(ctx context.Context, txHash gethcommon.Hash, cfg *types.TraceConfig, res interface{}) error {
+	return c.call(ctx, res, "debug_traceTransaction", txHash, toTraceConfigArg(cfg))
+}
+
+// TraceCall executes a call with debug_traceCall on top of the given block and decodes the tracer output into res.
+// The block number can be nil, in which case the call is traced at the latest block.
+func (c *Client) TraceCall(ctx context.Context, msg geth.CallMsg, blockNumber *big.Int, cfg *types.TraceConfig, res interface{}) error {
+	return c.call(ctx, res, "debug_traceCall", toCallArg(&msg), types.ToBlockNumArg(blockNumber), toTraceConfigArg(cfg))
+}
+
+// TraceBlockByNumber replays all transactions of a block with debug_traceBlockByNumber.
+//
+// Each result holds the raw tracer output of a transaction, or the error that occurred while tracing it.
+func (c *Client) TraceBlockByNumber(ctx context.Context, blockNumber *big.Int, cfg *types.TraceConfig) ([]*types.TxTraceResult, error) {
+	var res []*types.TxTraceResult
+	err := c.call(ctx, &res, "debug_traceBlockByNumber", types.ToBlockNumArg(blockNumber), toTraceConfigArg(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// TraceTransactionCallTracer returns the call tree of a transaction using the callTracer
+func (c *Client) TraceTransactionCallTracer(ctx context.Context, txHash gethcommon.Hash) (*types.CallFrame, error) {
+	res := new(types.CallFrame)
+	if err := c.TraceTransaction(ctx, txHash, &types.TraceConfig{Tracer: types.CallTracer}, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// TraceCallCallTracer returns the call tree of a call using the callTracer
+func (c *Client) TraceCallCallTracer(ctx context.Context, msg geth.CallMsg, blockNumber *big.Int) (*types.CallFrame, error) {
+	res := new(types.CallFrame)
+	if err := c.TraceCall(ctx, msg, blockNumber, &types.TraceConfig{Tracer: types.CallTracer}, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// TraceBlockByNumberCallTracer returns the call trees of all transactions of a block using the callTracer
+func (c *Client) TraceBlockByNumberCallTracer(ctx context.Context, blockNumber *big.Int) ([]*types.CallFrame, error) {
+	results, err := c.TraceBlockByNumber(ctx, blockNumber, &types.TraceConfig{Tracer: types.CallTracer})
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]*types.CallFrame, len(results))
+	for i, res := range results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("failed to trace transaction %v: %v", res.TxHash, res.Error)
+		}
+
+		frames[i] = new(types.CallFrame)
+		if err := json.Unmarshal(res.Result, frames[i]); err != nil {
+			return nil, fmt.Errorf("invalid trace for transaction %v: %w", res.TxHash, err)
+		}
+	}
+
+	return frames, nil
+}
+
+// TraceTransactionPrestateTracer returns the state accessed by a transaction using the prestateTracer.
+//
+// In diff mode, it returns the state modified by the transaction before and after its execution.
+func (c *Client) TraceTransactionPrestateTracer(ctx context.Context, txHash gethcommon.Hash, diffMode bool) (*types.PrestateTrace, error) {
+	return decodePrestateTrace(diffMode, func(res interface{}) error {
+		return c.TraceTransaction(ctx, txHash, prestateTraceConfig(diffMode), res)
+	})
+}
+
+// TraceCallPrestateTracer returns the state accessed by a call using the prestateTracer.
+//
+// In diff mode, it returns the state modified by the call before and after its execution.
+func (c *Client) TraceCallPrestateTracer(ctx context.Context, msg geth.CallMsg, blockNumber *big.Int, diffMode bool) (*types.PrestateTrace, error) {
+	return decodePrestateTrace(diffMode, func(res interface{}) error {
+		return c.TraceCall(ctx, msg, blockNumber, prestateTraceConfig(diffMode), res)
+	})
+}
+
+func prestateTraceConfig(diffMode bool) *types.TraceConfig {
+	cfg := &types.TraceConfig{Tracer: types.PrestateTracer}
+	if diffMode {
+		cfg.TracerConfig = json.RawMessage(`{"diffMode":true}`)
+	}
+	return cfg
+}
+
+// decodePrestateTrace decodes prestateTracer output whose format depends on the diff mode
+func decodePrestateTrace(diffMode bool, trace func(res interface{}) error) (*types.PrestateTrace, error) {
+	res := new(types.PrestateTrace)
+	if diffMode {
+		if err := trace(res); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	if err := trace(&res.Pre); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func toTraceConfigArg(cfg *types.TraceConfig) interface{} {
+	if cfg == nil {
+		return struct{}{}
+	}
+	return cfg
+}
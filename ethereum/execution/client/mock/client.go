@@ -53,6 +53,21 @@ func (mr *MockClientMockRecorder) BalanceAt(ctx, account, blockNumber interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BalanceAt", reflect.TypeOf((*MockClient)(nil).BalanceAt), ctx, account, blockNumber)
 }
 
+// BlobBaseFee mocks base method.
+func (m *MockClient) BlobBaseFee(ctx context.Context) (*big.Int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlobBaseFee", ctx)
+	ret0, _ := ret[0].(*big.Int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlobBaseFee indicates an expected call of BlobBaseFee.
+func (mr *MockClientMockRecorder) BlobBaseFee(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlobBaseFee", reflect.TypeOf((*MockClient)(nil).BlobBaseFee), ctx)
+}
+
 // BlockByHash mocks base method.
 func (m *MockClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
 	m.ctrl.T.Helper()
@@ -323,6 +338,20 @@ func (mr *MockClientMockRecorder) PendingTransactionCount(ctx interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingTransactionCount", reflect.TypeOf((*MockClient)(nil).PendingTransactionCount), ctx)
 }
 
+// PrepareContextForOutbound mocks base method.
+func (m *MockClient) PrepareContextForOutbound(ctx context.Context) context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrepareContextForOutbound", ctx)
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// PrepareContextForOutbound indicates an expected call of PrepareContextForOutbound.
+func (mr *MockClientMockRecorder) PrepareContextForOutbound(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareContextForOutbound", reflect.TypeOf((*MockClient)(nil).PrepareContextForOutbound), ctx)
+}
+
 // SendTransaction mocks base method.
 func (m *MockClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
 	m.ctrl.T.Helper()
@@ -502,17 +531,3 @@ func (mr *MockClientMockRecorder) TransactionReceipt(ctx, txHash interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactionReceipt", reflect.TypeOf((*MockClient)(nil).TransactionReceipt), ctx, txHash)
 }
-
-// PrepareContextForOutbound mocks base method.
-func (m *MockClient) PrepareContextForOutbound(ctx context.Context) context.Context {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PrepareContextForOutbound", ctx)
-	ret0, _ := ret[0].(context.Context)
-	return ret0
-}
-
-// PrepareContextForOutbound indicates an expected call of PrepareContextForOutbound.
-func (mr *MockClientMockRecorder) PrepareContextForOutbound(ctx interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareContextForOutbound", reflect.TypeOf((*MockClient)(nil).PrepareContextForOutbound), ctx)
-}
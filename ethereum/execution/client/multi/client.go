@@ -0,0 +1,459 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/health-go/v4"
+	"github.com/kilnfi/go-utils/ethereum/execution/client"
+	"github.com/kilnfi/go-utils/ethereum/execution/client/jsonrpc"
+	kilnjsonrpc "github.com/kilnfi/go-utils/net/jsonrpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// Ensure Client interface is fully implemented
+var _ client.Client = (*Client)(nil)
+
+var (
+	// ErrNoHealthyEndpoint is returned when all endpoints are unhealthy
+	ErrNoHealthyEndpoint = errors.New("no healthy execution layer endpoint")
+
+	// ErrNoQuorum is returned when not enough endpoints returned the same result
+	ErrNoQuorum = errors.New("execution layer endpoints did not reach quorum")
+)
+
+// Endpoint is a named execution layer client
+type Endpoint struct {
+	Name string
+	client.Client
+}
+
+type endpoint struct {
+	*Endpoint
+
+	mu          sync.RWMutex
+	healthy     bool
+	blockNumber uint64
+	err         error
+}
+
+func (e *endpoint) isHealthy() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.healthy
+}
+
+// Client is an execution layer client spreading calls over multiple endpoints
+//
+// Calls are sent to the first healthy endpoint (in configured order) and fail over to the next ones on error.
+// An endpoint is unhealthy if it fails to return its block number or if it lags more than cfg.MaxBlockLag
+// blocks behind the most advanced endpoint.
+//
+// Transactions are broadcast to all healthy endpoints and reads at a specific block can require a quorum.
+type Client struct {
+	cfg       *Config
+	endpoints []*endpoint
+
+	mu       sync.Mutex
+	status   string
+	done     chan struct{}
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+
+	healthyGauge     *prometheus.GaugeVec
+	blockNumberGauge *prometheus.GaugeVec
+	errorsCounter    *prometheus.CounterVec
+
+	logger logrus.FieldLogger
+}
+
+const (
+	statusRunning = "running"
+	statusStopped = "stopped"
+)
+
+// New creates a client over the given endpoints, the first one being the primary
+func New(cfg *Config, endpoints ...*Endpoint) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("at least one endpoint is required")
+	}
+
+	if cfg.Quorum > len(endpoints) {
+		return nil, fmt.Errorf("quorum %v is higher than the number of endpoints (%v)", cfg.Quorum, len(endpoints))
+	}
+
+	c := &Client{
+		cfg: cfg,
+		healthyGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "eth_el_endpoint_healthy",
+				Help: "Whether the execution layer endpoint is healthy (1) or not (0)",
+			},
+			[]string{"endpoint"},
+		),
+		blockNumberGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "eth_el_endpoint_block_number",
+				Help: "Last block number returned by the execution layer endpoint",
+			},
+			[]string{"endpoint"},
+		),
+		errorsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "eth_el_endpoint_errors_total",
+				Help: "Number of failed calls to the execution layer endpoint",
+			},
+			[]string{"endpoint"},
+		),
+	}
+
+	for _, e := range endpoints {
+		c.endpoints = append(c.endpoints, &endpoint{
+			Endpoint: e,
+			healthy:  true,
+		})
+	}
+
+	c.SetLogger(logrus.StandardLogger())
+
+	return c, nil
+}
+
+// NewFromConfig creates a client connecting to the JSON-RPC endpoints of cfg
+func NewFromConfig(cfg *Config) (*Client, error) {
+	endpoints := make([]*Endpoint, len(cfg.Endpoints))
+	for i, endpointCfg := range cfg.Endpoints {
+		cli, err := jsonrpc.New(endpointCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		endpoints[i] = &Endpoint{
+			Name:   endpointName(endpointCfg.Address, i),
+			Client: cli,
+		}
+	}
+
+	return New(cfg, endpoints...)
+}
+
+// endpointName returns the host of addr so credentials in path or query are not exposed in logs and metrics
+//
+// The index of the endpoint is appended to tell apart endpoints of a same host (e.g. with different API keys).
+func endpointName(addr string, i int) string {
+	if u, err := url.Parse(addr); err == nil && u.Host != "" {
+		return fmt.Sprintf("%v#%v", u.Host, i)
+	}
+	return fmt.Sprintf("endpoint-%v", i)
+}
+
+func (c *Client) Logger() logrus.FieldLogger {
+	return c.logger
+}
+
+func (c *Client) SetLogger(logger logrus.FieldLogger) {
+	c.logger = logger.WithField("component", "eth-el-multi")
+}
+
+// Init initializes endpoints and checks their health
+func (c *Client) Init(ctx context.Context) error {
+	for _, e := range c.endpoints {
+		if initializable, ok := e.Client.(interface{ Init(context.Context) error }); ok {
+			if err := initializable.Init(ctx); err != nil {
+				return fmt.Errorf("failed to initialize endpoint %q: %w", e.Name, err)
+			}
+		}
+	}
+
+	c.checkHealth(ctx)
+
+	if len(c.healthyEndpoints()) == 0 {
+		return ErrNoHealthyEndpoint
+	}
+
+	return nil
+}
+
+// Start polls endpoints block numbers every cfg.HealthCheckInterval to update their health
+func (c *Client) Start(_ context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.status != "" {
+		return fmt.Errorf("client already %v", c.status)
+	}
+
+	// the polling loop must outlive the start context
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	c.status = statusRunning
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.cfg.HealthCheckInterval.Duration)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.checkHealth(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops polling endpoints
+func (c *Client) Stop(ctx context.Context) error {
+	var err error
+	c.stopOnce.Do(func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if c.status != statusRunning {
+			c.status = statusStopped
+			return
+		}
+		c.status = statusStopped
+
+		c.cancel()
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	})
+
+	return err
+}
+
+// RegisterMetrics registers endpoints health metrics, as well as metrics of endpoints exposing some
+func (c *Client) RegisterMetrics(reg prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{c.healthyGauge, c.blockNumberGauge, c.errorsCounter} {
+		if err := reg.Register(collector); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range c.endpoints {
+		if measurable, ok := e.Client.(interface {
+			RegisterMetrics(prometheus.Registerer) error
+		}); ok {
+			if err := measurable.RegisterMetrics(reg); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RegisterCheck registers a check failing when no endpoint is healthy
+func (c *Client) RegisterCheck(h *health.Health) error {
+	return h.Register(health.Config{
+		Name:    "eth-el",
+		Timeout: time.Second,
+		Check: func(context.Context) error {
+			if len(c.healthyEndpoints()) == 0 {
+				return ErrNoHealthyEndpoint
+			}
+			return nil
+		},
+	})
+}
+
+// checkHealth queries all endpoints block numbers and updates their health
+func (c *Client) checkHealth(ctx context.Context) {
+	var (
+		wg           sync.WaitGroup
+		blockNumbers = make([]uint64, len(c.endpoints))
+		errs         = make([]error, len(c.endpoints))
+	)
+	for i, e := range c.endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			blockNumbers[i], errs[i] = e.BlockNumber(e.PrepareContextForOutbound(ctx))
+		}(i, e)
+	}
+	wg.Wait()
+
+	var head uint64
+	for i := range c.endpoints {
+		if errs[i] == nil && blockNumbers[i] > head {
+			head = blockNumbers[i]
+		}
+	}
+
+	for i, e := range c.endpoints {
+		err := errs[i]
+		if err == nil && head-blockNumbers[i] > c.cfg.MaxBlockLag {
+			err = fmt.Errorf("endpoint is %v blocks behind", head-blockNumbers[i])
+		}
+
+		e.mu.Lock()
+		wasHealthy := e.healthy
+		e.healthy = err == nil
+		e.err = err
+		if errs[i] == nil {
+			e.blockNumber = blockNumbers[i]
+		}
+		e.mu.Unlock()
+
+		logger := c.logger.WithField("endpoint", e.Name)
+		switch {
+		case wasHealthy && err != nil:
+			logger.WithError(err).Warnf("execution layer endpoint became unhealthy")
+		case !wasHealthy && err == nil:
+			logger.Infof("execution layer endpoint became healthy")
+		}
+
+		if err == nil {
+			c.healthyGauge.WithLabelValues(e.Name).Set(1)
+		} else {
+			c.healthyGauge.WithLabelValues(e.Name).Set(0)
+			c.errorsCounter.WithLabelValues(e.Name).Inc()
+		}
+		if errs[i] == nil {
+			c.blockNumberGauge.WithLabelValues(e.Name).Set(float64(blockNumbers[i]))
+		}
+	}
+}
+
+// healthyEndpoints returns healthy endpoints in configured order
+func (c *Client) healthyEndpoints() []*endpoint {
+	var endpoints []*endpoint
+	for _, e := range c.endpoints {
+		if e.isHealthy() {
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// do executes fn on the first healthy endpoint, failing over to the next ones on error
+//
+// It does not fail over on context errors and on errors returned by the EVM (e.g. execution reverted)
+// as other endpoints would return the same error.
+func (c *Client) do(ctx context.Context, fn func(ctx context.Context, cli client.Client) error) error {
+	endpoints := c.healthyEndpoints()
+	if len(endpoints) == 0 {
+		return ErrNoHealthyEndpoint
+	}
+
+	var err error
+	for _, e := range endpoints {
+		err = fn(e.PrepareContextForOutbound(ctx), e.Client)
+		if err == nil || !shouldFailover(ctx, err) {
+			return err
+		}
+
+		c.errorsCounter.WithLabelValues(e.Name).Inc()
+		c.logger.WithError(err).WithField("endpoint", e.Name).Debugf("call failed, fail over to next endpoint")
+	}
+
+	return err
+}
+
+// quorum executes fn on all healthy endpoints and returns the result returned by at least cfg.Quorum of them
+//
+// Results are compared on their string representation.
+func (c *Client) quorum(ctx context.Context, fn func(ctx context.Context, cli client.Client) (string, error)) (string, error) {
+	endpoints := c.healthyEndpoints()
+	if len(endpoints) < c.cfg.Quorum {
+		return "", fmt.Errorf("%w: only %v healthy endpoints", ErrNoQuorum, len(endpoints))
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]string, len(endpoints))
+		errs    = make([]error, len(endpoints))
+	)
+	for i, e := range endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			results[i], errs[i] = fn(e.PrepareContextForOutbound(ctx), e.Client)
+		}(i, e)
+	}
+	wg.Wait()
+
+	votes := make(map[string]int)
+	for i, e := range endpoints {
+		if errs[i] != nil {
+			c.errorsCounter.WithLabelValues(e.Name).Inc()
+			continue
+		}
+		votes[results[i]]++
+		if votes[results[i]] >= c.cfg.Quorum {
+			return results[i], nil
+		}
+	}
+
+	if len(votes) == 0 {
+		// all endpoints failed, return the error of the most preferred one
+		return "", errs[0]
+	}
+
+	return "", fmt.Errorf("%w: got %v different results", ErrNoQuorum, len(votes))
+}
+
+// broadcast executes fn on all healthy endpoints and succeeds if at least one of them succeeds
+func (c *Client) broadcast(ctx context.Context, fn func(ctx context.Context, cli client.Client) error) error {
+	endpoints := c.healthyEndpoints()
+	if len(endpoints) == 0 {
+		return ErrNoHealthyEndpoint
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(endpoints))
+	)
+	for i, e := range endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			errs[i] = fn(e.PrepareContextForOutbound(ctx), e.Client)
+		}(i, e)
+	}
+	wg.Wait()
+
+	for i, e := range endpoints {
+		if errs[i] == nil {
+			return nil
+		}
+		c.errorsCounter.WithLabelValues(e.Name).Inc()
+		c.logger.WithError(errs[i]).WithField("endpoint", e.Name).Debugf("broadcast failed")
+	}
+
+	return errs[0]
+}
+
+func shouldFailover(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// errors carrying data are returned by the EVM (e.g. revert reason)
+	var errMsg *kilnjsonrpc.ErrorMsg
+	if errors.As(err, &errMsg) && errMsg.Data != nil {
+		return false
+	}
+
+	var dataErr interface{ ErrorData() interface{} }
+	if errors.As(err, &dataErr) && dataErr.ErrorData() != nil {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,203 @@
+//go:build !integration
+
+package multi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/golang/mock/gomock"
+	"github.com/kilnfi/go-utils/ethereum/execution/client/mock"
+	"github.com/kilnfi/go-utils/net/jsonrpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAddr = gethcommon.HexToAddress("0x4592d8f8d7b001e72cb26a73e4fa1806a51ac79d")
+
+func newTestClient(t *testing.T, cfg *Config, n int) (*Client, []*mock.MockClient) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	var (
+		mocks     []*mock.MockClient
+		endpoints []*Endpoint
+	)
+	for i := 0; i < n; i++ {
+		cli := mock.NewMockClient(ctrl)
+		cli.EXPECT().PrepareContextForOutbound(gomock.Any()).DoAndReturn(func(ctx context.Context) context.Context { return ctx }).AnyTimes()
+		mocks = append(mocks, cli)
+		endpoints = append(endpoints, &Endpoint{Name: string(rune('a' + i)), Client: cli})
+	}
+
+	c, err := New(cfg.SetDefault(), endpoints...)
+	require.NoError(t, err)
+
+	return c, mocks
+}
+
+func TestFailover(t *testing.T) {
+	c, mocks := newTestClient(t, &Config{}, 2)
+
+	mocks[0].EXPECT().ChainID(gomock.Any()).Return(nil, errors.New("connection refused"))
+	mocks[1].EXPECT().ChainID(gomock.Any()).Return(big.NewInt(1), nil)
+
+	chainID, err := c.ChainID(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), chainID.Int64())
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.errorsCounter.WithLabelValues("a")))
+}
+
+func TestNoFailoverOnRevert(t *testing.T) {
+	c, mocks := newTestClient(t, &Config{}, 2)
+
+	data := json.RawMessage(`"0x08c379a0"`)
+	revertErr := &jsonrpc.ErrorMsg{Code: 3, Message: "execution reverted", Data: &data}
+	mocks[0].EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Nil()).Return(nil, revertErr)
+
+	_, err := c.CallContract(t.Context(), geth.CallMsg{To: &testAddr}, nil)
+	assert.Equal(t, revertErr, err)
+}
+
+func TestHealth(t *testing.T) {
+	c, mocks := newTestClient(t, &Config{MaxBlockLag: 2}, 3)
+
+	mocks[0].EXPECT().BlockNumber(gomock.Any()).Return(uint64(100), nil)
+	mocks[1].EXPECT().BlockNumber(gomock.Any()).Return(uint64(97), nil)
+	mocks[2].EXPECT().BlockNumber(gomock.Any()).Return(uint64(0), errors.New("connection refused"))
+
+	require.NoError(t, c.Init(t.Context()))
+
+	healthy := c.healthyEndpoints()
+	require.Len(t, healthy, 1)
+	assert.Equal(t, "a", healthy[0].Name)
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.healthyGauge.WithLabelValues("a")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(c.healthyGauge.WithLabelValues("b")))
+	assert.Equal(t, float64(97), testutil.ToFloat64(c.blockNumberGauge.WithLabelValues("b")))
+
+	// unhealthy endpoints are not called
+	mocks[0].EXPECT().BlockNumber(gomock.Any()).Return(uint64(101), nil)
+	number, err := c.BlockNumber(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(101), number)
+
+	// endpoints recover once they catch up
+	mocks[0].EXPECT().BlockNumber(gomock.Any()).Return(uint64(0), errors.New("connection refused"))
+	mocks[1].EXPECT().BlockNumber(gomock.Any()).Return(uint64(102), nil)
+	mocks[2].EXPECT().BlockNumber(gomock.Any()).Return(uint64(101), nil)
+	c.checkHealth(t.Context())
+
+	healthy = c.healthyEndpoints()
+	require.Len(t, healthy, 2)
+	assert.Equal(t, "b", healthy[0].Name)
+	assert.Equal(t, "c", healthy[1].Name)
+}
+
+func TestInitNoHealthyEndpoint(t *testing.T) {
+	c, mocks := newTestClient(t, &Config{}, 1)
+
+	mocks[0].EXPECT().BlockNumber(gomock.Any()).Return(uint64(0), errors.New("connection refused"))
+
+	assert.ErrorIs(t, c.Init(t.Context()), ErrNoHealthyEndpoint)
+
+	_, err := c.ChainID(t.Context())
+	assert.ErrorIs(t, err, ErrNoHealthyEndpoint)
+}
+
+func TestQuorum(t *testing.T) {
+	blockNumber := big.NewInt(100)
+
+	t.Run("reached", func(t *testing.T) {
+		c, mocks := newTestClient(t, &Config{Quorum: 2}, 3)
+
+		mocks[0].EXPECT().BalanceAt(gomock.Any(), testAddr, blockNumber).Return(big.NewInt(1), nil)
+		mocks[1].EXPECT().BalanceAt(gomock.Any(), testAddr, blockNumber).Return(big.NewInt(2), nil)
+		mocks[2].EXPECT().BalanceAt(gomock.Any(), testAddr, blockNumber).Return(big.NewInt(2), nil)
+
+		balance, err := c.BalanceAt(t.Context(), testAddr, blockNumber)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), balance.Int64())
+	})
+
+	t.Run("not reached", func(t *testing.T) {
+		c, mocks := newTestClient(t, &Config{Quorum: 2}, 3)
+
+		mocks[0].EXPECT().CodeAt(gomock.Any(), testAddr, blockNumber).Return([]byte{0x1}, nil)
+		mocks[1].EXPECT().CodeAt(gomock.Any(), testAddr, blockNumber).Return([]byte{0x2}, nil)
+		mocks[2].EXPECT().CodeAt(gomock.Any(), testAddr, blockNumber).Return(nil, errors.New("connection refused"))
+
+		_, err := c.CodeAt(t.Context(), testAddr, blockNumber)
+		assert.ErrorIs(t, err, ErrNoQuorum)
+	})
+
+	t.Run("latest block", func(t *testing.T) {
+		c, mocks := newTestClient(t, &Config{Quorum: 2}, 2)
+
+		mocks[0].EXPECT().NonceAt(gomock.Any(), testAddr, gomock.Nil()).Return(uint64(5), nil)
+
+		nonce, err := c.NonceAt(t.Context(), testAddr, nil)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), nonce)
+	})
+}
+
+func TestSendTransaction(t *testing.T) {
+	tx := gethtypes.NewTx(&gethtypes.LegacyTx{Nonce: 1})
+
+	t.Run("broadcast", func(t *testing.T) {
+		c, mocks := newTestClient(t, &Config{}, 2)
+
+		mocks[0].EXPECT().SendTransaction(gomock.Any(), tx).Return(errors.New("connection refused"))
+		mocks[1].EXPECT().SendTransaction(gomock.Any(), tx).Return(nil)
+
+		require.NoError(t, c.SendTransaction(t.Context(), tx))
+	})
+
+	t.Run("all failed", func(t *testing.T) {
+		c, mocks := newTestClient(t, &Config{}, 2)
+
+		errPrimary := errors.New("nonce too low")
+		mocks[0].EXPECT().SendTransaction(gomock.Any(), tx).Return(errPrimary)
+		mocks[1].EXPECT().SendTransaction(gomock.Any(), tx).Return(errors.New("already known"))
+
+		assert.Equal(t, errPrimary, c.SendTransaction(t.Context(), tx))
+	})
+}
+
+func TestStartStop(t *testing.T) {
+	c, _ := newTestClient(t, &Config{}, 1)
+
+	require.NoError(t, c.Start(t.Context()))
+	require.NoError(t, c.Stop(t.Context()))
+	require.NoError(t, c.RegisterMetrics(prometheus.NewRegistry()))
+}
+
+func TestConfigFromViper(t *testing.T) {
+	v := viper.New()
+	f := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	Flags(v, f)
+
+	require.NoError(t, f.Parse([]string{"--eth-el-addr", "http://a:8545,http://b:8545", "--eth-el-addr", "http://c:8545", "--eth-el-quorum", "2"}))
+
+	cfg := ConfigFromViper(v).SetDefault()
+	require.Len(t, cfg.Endpoints, 3)
+	assert.Equal(t, "http://c:8545", cfg.Endpoints[2].Address)
+	assert.Equal(t, 2, cfg.Quorum)
+	assert.Equal(t, uint64(2), cfg.MaxBlockLag)
+	assert.Equal(t, "a:8545#0", endpointName(cfg.Endpoints[0].Address, 0))
+
+	// endpoints of a same provider have distinct names without exposing keys
+	assert.Equal(t, "mainnet.infura.io#0", endpointName("https://mainnet.infura.io/v3/key1", 0))
+	assert.Equal(t, "mainnet.infura.io#1", endpointName("https://mainnet.infura.io/v3/key2", 1))
+	assert.Equal(t, "endpoint-2", endpointName("invalid", 2))
+}
@@ -0,0 +1,41 @@
+package multi
+
+import (
+	"time"
+
+	kilntypes "github.com/kilnfi/go-utils/common/types"
+	jsonrpchttp "github.com/kilnfi/go-utils/net/jsonrpc/http"
+)
+
+// Config for the multi-endpoint client
+type Config struct {
+	// Endpoints are the JSON-RPC endpoints to connect to (only used by NewFromConfig)
+	Endpoints []*jsonrpchttp.Config
+
+	// MaxBlockLag is the number of blocks an endpoint can lag behind the most advanced endpoint
+	// before being considered unhealthy
+	MaxBlockLag uint64
+
+	// HealthCheckInterval is the interval at which endpoints block numbers are polled
+	HealthCheckInterval *kilntypes.Duration
+
+	// Quorum is the number of endpoints that must return the same result for reads at a specific block
+	// (CallContract, CodeAt, StorageAt, BalanceAt and NonceAt). Reads are not checked if Quorum is lower than 2
+	Quorum int
+}
+
+func (cfg *Config) SetDefault() *Config {
+	for _, endpoint := range cfg.Endpoints {
+		endpoint.SetDefault()
+	}
+
+	if cfg.MaxBlockLag == 0 {
+		cfg.MaxBlockLag = 2
+	}
+
+	if cfg.HealthCheckInterval == nil {
+		cfg.HealthCheckInterval = &kilntypes.Duration{Duration: 12 * time.Second}
+	}
+
+	return cfg
+}
@@ -0,0 +1,104 @@
+package multi
+
+import (
+	"strings"
+
+	cmdutils "github.com/kilnfi/go-utils/cmd/utils"
+	jsonrpchttp "github.com/kilnfi/go-utils/net/jsonrpc/http"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func Flags(v *viper.Viper, f *pflag.FlagSet) {
+	EthELAddrsFlag(v, f)
+	EthELMaxBlockLagFlag(v, f)
+	EthELQuorumFlag(v, f)
+}
+
+func ConfigFromViper(v *viper.Viper) *Config {
+	cfg := &Config{
+		MaxBlockLag: GetEthELMaxBlockLag(v),
+		Quorum:      GetEthELQuorum(v),
+	}
+
+	for _, addr := range GetEthELAddrs(v) {
+		cfg.Endpoints = append(cfg.Endpoints, &jsonrpchttp.Config{Address: addr})
+	}
+
+	return cfg
+}
+
+const (
+	ethELAddrFlag     = "eth-el-addr"
+	ethELAddrViperKey = "eth.el-addr"
+	ethELAddrEnv      = "ETH_EL_ADDR"
+)
+
+// EthELAddrsFlag register flag for Eth1 nodes to connect to
+//
+// It uses the same flag, viper key and environment variable as the single endpoint client
+// but accepts multiple values (either by repeating the flag or separating addresses by commas)
+func EthELAddrsFlag(v *viper.Viper, f *pflag.FlagSet) {
+	desc := cmdutils.FlagDesc(
+		"JSON-RPC addresses of the Ethereum execution layer nodes to connect to (the first one is the primary)",
+		ethELAddrEnv,
+	)
+	f.StringSlice(ethELAddrFlag, nil, desc)
+	_ = v.BindPFlag(ethELAddrViperKey, f.Lookup(ethELAddrFlag))
+	_ = v.BindEnv(ethELAddrViperKey, ethELAddrEnv)
+}
+
+func GetEthELAddrs(v *viper.Viper) []string {
+	var addrs []string
+	for _, value := range v.GetStringSlice(ethELAddrViperKey) {
+		// environment variable is not split by viper
+		for _, addr := range strings.Split(value, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
+
+const (
+	ethELMaxBlockLagFlag     = "eth-el-max-block-lag"
+	ethELMaxBlockLagViperKey = "eth.el-max-block-lag"
+	ethELMaxBlockLagEnv      = "ETH_EL_MAX_BLOCK_LAG"
+)
+
+// EthELMaxBlockLagFlag register flag for the maximum number of blocks a node can lag behind
+func EthELMaxBlockLagFlag(v *viper.Viper, f *pflag.FlagSet) {
+	desc := cmdutils.FlagDesc(
+		"Number of blocks an execution layer node can lag behind the most advanced node before being considered unhealthy",
+		ethELMaxBlockLagEnv,
+	)
+	f.Uint64(ethELMaxBlockLagFlag, 2, desc)
+	_ = v.BindPFlag(ethELMaxBlockLagViperKey, f.Lookup(ethELMaxBlockLagFlag))
+	_ = v.BindEnv(ethELMaxBlockLagViperKey, ethELMaxBlockLagEnv)
+}
+
+func GetEthELMaxBlockLag(v *viper.Viper) uint64 {
+	return v.GetUint64(ethELMaxBlockLagViperKey)
+}
+
+const (
+	ethELQuorumFlag     = "eth-el-quorum"
+	ethELQuorumViperKey = "eth.el-quorum"
+	ethELQuorumEnv      = "ETH_EL_QUORUM"
+)
+
+// EthELQuorumFlag register flag for the number of nodes that must agree on reads at a specific block
+func EthELQuorumFlag(v *viper.Viper, f *pflag.FlagSet) {
+	desc := cmdutils.FlagDesc(
+		"Number of execution layer nodes that must return the same result for reads at a specific block (disabled if lower than 2)",
+		ethELQuorumEnv,
+	)
+	f.Int(ethELQuorumFlag, 0, desc)
+	_ = v.BindPFlag(ethELQuorumViperKey, f.Lookup(ethELQuorumFlag))
+	_ = v.BindEnv(ethELQuorumViperKey, ethELQuorumEnv)
+}
+
+func GetEthELQuorum(v *viper.Viper) int {
+	return v.GetInt(ethELQuorumViperKey)
+}
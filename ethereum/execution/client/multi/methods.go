@@ -0,0 +1,327 @@
+package multi
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kilnfi/go-utils/ethereum/execution/client"
+)
+
+// PrepareContextForOutbound is a no-op as contexts are prepared for each endpoint when calling it
+func (c *Client) PrepareContextForOutbound(ctx context.Context) context.Context {
+	return ctx
+}
+
+// requiresQuorum indicates whether a read at blockNumber must reach quorum
+//
+// Reads at latest or pending block are never checked as endpoints may legitimately be a few blocks apart.
+func (c *Client) requiresQuorum(blockNumber *big.Int) bool {
+	return c.cfg.Quorum > 1 && blockNumber != nil && blockNumber.Sign() >= 0
+}
+
+func (c *Client) readBytes(ctx context.Context, blockNumber *big.Int, fn func(ctx context.Context, cli client.Client) ([]byte, error)) ([]byte, error) {
+	if !c.requiresQuorum(blockNumber) {
+		var res []byte
+		err := c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+			res, err = fn(ctx, cli)
+			return err
+		})
+		return res, err
+	}
+
+	res, err := c.quorum(ctx, func(ctx context.Context, cli client.Client) (string, error) {
+		b, err := fn(ctx, cli)
+		return string(b), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(res), nil
+}
+
+func (c *Client) CodeAt(ctx context.Context, contract gethcommon.Address, blockNumber *big.Int) ([]byte, error) {
+	return c.readBytes(ctx, blockNumber, func(ctx context.Context, cli client.Client) ([]byte, error) {
+		return cli.CodeAt(ctx, contract, blockNumber)
+	})
+}
+
+func (c *Client) CallContract(ctx context.Context, msg geth.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return c.readBytes(ctx, blockNumber, func(ctx context.Context, cli client.Client) ([]byte, error) {
+		return cli.CallContract(ctx, msg, blockNumber)
+	})
+}
+
+func (c *Client) StorageAt(ctx context.Context, account gethcommon.Address, key gethcommon.Hash, blockNumber *big.Int) ([]byte, error) {
+	return c.readBytes(ctx, blockNumber, func(ctx context.Context, cli client.Client) ([]byte, error) {
+		return cli.StorageAt(ctx, account, key, blockNumber)
+	})
+}
+
+func (c *Client) BalanceAt(ctx context.Context, account gethcommon.Address, blockNumber *big.Int) (*big.Int, error) {
+	if !c.requiresQuorum(blockNumber) {
+		var balance *big.Int
+		err := c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+			balance, err = cli.BalanceAt(ctx, account, blockNumber)
+			return err
+		})
+		return balance, err
+	}
+
+	res, err := c.quorum(ctx, func(ctx context.Context, cli client.Client) (string, error) {
+		balance, err := cli.BalanceAt(ctx, account, blockNumber)
+		if err != nil {
+			return "", err
+		}
+		return balance.String(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	balance, _ := new(big.Int).SetString(res, 10)
+	return balance, nil
+}
+
+func (c *Client) NonceAt(ctx context.Context, account gethcommon.Address, blockNumber *big.Int) (uint64, error) {
+	if !c.requiresQuorum(blockNumber) {
+		var nonce uint64
+		err := c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+			nonce, err = cli.NonceAt(ctx, account, blockNumber)
+			return err
+		})
+		return nonce, err
+	}
+
+	res, err := c.quorum(ctx, func(ctx context.Context, cli client.Client) (string, error) {
+		nonce, err := cli.NonceAt(ctx, account, blockNumber)
+		return strconv.FormatUint(nonce, 10), err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(res, 10, 64)
+}
+
+// SendTransaction broadcasts tx to all healthy endpoints and succeeds if at least one of them accepted it
+func (c *Client) SendTransaction(ctx context.Context, tx *gethtypes.Transaction) error {
+	return c.broadcast(ctx, func(ctx context.Context, cli client.Client) error {
+		return cli.SendTransaction(ctx, tx)
+	})
+}
+
+func (c *Client) EstimateGas(ctx context.Context, msg geth.CallMsg) (gas uint64, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		gas, err = cli.EstimateGas(ctx, msg)
+		return err
+	})
+	return
+}
+
+func (c *Client) SuggestGasPrice(ctx context.Context) (price *big.Int, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		price, err = cli.SuggestGasPrice(ctx)
+		return err
+	})
+	return
+}
+
+func (c *Client) SuggestGasTipCap(ctx context.Context) (tip *big.Int, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		tip, err = cli.SuggestGasTipCap(ctx)
+		return err
+	})
+	return
+}
+
+func (c *Client) BlobBaseFee(ctx context.Context) (fee *big.Int, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		fee, err = cli.BlobBaseFee(ctx)
+		return err
+	})
+	return
+}
+
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (header *gethtypes.Header, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		header, err = cli.HeaderByNumber(ctx, number)
+		return err
+	})
+	return
+}
+
+func (c *Client) HeaderByHash(ctx context.Context, hash gethcommon.Hash) (header *gethtypes.Header, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		header, err = cli.HeaderByHash(ctx, hash)
+		return err
+	})
+	return
+}
+
+func (c *Client) BlockByNumber(ctx context.Context, number *big.Int) (block *gethtypes.Block, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		block, err = cli.BlockByNumber(ctx, number)
+		return err
+	})
+	return
+}
+
+func (c *Client) BlockByHash(ctx context.Context, hash gethcommon.Hash) (block *gethtypes.Block, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		block, err = cli.BlockByHash(ctx, hash)
+		return err
+	})
+	return
+}
+
+func (c *Client) TransactionCount(ctx context.Context, blockHash gethcommon.Hash) (count uint, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		count, err = cli.TransactionCount(ctx, blockHash)
+		return err
+	})
+	return
+}
+
+func (c *Client) TransactionInBlock(ctx context.Context, blockHash gethcommon.Hash, index uint) (tx *gethtypes.Transaction, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		tx, err = cli.TransactionInBlock(ctx, blockHash, index)
+		return err
+	})
+	return
+}
+
+func (c *Client) TransactionByHash(ctx context.Context, txHash gethcommon.Hash) (tx *gethtypes.Transaction, isPending bool, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		tx, isPending, err = cli.TransactionByHash(ctx, txHash)
+		return err
+	})
+	return
+}
+
+func (c *Client) TransactionReceipt(ctx context.Context, txHash gethcommon.Hash) (receipt *gethtypes.Receipt, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		receipt, err = cli.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return
+}
+
+func (c *Client) PendingCodeAt(ctx context.Context, account gethcommon.Address) (code []byte, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		code, err = cli.PendingCodeAt(ctx, account)
+		return err
+	})
+	return
+}
+
+func (c *Client) PendingNonceAt(ctx context.Context, account gethcommon.Address) (nonce uint64, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		nonce, err = cli.PendingNonceAt(ctx, account)
+		return err
+	})
+	return
+}
+
+func (c *Client) PendingBalanceAt(ctx context.Context, account gethcommon.Address) (balance *big.Int, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		balance, err = cli.PendingBalanceAt(ctx, account)
+		return err
+	})
+	return
+}
+
+func (c *Client) PendingStorageAt(ctx context.Context, account gethcommon.Address, key gethcommon.Hash) (value []byte, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		value, err = cli.PendingStorageAt(ctx, account, key)
+		return err
+	})
+	return
+}
+
+func (c *Client) PendingTransactionCount(ctx context.Context) (count uint, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		count, err = cli.PendingTransactionCount(ctx)
+		return err
+	})
+	return
+}
+
+func (c *Client) PendingCallContract(ctx context.Context, msg geth.CallMsg) (res []byte, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		res, err = cli.PendingCallContract(ctx, msg)
+		return err
+	})
+	return
+}
+
+func (c *Client) FilterLogs(ctx context.Context, q geth.FilterQuery) (logs []gethtypes.Log, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		logs, err = cli.FilterLogs(ctx, q)
+		return err
+	})
+	return
+}
+
+// SubscribeFilterLogs subscribes to logs on the first healthy endpoint
+func (c *Client) SubscribeFilterLogs(ctx context.Context, q geth.FilterQuery, ch chan<- gethtypes.Log) (sub geth.Subscription, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		sub, err = cli.SubscribeFilterLogs(ctx, q, ch)
+		return err
+	})
+	return
+}
+
+// SubscribeNewHead subscribes to new heads on the first healthy endpoint
+func (c *Client) SubscribeNewHead(ctx context.Context, ch chan<- *gethtypes.Header) (sub geth.Subscription, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		sub, err = cli.SubscribeNewHead(ctx, ch)
+		return err
+	})
+	return
+}
+
+func (c *Client) SyncProgress(ctx context.Context) (progress *geth.SyncProgress, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		progress, err = cli.SyncProgress(ctx)
+		return err
+	})
+	return
+}
+
+func (c *Client) BlockNumber(ctx context.Context) (number uint64, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		number, err = cli.BlockNumber(ctx)
+		return err
+	})
+	return
+}
+
+func (c *Client) ChainID(ctx context.Context) (chainID *big.Int, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		chainID, err = cli.ChainID(ctx)
+		return err
+	})
+	return
+}
+
+func (c *Client) NetworkID(ctx context.Context) (networkID *big.Int, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		networkID, err = cli.NetworkID(ctx)
+		return err
+	})
+	return
+}
+
+// SubscribeTransactionReceipts subscribes to transaction receipts on the first healthy endpoint
+func (c *Client) SubscribeTransactionReceipts(ctx context.Context, q *geth.TransactionReceiptsQuery, ch chan<- []*gethtypes.Receipt) (sub geth.Subscription, err error) {
+	err = c.do(ctx, func(ctx context.Context, cli client.Client) (err error) {
+		sub, err = cli.SubscribeTransactionReceipts(ctx, q, ch)
+		return err
+	})
+	return
+}
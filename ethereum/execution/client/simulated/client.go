@@ -0,0 +1,343 @@
+package simulated
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethbind "github.com/ethereum/go-ethereum/accounts/abi/bind"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	gethsimulated "github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/kilnfi/go-utils/ethereum/execution/client"
+)
+
+// Ensure Client interface is fully implemented
+var _ client.Client = (*Client)(nil)
+
+// txIndexingPollInterval is the interval at which receipts are queried while transactions are being indexed
+const txIndexingPollInterval = 10 * time.Millisecond
+
+// txIndexingTimeout bounds the time Commit waits for the transactions of a block to be indexed
+const txIndexingTimeout = 5 * time.Second
+
+// faucetBalance is the balance of the account funding other accounts (1 billion ETH)
+var faucetBalance = new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18))
+
+// backendClient is the client exposed by the simulated backend
+//
+// The simulated backend only exposes a subset of the methods of the underlying ethclient.Client
+// so the missing ones are asserted when creating the client.
+type backendClient interface {
+	gethsimulated.Client
+	geth.ChainSyncReader
+	NetworkID(ctx context.Context) (*big.Int, error)
+	BlobBaseFee(ctx context.Context) (*big.Int, error)
+}
+
+// Client is an in-memory execution client running a simulated chain, for tests
+//
+// Transactions are only mined when Commit is called, unless auto-commit is enabled.
+type Client struct {
+	backendClient
+
+	backend *gethsimulated.Backend
+	chainID *big.Int
+
+	mu         sync.RWMutex
+	keys       map[gethcommon.Address]*ecdsa.PrivateKey
+	faucet     gethcommon.Address
+	autoCommit bool
+}
+
+// New creates a simulated chain with the given genesis allocation
+func New(alloc gethtypes.GenesisAlloc, options ...func(nodeConf *node.Config, ethConf *ethconfig.Config)) (*Client, error) {
+	faucetKey, err := gethcrypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	faucet := gethcrypto.PubkeyToAddress(faucetKey.PublicKey)
+
+	genesisAlloc := gethtypes.GenesisAlloc{
+		faucet: {Balance: faucetBalance},
+	}
+	for addr, account := range alloc {
+		genesisAlloc[addr] = account
+	}
+
+	backend := gethsimulated.NewBackend(genesisAlloc, options...)
+
+	cli, ok := backend.Client().(backendClient)
+	if !ok {
+		_ = backend.Close()
+		return nil, errors.New("simulated backend client does not implement execution client")
+	}
+
+	chainID, err := cli.ChainID(context.Background())
+	if err != nil {
+		_ = backend.Close()
+		return nil, err
+	}
+
+	return &Client{
+		backendClient: cli,
+		backend:       backend,
+		chainID:       chainID,
+		keys:          map[gethcommon.Address]*ecdsa.PrivateKey{faucet: faucetKey},
+		faucet:        faucet,
+	}, nil
+}
+
+// Close stops the simulated chain
+func (c *Client) Close() error {
+	return c.backend.Close()
+}
+
+// PrepareContextForOutbound returns ctx unchanged as calls are not sent over the network
+func (c *Client) PrepareContextForOutbound(ctx context.Context) context.Context {
+	return ctx
+}
+
+// SetAutoCommit indicates whether a block is mined after each sent transaction
+func (c *Client) SetAutoCommit(autoCommit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.autoCommit = autoCommit
+}
+
+// SendTransaction sends tx to the pool of pending transactions, mining it if auto-commit is enabled
+func (c *Client) SendTransaction(ctx context.Context, tx *gethtypes.Transaction) error {
+	if err := c.backendClient.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	autoCommit := c.autoCommit
+	c.mu.RUnlock()
+
+	if autoCommit {
+		c.Commit()
+	}
+
+	return nil
+}
+
+// TransactionReceipt returns the receipt of a mined transaction
+func (c *Client) TransactionReceipt(ctx context.Context, txHash gethcommon.Hash) (*gethtypes.Receipt, error) {
+	for {
+		receipt, err := c.backendClient.TransactionReceipt(ctx, txHash)
+		if err == nil || !isTxIndexing(err) {
+			return receipt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(txIndexingPollInterval):
+		}
+	}
+}
+
+func isTxIndexing(err error) bool {
+	var dataErr interface{ ErrorData() interface{} }
+	return errors.As(err, &dataErr) && dataErr.ErrorData() == "transaction indexing is in progress"
+}
+
+// Commit mines a block with all pending transactions and returns its hash
+//
+// The simulated chain indexes transactions asynchronously so Commit waits for the transactions
+// of the block to be indexed (up to 5 seconds), for their receipts to be available as soon as it returns.
+func (c *Client) Commit() gethcommon.Hash {
+	hash := c.backend.Commit()
+
+	ctx, cancel := context.WithTimeout(context.Background(), txIndexingTimeout)
+	defer cancel()
+	c.waitIndexed(ctx, hash)
+
+	return hash
+}
+
+// waitIndexed waits for the transactions of the block to be indexed, giving up once ctx is done
+func (c *Client) waitIndexed(ctx context.Context, blockHash gethcommon.Hash) {
+	block, err := c.BlockByHash(ctx, blockHash)
+	if err != nil {
+		return
+	}
+
+	for _, tx := range block.Transactions() {
+		for {
+			_, err := c.backendClient.TransactionReceipt(ctx, tx.Hash())
+			if !isTxIndexing(err) && !errors.Is(err, geth.NotFound) {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(txIndexingPollInterval):
+			}
+		}
+	}
+}
+
+// Mine mines n blocks
+func (c *Client) Mine(n int) {
+	for i := 0; i < n; i++ {
+		c.backend.Commit()
+	}
+}
+
+// Rollback removes all pending transactions
+func (c *Client) Rollback() {
+	c.backend.Rollback()
+}
+
+// Fork starts a side chain on top of the block with the given hash, to simulate reorgs
+func (c *Client) Fork(parentHash gethcommon.Hash) error {
+	return c.backend.Fork(parentHash)
+}
+
+// AdjustTime moves the time of the chain forward by mining an empty block with an adjusted timestamp
+//
+// It fails if there are pending transactions.
+func (c *Client) AdjustTime(adjustment time.Duration) error {
+	return c.backend.AdjustTime(adjustment)
+}
+
+// AddAccount registers key so transactions of its account can be signed with SignTx
+func (c *Client) AddAccount(key *ecdsa.PrivateKey) gethcommon.Address {
+	addr := gethcrypto.PubkeyToAddress(key.PublicKey)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[addr] = key
+
+	return addr
+}
+
+// NewAccount creates and registers an account funded with balance
+func (c *Client) NewAccount(ctx context.Context, balance *big.Int) (gethcommon.Address, error) {
+	key, err := gethcrypto.GenerateKey()
+	if err != nil {
+		return gethcommon.Address{}, err
+	}
+
+	addr := c.AddAccount(key)
+	if balance != nil && balance.Sign() > 0 {
+		if err := c.Fund(ctx, addr, balance); err != nil {
+			return gethcommon.Address{}, err
+		}
+	}
+
+	return addr, nil
+}
+
+// Fund transfers amount to addr and mines the transfer
+func (c *Client) Fund(ctx context.Context, addr gethcommon.Address, amount *big.Int) error {
+	nonce, err := c.PendingNonceAt(ctx, c.faucet)
+	if err != nil {
+		return err
+	}
+
+	head, err := c.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// transactions with a tip below the one suggested are silently dropped by the pool
+	tip, err := c.SuggestGasTipCap(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := c.SignTx(ctx, c.faucet, gethtypes.NewTx(&gethtypes.DynamicFeeTx{
+		ChainID:   c.chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip),
+		Gas:       21000,
+		To:        &addr,
+		Value:     amount,
+	}), c.chainID)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.mine(ctx, tx)
+
+	return err
+}
+
+// SignTx signs tx with the key of a registered account
+//
+// It can be used as types.SignTxFunc.
+func (c *Client) SignTx(_ context.Context, addr gethcommon.Address, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
+	c.mu.RLock()
+	key, ok := c.keys[addr]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown account %v", addr)
+	}
+
+	return gethtypes.SignTx(tx, gethtypes.LatestSignerForChainID(chainID), key)
+}
+
+// TransactOpts returns options to send transactions from a registered account with generated bindings
+func (c *Client) TransactOpts(ctx context.Context, from gethcommon.Address) *gethbind.TransactOpts {
+	return &gethbind.TransactOpts{
+		Context: ctx,
+		From:    from,
+		Signer: func(addr gethcommon.Address, tx *gethtypes.Transaction) (*gethtypes.Transaction, error) {
+			return c.SignTx(ctx, addr, tx, c.chainID)
+		},
+	}
+}
+
+// DeployContract deploys the contract of generated bindings from a registered account and mines it
+func (c *Client) DeployContract(ctx context.Context, from gethcommon.Address, metadata *gethbind.MetaData, params ...interface{}) (gethcommon.Address, *gethtypes.Receipt, error) {
+	contractABI, err := metadata.GetAbi()
+	if err != nil {
+		return gethcommon.Address{}, nil, err
+	}
+
+	opts := c.TransactOpts(ctx, from)
+	opts.NoSend = true
+
+	_, tx, _, err := gethbind.DeployContract(opts, *contractABI, gethcommon.FromHex(metadata.Bin), c, params...)
+	if err != nil {
+		return gethcommon.Address{}, nil, fmt.Errorf("failed to deploy contract: %w", err)
+	}
+
+	receipt, err := c.mine(ctx, tx)
+	if err != nil {
+		return gethcommon.Address{}, receipt, err
+	}
+
+	return receipt.ContractAddress, receipt, nil
+}
+
+// mine sends tx and mines it, returning its receipt
+func (c *Client) mine(ctx context.Context, tx *gethtypes.Transaction) (*gethtypes.Receipt, error) {
+	if err := c.backendClient.SendTransaction(ctx, tx); err != nil {
+		return nil, err
+	}
+	c.Commit()
+
+	receipt, err := c.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status != gethtypes.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("transaction %v failed", tx.Hash())
+	}
+
+	return receipt, nil
+}
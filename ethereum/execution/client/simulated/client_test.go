@@ -0,0 +1,158 @@
+//go:build !integration
+
+package simulated
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethbind "github.com/ethereum/go-ethereum/accounts/abi/bind"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var oneEther = big.NewInt(1e18)
+
+// pingMetaData is a contract emitting Ping() whenever it is called
+//
+// Its runtime code is PUSH32 <Ping() topic> PUSH1 0 PUSH1 0 LOG1 STOP
+var pingMetaData = &gethbind.MetaData{
+	ABI: `[{"type":"event","name":"Ping","inputs":[],"anonymous":false}]`,
+	Bin: fmt.Sprintf("0x602780600b6000396000f37f%x60006000a100", pingTopic),
+}
+
+var pingTopic = gethcrypto.Keccak256Hash([]byte("Ping()"))
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	c, err := New(nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestNewAccount(t *testing.T) {
+	c := newTestClient(t)
+
+	addr, err := c.NewAccount(t.Context(), oneEther)
+	require.NoError(t, err)
+
+	balance, err := c.BalanceAt(t.Context(), addr, nil)
+	require.NoError(t, err)
+	assert.Equal(t, oneEther, balance)
+
+	chainID, err := c.ChainID(t.Context())
+	require.NoError(t, err)
+
+	// accounts created without balance can still sign
+	empty, err := c.NewAccount(t.Context(), nil)
+	require.NoError(t, err)
+	_, err = c.SignTx(t.Context(), empty, gethtypes.NewTx(&gethtypes.LegacyTx{}), chainID)
+	require.NoError(t, err)
+
+	_, err = c.SignTx(t.Context(), gethcommon.Address{0x1}, gethtypes.NewTx(&gethtypes.LegacyTx{}), chainID)
+	assert.Error(t, err)
+}
+
+func TestMineOnDemand(t *testing.T) {
+	c := newTestClient(t)
+
+	from, err := c.NewAccount(t.Context(), oneEther)
+	require.NoError(t, err)
+	to := gethcommon.Address{0x1}
+
+	transfer := func() *gethtypes.Transaction {
+		opts := c.TransactOpts(t.Context(), from)
+		nonce, err := c.PendingNonceAt(t.Context(), from)
+		require.NoError(t, err)
+		head, err := c.HeaderByNumber(t.Context(), nil)
+		require.NoError(t, err)
+		tip, err := c.SuggestGasTipCap(t.Context())
+		require.NoError(t, err)
+
+		tx, err := opts.Signer(from, gethtypes.NewTx(&gethtypes.DynamicFeeTx{
+			Nonce:     nonce,
+			GasTipCap: tip,
+			GasFeeCap: new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(1),
+		}))
+		require.NoError(t, err)
+		require.NoError(t, c.SendTransaction(t.Context(), tx))
+		return tx
+	}
+
+	tx := transfer()
+	_, err = c.TransactionReceipt(t.Context(), tx.Hash())
+	assert.ErrorIs(t, err, geth.NotFound)
+
+	before, err := c.BlockNumber(t.Context())
+	require.NoError(t, err)
+	c.Commit()
+
+	receipt, err := c.TransactionReceipt(t.Context(), tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, before+1, receipt.BlockNumber.Uint64())
+
+	c.SetAutoCommit(true)
+	tx = transfer()
+	_, err = c.TransactionReceipt(t.Context(), tx.Hash())
+	require.NoError(t, err)
+
+	c.Mine(3)
+	number, err := c.BlockNumber(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, before+5, number)
+}
+
+func TestAdjustTime(t *testing.T) {
+	c := newTestClient(t)
+
+	before, err := c.HeaderByNumber(t.Context(), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, c.AdjustTime(time.Hour))
+
+	after, err := c.HeaderByNumber(t.Context(), nil)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, after.Time, before.Time+3600)
+}
+
+func TestDeployContractAndFilterLogs(t *testing.T) {
+	c := newTestClient(t)
+
+	from, err := c.NewAccount(t.Context(), oneEther)
+	require.NoError(t, err)
+
+	addr, receipt, err := c.DeployContract(t.Context(), from, pingMetaData)
+	require.NoError(t, err)
+	assert.Equal(t, addr, receipt.ContractAddress)
+
+	code, err := c.CodeAt(t.Context(), addr, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, code)
+
+	contractABI, err := pingMetaData.GetAbi()
+	require.NoError(t, err)
+	contract := gethbind.NewBoundContract(addr, *contractABI, c, c, c)
+
+	c.SetAutoCommit(true)
+	_, err = contract.RawTransact(c.TransactOpts(t.Context(), from), nil)
+	require.NoError(t, err)
+
+	logs, err := c.FilterLogs(t.Context(), geth.FilterQuery{
+		FromBlock: receipt.BlockNumber,
+		Addresses: []gethcommon.Address{addr},
+		Topics:    [][]gethcommon.Hash{{pingTopic}},
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, addr, logs[0].Address)
+}
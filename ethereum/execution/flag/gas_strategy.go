@@ -0,0 +1,69 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kilnfi/go-utils/ethereum/execution/gas"
+	"github.com/spf13/pflag"
+)
+
+// gasStrategyValue is a type implementing pflag.Value interface for gas strategy names
+type gasStrategyValue struct {
+	strategy *string
+}
+
+func (v *gasStrategyValue) Set(s string) error {
+	if _, err := gas.GetStrategy(s); err != nil {
+		return fmt.Errorf("%w (expected one of %s)", err, strings.Join(gas.StrategyNames(), ", "))
+	}
+
+	*v.strategy = s
+
+	return nil
+}
+
+func (v *gasStrategyValue) Type() string   { return "gasStrategy" }
+func (v *gasStrategyValue) String() string { return *v.strategy }
+
+// GasStrategyVar registers a gas strategy custom flag with specified name, default value, and usage string.
+// The argument p points to a string variable in which to store the name of the strategy
+func GasStrategyVar(f *pflag.FlagSet, p *string, name, value, usage string) {
+	*p = value
+	f.Var(&gasStrategyValue{p}, name, usage)
+}
+
+// GasStrategyVarP registers a gas strategy custom flag with specified name and shorthand, default value, and usage string.
+// The argument p points to a string variable in which to store the name of the strategy
+func GasStrategyVarP(f *pflag.FlagSet, p *string, name, shorthand, value, usage string) {
+	*p = value
+	f.VarP(&gasStrategyValue{p}, name, shorthand, usage)
+}
+
+// GasConfigVar registers a set of custom flags for gas.Config
+func GasConfigVar(f *pflag.FlagSet, cfg *gas.Config) {
+	GasStrategyVar(
+		f,
+		&cfg.Strategy,
+		"gas-strategy",
+		gas.StandardStrategy,
+		fmt.Sprintf(`Optional strategy used to estimate EIP-1559 fees from fee history.
+Expects one of %s`, strings.Join(gas.StrategyNames(), ", ")),
+	)
+	BigIntVar(
+		f,
+		&cfg.MaxFeeCap,
+		"gas-max-fee-cap",
+		nil,
+		`Optional ceiling for the estimated gas fee cap in Wei. If not set then no ceiling is applied
+Expects either a decimal or an hex encoded value with 0x prefix`,
+	)
+	BigIntVar(
+		f,
+		&cfg.MaxTipCap,
+		"gas-max-tip-cap",
+		nil,
+		`Optional ceiling for the estimated gas tip cap in Wei. If not set then no ceiling is applied
+Expects either a decimal or an hex encoded value with 0x prefix`,
+	)
+}
@@ -0,0 +1,40 @@
+//go:build !integration
+
+package flag
+
+import (
+	"testing"
+
+	"github.com/kilnfi/go-utils/ethereum/execution/gas"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasStrategy(t *testing.T) {
+	t.Run("default and flag unset", func(t *testing.T) {
+		cfg := new(gas.Config)
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		GasConfigVar(flags, cfg)
+		require.NoError(t, flags.Parse([]string{}))
+		assert.Equal(t, gas.StandardStrategy, cfg.Strategy)
+		assert.Nil(t, cfg.MaxFeeCap)
+	})
+
+	t.Run("flag set", func(t *testing.T) {
+		cfg := new(gas.Config)
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		GasConfigVar(flags, cfg)
+		require.NoError(t, flags.Parse([]string{"--gas-strategy", "fast", "--gas-max-fee-cap", "100"}))
+		assert.Equal(t, gas.FastStrategy, cfg.Strategy)
+		assert.Equal(t, int64(100), cfg.MaxFeeCap.Int64())
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		cfg := new(gas.Config)
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		GasConfigVar(flags, cfg)
+		err := flags.Parse([]string{"--gas-strategy", "ludicrous"})
+		require.Error(t, err)
+	})
+}
@@ -61,6 +61,14 @@ Expects either a decimal or an hex encoded value with 0x prefix`,
 		"t",
 		nil,
 		`Optional gas priority tip fee cap to use for the EIP-1559 transaction execution in Wei. If not set then uses gas price oracle
+Expects either a decimal or an hex encoded value with 0x prefix`,
+	)
+	BigIntVar(
+		f,
+		&txOpts.BlobFeeCap,
+		"blob-fee-cap",
+		nil,
+		`Optional blob gas fee cap to use for the EIP-4844 transaction execution in Wei. If not set then uses twice the current blob base fee
 Expects either a decimal or an hex encoded value with 0x prefix`,
 	)
 	f.Uint64VarP(
@@ -0,0 +1,28 @@
+package gas
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// BlobBaseFeeReader is the node method required to estimate blob fees
+type BlobBaseFeeReader interface {
+	BlobBaseFee(ctx context.Context) (*big.Int, error)
+}
+
+// BlobFeeCap returns a blob gas fee cap covering the blob base fee for the given number of blocks.
+//
+// The blob base fee can not increase faster than the EIP-1559 base fee (at most 1/8 per block with full blobs)
+// so the current blob base fee is projected using ProjectBaseFee.
+//
+// types.TransactOpts defaults to types.DefaultBlobFeeCapMultiplier times the blob base fee instead,
+// which covers at least 5 full blocks (i.e. BlobFeeCap with 5 blocks never exceeds it).
+func BlobFeeCap(ctx context.Context, cli BlobBaseFeeReader, blocks uint64) (*big.Int, error) {
+	blobBaseFee, err := cli.BlobBaseFee(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve blob base fee: %w", err)
+	}
+
+	return ProjectBaseFee(blobBaseFee, blocks), nil
+}
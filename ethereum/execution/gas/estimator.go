@@ -0,0 +1,87 @@
+package gas
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	geth "github.com/ethereum/go-ethereum"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
+)
+
+// Ensure FeeEstimator interface is fully implemented
+var _ types.FeeEstimator = (*Estimator)(nil)
+
+// Config for the fee estimator
+type Config struct {
+	Strategy   string   // Name of the strategy to use
+	BlockCount uint64   // Number of blocks of fee history to consider
+	MaxFeeCap  *big.Int // Optional ceiling for the gas fee cap in Wei (nil = no ceiling)
+	MaxTipCap  *big.Int // Optional ceiling for the gas tip cap in Wei (nil = no ceiling)
+}
+
+func (cfg *Config) SetDefault() *Config {
+	if cfg.Strategy == "" {
+		cfg.Strategy = StandardStrategy
+	}
+
+	if cfg.BlockCount == 0 {
+		cfg.BlockCount = 20
+	}
+
+	return cfg
+}
+
+// Estimator computes EIP-1559 fees from eth_feeHistory
+type Estimator struct {
+	cfg      *Config
+	client   geth.FeeHistoryReader
+	strategy Strategy
+}
+
+// New creates an estimator using the strategy registered under cfg.Strategy
+func New(cfg *Config, cli geth.FeeHistoryReader) (*Estimator, error) {
+	strategy, err := GetStrategy(cfg.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithStrategy(cfg, cli, strategy), nil
+}
+
+// NewWithStrategy creates an estimator using a custom strategy
+func NewWithStrategy(cfg *Config, cli geth.FeeHistoryReader, strategy Strategy) *Estimator {
+	return &Estimator{
+		cfg:      cfg,
+		client:   cli,
+		strategy: strategy,
+	}
+}
+
+// EstimateFees returns fees for a transaction to be included in the next blocks
+func (e *Estimator) EstimateFees(ctx context.Context) (gasTipCap, gasFeeCap *big.Int, err error) {
+	history, err := e.client.FeeHistory(ctx, e.cfg.BlockCount, nil, []float64{e.strategy.RewardPercentile()})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch fee history: %w", err)
+	}
+
+	gasTipCap, gasFeeCap, err = e.strategy.Fees(history)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if e.cfg.MaxTipCap != nil && gasTipCap.Cmp(e.cfg.MaxTipCap) > 0 {
+		gasTipCap = new(big.Int).Set(e.cfg.MaxTipCap)
+	}
+
+	if e.cfg.MaxFeeCap != nil && gasFeeCap.Cmp(e.cfg.MaxFeeCap) > 0 {
+		gasFeeCap = new(big.Int).Set(e.cfg.MaxFeeCap)
+	}
+
+	// The tip can not exceed the fee cap
+	if gasTipCap.Cmp(gasFeeCap) > 0 {
+		gasTipCap = new(big.Int).Set(gasFeeCap)
+	}
+
+	return gasTipCap, gasFeeCap, nil
+}
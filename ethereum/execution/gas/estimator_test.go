@@ -0,0 +1,107 @@
+//go:build !integration
+
+package gas
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	geth "github.com/ethereum/go-ethereum"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type feeHistoryFunc func(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*geth.FeeHistory, error)
+
+func (f feeHistoryFunc) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*geth.FeeHistory, error) {
+	return f(ctx, blockCount, lastBlock, rewardPercentiles)
+}
+
+func testHistory(t *testing.T, expectedPercentile float64) geth.FeeHistoryReader {
+	t.Helper()
+	return feeHistoryFunc(func(_ context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*geth.FeeHistory, error) {
+		assert.Equal(t, uint64(4), blockCount)
+		assert.Nil(t, lastBlock)
+		assert.Equal(t, []float64{expectedPercentile}, rewardPercentiles)
+		return &geth.FeeHistory{
+			OldestBlock:  big.NewInt(100),
+			Reward:       [][]*big.Int{{big.NewInt(30)}, {big.NewInt(0)}, {big.NewInt(10)}, {big.NewInt(20)}},
+			BaseFee:      []*big.Int{big.NewInt(800), big.NewInt(800), big.NewInt(800), big.NewInt(800), big.NewInt(1000)},
+			GasUsedRatio: []float64{0.5, 0, 0.7, 0.4},
+		}, nil
+	})
+}
+
+func TestEstimateFees(t *testing.T) {
+	e, err := New((&Config{BlockCount: 4}).SetDefault(), testHistory(t, 50))
+	require.NoError(t, err)
+
+	tip, feeCap, err := e.EstimateFees(t.Context())
+	require.NoError(t, err)
+
+	// median of 10, 20, 30 (empty block ignored)
+	assert.Equal(t, int64(20), tip.Int64())
+	// 1000 * 1.125^4 rounded up at each step + tip
+	assert.Equal(t, int64(1604+20), feeCap.Int64())
+}
+
+func TestEstimateFeesWithCeilings(t *testing.T) {
+	e, err := New(
+		(&Config{
+			Strategy:   FastStrategy,
+			BlockCount: 4,
+			MaxFeeCap:  big.NewInt(15),
+			MaxTipCap:  big.NewInt(18),
+		}).SetDefault(),
+		testHistory(t, 90),
+	)
+	require.NoError(t, err)
+
+	tip, feeCap, err := e.EstimateFees(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), feeCap.Int64())
+	assert.Equal(t, int64(15), tip.Int64())
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	_, err := New(&Config{Strategy: "unknown"}, nil)
+	require.Error(t, err)
+}
+
+func TestProjectBaseFee(t *testing.T) {
+	assert.Equal(t, int64(100), ProjectBaseFee(big.NewInt(100), 0).Int64())
+	assert.Equal(t, int64(113), ProjectBaseFee(big.NewInt(100), 1).Int64())
+}
+
+func TestTransactOptsWithFees(t *testing.T) {
+	e, err := New((&Config{BlockCount: 4}).SetDefault(), testHistory(t, 50))
+	require.NoError(t, err)
+
+	opts := &types.TransactOpts{FeeEstimator: e}
+	bindOpts, err := opts.ToOptsWithFees(t.Context(), big.NewInt(1), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), bindOpts.GasTipCap.Int64())
+	assert.Equal(t, int64(1624), bindOpts.GasFeeCap.Int64())
+
+	opts = &types.TransactOpts{FeeEstimator: e, GasFeeCap: big.NewInt(10)}
+	bindOpts, err = opts.ToOptsWithFees(t.Context(), big.NewInt(1), nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), bindOpts.GasTipCap.Int64())
+	assert.Equal(t, int64(10), bindOpts.GasFeeCap.Int64())
+}
+
+type blobBaseFeeFunc func(ctx context.Context) (*big.Int, error)
+
+func (f blobBaseFeeFunc) BlobBaseFee(ctx context.Context) (*big.Int, error) {
+	return f(ctx)
+}
+
+func TestBlobFeeCap(t *testing.T) {
+	feeCap, err := BlobFeeCap(t.Context(), blobBaseFeeFunc(func(context.Context) (*big.Int, error) {
+		return big.NewInt(100), nil
+	}), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(113), feeCap.Int64())
+}
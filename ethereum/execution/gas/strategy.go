@@ -0,0 +1,128 @@
+package gas
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	geth "github.com/ethereum/go-ethereum"
+)
+
+const (
+	SlowStrategy     = "slow"
+	StandardStrategy = "standard"
+	FastStrategy     = "fast"
+)
+
+// defaultGasTipCap is the tip used when fee history holds no reward sample (1 gwei)
+var defaultGasTipCap = big.NewInt(1_000_000_000)
+
+// Strategy computes EIP-1559 fees from a fee history
+type Strategy interface {
+	// RewardPercentile is the percentile of effective priority fees to request with eth_feeHistory
+	RewardPercentile() float64
+
+	// Fees computes a gas tip cap and a gas fee cap from a fee history requested with RewardPercentile
+	Fees(history *geth.FeeHistory) (gasTipCap, gasFeeCap *big.Int, err error)
+}
+
+// PercentileStrategy sets the tip to the median over recent blocks of the given priority fee percentile
+// and sets the fee cap so the transaction stays includable if the base fee grows at its maximum
+// rate (12.5% per block) for BaseFeeBlocks blocks after the next one.
+type PercentileStrategy struct {
+	Percentile    float64
+	BaseFeeBlocks uint64
+}
+
+func (s *PercentileStrategy) RewardPercentile() float64 { return s.Percentile }
+
+func (s *PercentileStrategy) Fees(history *geth.FeeHistory) (gasTipCap, gasFeeCap *big.Int, err error) {
+	if len(history.BaseFee) == 0 {
+		return nil, nil, errors.New("fee history has no base fee")
+	}
+
+	// Last base fee is the one of the next block
+	nextBaseFee := history.BaseFee[len(history.BaseFee)-1]
+	if nextBaseFee == nil {
+		return nil, nil, errors.New("fee history has no base fee for next block")
+	}
+
+	gasTipCap = medianReward(history)
+	gasFeeCap = new(big.Int).Add(ProjectBaseFee(nextBaseFee, s.BaseFeeBlocks), gasTipCap)
+
+	return gasTipCap, gasFeeCap, nil
+}
+
+// ProjectBaseFee returns the maximum base fee reachable after the given number of full blocks
+func ProjectBaseFee(baseFee *big.Int, blocks uint64) *big.Int {
+	projected := new(big.Int).Set(baseFee)
+	for i := uint64(0); i < blocks; i++ {
+		// base fee can increase by at most 1/8 per block, round up
+		projected.Mul(projected, big.NewInt(9))
+		projected.Add(projected, big.NewInt(7))
+		projected.Div(projected, big.NewInt(8))
+	}
+	return projected
+}
+
+func medianReward(history *geth.FeeHistory) *big.Int {
+	var samples []*big.Int
+	for i, rewards := range history.Reward {
+		// Empty blocks report zero rewards which would bias the median
+		if i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0 {
+			continue
+		}
+		if len(rewards) == 0 || rewards[0] == nil {
+			continue
+		}
+		samples = append(samples, rewards[0])
+	}
+
+	if len(samples) == 0 {
+		return new(big.Int).Set(defaultGasTipCap)
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Cmp(samples[j]) < 0 })
+
+	return new(big.Int).Set(samples[len(samples)/2])
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[string]Strategy{
+		SlowStrategy:     &PercentileStrategy{Percentile: 10, BaseFeeBlocks: 2},
+		StandardStrategy: &PercentileStrategy{Percentile: 50, BaseFeeBlocks: 4},
+		FastStrategy:     &PercentileStrategy{Percentile: 90, BaseFeeBlocks: 6},
+	}
+)
+
+// RegisterStrategy registers a custom strategy that can then be selected by name
+func RegisterStrategy(name string, s Strategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	strategies[name] = s
+}
+
+// GetStrategy returns the strategy registered with the given name
+func GetStrategy(name string) (Strategy, error) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	if s, ok := strategies[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown gas strategy %q", name)
+}
+
+// StrategyNames returns the sorted names of all registered strategies
+func StrategyNames() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,530 @@
+//revive:disable-next-line:package-directory-mismatch
+package ethel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	kilntypes "github.com/kilnfi/go-utils/common/types"
+	"github.com/kilnfi/go-utils/ethereum/execution/client"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrReorged is returned when a block is no longer part of the canonical chain
+var ErrReorged = errors.New("block has been reorged out of the canonical chain")
+
+// HeadTrackerConfig for the head tracker
+type HeadTrackerConfig struct {
+	// Depth is the number of recent canonical headers kept in memory,
+	// reorgs deeper than Depth are not reported as such and reset the tracker
+	Depth int
+
+	// PollInterval is the interval at which the latest header is polled
+	// (when UseSubscription is set, it is the interval at which subscription is retried)
+	PollInterval *kilntypes.Duration
+
+	// UseSubscription indicates whether to subscribe to new heads instead of polling
+	UseSubscription bool
+}
+
+func (cfg *HeadTrackerConfig) SetDefault() *HeadTrackerConfig {
+	if cfg.Depth == 0 {
+		cfg.Depth = 64
+	}
+
+	if cfg.PollInterval == nil {
+		cfg.PollInterval = &kilntypes.Duration{Duration: 4 * time.Second}
+	}
+
+	return cfg
+}
+
+// ReorgEvent describes a change of the canonical chain
+type ReorgEvent struct {
+	// Depth is the number of headers removed from the canonical chain
+	Depth int
+
+	// Ancestor is the last header common to both branches
+	Ancestor *gethtypes.Header
+
+	// Old are the headers removed from the canonical chain in ascending order
+	Old []*gethtypes.Header
+
+	// New are the headers added to the canonical chain in ascending order
+	New []*gethtypes.Header
+}
+
+// HeadTracker follows the head of the chain, keeping recent canonical headers to detect reorgs
+//
+// It also tracks safe and finalized headers. Subscribers are notified of new heads, reorgs and
+// newly finalized headers; notifications are blocking so subscribers must consume them promptly.
+type HeadTracker struct {
+	cfg    *HeadTrackerConfig
+	client client.Client
+
+	mu        sync.RWMutex
+	headers   *headerRing
+	safe      *gethtypes.Header
+	finalized *gethtypes.Header
+
+	headFeed      event.Feed
+	reorgFeed     event.Feed
+	finalizedFeed event.Feed
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	cancel    context.CancelFunc
+	done      chan struct{}
+
+	logger logrus.FieldLogger
+}
+
+// NewHeadTracker creates a head tracker following the chain of cli
+func NewHeadTracker(cfg *HeadTrackerConfig, cli client.Client) *HeadTracker {
+	t := &HeadTracker{
+		cfg:     cfg,
+		client:  cli,
+		headers: newHeaderRing(cfg.Depth),
+	}
+
+	t.SetLogger(logrus.StandardLogger())
+
+	return t
+}
+
+func (t *HeadTracker) Logger() logrus.FieldLogger {
+	return t.logger
+}
+
+func (t *HeadTracker) SetLogger(logger logrus.FieldLogger) {
+	t.logger = logger.WithField("component", "head-tracker")
+}
+
+// Init loads the latest, safe and finalized headers
+func (t *HeadTracker) Init(ctx context.Context) error {
+	head, err := t.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest header: %w", err)
+	}
+
+	return t.update(ctx, head)
+}
+
+// Start follows the chain head until Stop is called
+func (t *HeadTracker) Start(_ context.Context) error {
+	t.startOnce.Do(func() {
+		// the loop must outlive the start context
+		ctx, cancel := context.WithCancel(context.Background())
+		t.cancel = cancel
+		t.done = make(chan struct{})
+		go t.loop(ctx)
+	})
+
+	return nil
+}
+
+// Stop stops following the chain head
+func (t *HeadTracker) Stop(ctx context.Context) error {
+	var err error
+	t.stopOnce.Do(func() {
+		if t.cancel == nil {
+			return
+		}
+
+		t.cancel()
+		select {
+		case <-t.done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	})
+
+	return err
+}
+
+func (t *HeadTracker) loop(ctx context.Context) {
+	defer close(t.done)
+
+	ticker := time.NewTicker(t.cfg.PollInterval.Duration)
+	defer ticker.Stop()
+
+	var (
+		heads  = make(chan *gethtypes.Header, 16)
+		sub    geth.Subscription
+		subErr <-chan error
+	)
+
+	subscribe := func() {
+		s, err := t.client.SubscribeNewHead(ctx, heads)
+		if err != nil {
+			t.logger.WithError(err).Warnf("failed to subscribe to new heads, fall back to polling")
+			return
+		}
+		sub, subErr = s, s.Err()
+	}
+
+	if t.cfg.UseSubscription {
+		subscribe()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if sub != nil {
+				sub.Unsubscribe()
+			}
+			return
+		case err := <-subErr:
+			t.logger.WithError(err).Warnf("new heads subscription failed, fall back to polling")
+			sub, subErr = nil, nil
+		case head := <-heads:
+			if err := t.update(ctx, head); err != nil {
+				t.logger.WithError(err).Warnf("failed to process new head")
+			}
+		case <-ticker.C:
+			if sub != nil {
+				continue
+			}
+
+			if t.cfg.UseSubscription {
+				subscribe()
+			}
+
+			if err := t.poll(ctx); err != nil {
+				t.logger.WithError(err).Warnf("failed to poll latest header")
+			}
+		}
+	}
+}
+
+func (t *HeadTracker) poll(ctx context.Context) error {
+	head, err := t.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	return t.update(ctx, head)
+}
+
+// update processes a new head and refreshes safe and finalized headers
+func (t *HeadTracker) update(ctx context.Context, head *gethtypes.Header) error {
+	if err := t.processHead(ctx, head); err != nil {
+		return err
+	}
+
+	return t.updateTags(ctx)
+}
+
+// processHead inserts head in the canonical chain, reporting a reorg if it does not extend the current head
+//
+// It is only called from a single go-routine so state can be read without holding the lock for writing.
+func (t *HeadTracker) processHead(ctx context.Context, head *gethtypes.Header) error {
+	t.mu.RLock()
+	current := t.headers.last()
+	known := t.headers.get(head.Number.Uint64())
+	t.mu.RUnlock()
+
+	switch {
+	case current == nil:
+		t.mu.Lock()
+		t.headers.push(head)
+		t.mu.Unlock()
+		t.headFeed.Send(head)
+		return nil
+	case known != nil && known.Hash() == head.Hash():
+		// head is already known (e.g. a lagging node returned a previous head)
+		return nil
+	}
+
+	// fetch headers of the new branch until reaching a known canonical header
+	branch := []*gethtypes.Header{head}
+	var ancestor *gethtypes.Header
+	for cur := head; ancestor == nil; {
+		if cur.Number.Sign() == 0 {
+			break
+		}
+
+		t.mu.RLock()
+		parent := t.headers.get(cur.Number.Uint64() - 1)
+		t.mu.RUnlock()
+		if parent != nil && parent.Hash() == cur.ParentHash {
+			ancestor = parent
+			break
+		}
+
+		if len(branch) > t.cfg.Depth || (parent == nil && cur.Number.Uint64() <= current.Number.Uint64()) {
+			break
+		}
+
+		next, err := t.client.HeaderByHash(ctx, cur.ParentHash)
+		if err != nil {
+			return fmt.Errorf("failed to get header %v: %w", cur.ParentHash, err)
+		}
+		branch = append(branch, next)
+		cur = next
+	}
+
+	// headers were fetched from head to ancestor
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
+	}
+
+	if ancestor == nil {
+		t.logger.
+			WithField("head.number", head.Number).
+			WithField("head.hash", head.Hash().Hex()).
+			Warnf("new head does not connect to known headers, reset tracker")
+
+		t.mu.Lock()
+		t.headers.reset()
+		t.headers.push(head)
+		t.mu.Unlock()
+		t.headFeed.Send(head)
+		return nil
+	}
+
+	t.mu.Lock()
+	old := t.headers.truncate(ancestor.Number.Uint64() + 1)
+	for _, h := range branch {
+		t.headers.push(h)
+	}
+	t.mu.Unlock()
+
+	if len(old) > 0 {
+		reorg := &ReorgEvent{
+			Depth:    len(old),
+			Ancestor: ancestor,
+			Old:      old,
+			New:      branch,
+		}
+
+		t.logger.
+			WithField("depth", reorg.Depth).
+			WithField("ancestor.number", ancestor.Number).
+			WithField("ancestor.hash", ancestor.Hash().Hex()).
+			Warnf("chain reorg detected")
+
+		t.reorgFeed.Send(reorg)
+		t.headFeed.Send(head)
+		return nil
+	}
+
+	for _, h := range branch {
+		t.headFeed.Send(h)
+	}
+
+	return nil
+}
+
+func (t *HeadTracker) updateTags(ctx context.Context) error {
+	safe, err := t.client.HeaderByNumber(ctx, big.NewInt(int64(gethrpc.SafeBlockNumber)))
+	if err != nil {
+		return fmt.Errorf("failed to get safe header: %w", err)
+	}
+
+	finalized, err := t.client.HeaderByNumber(ctx, big.NewInt(int64(gethrpc.FinalizedBlockNumber)))
+	if err != nil {
+		return fmt.Errorf("failed to get finalized header: %w", err)
+	}
+
+	t.mu.Lock()
+	t.safe = safe
+	isNewFinalized := t.finalized == nil || finalized.Number.Cmp(t.finalized.Number) > 0
+	if isNewFinalized {
+		t.finalized = finalized
+	}
+	t.mu.Unlock()
+
+	if isNewFinalized {
+		t.finalizedFeed.Send(finalized)
+	}
+
+	return nil
+}
+
+// SubscribeHead notifies ch of each new canonical head
+func (t *HeadTracker) SubscribeHead(ch chan<- *gethtypes.Header) event.Subscription {
+	return t.headFeed.Subscribe(ch)
+}
+
+// SubscribeReorg notifies ch of each reorg, before the new head is notified to head subscribers
+func (t *HeadTracker) SubscribeReorg(ch chan<- *ReorgEvent) event.Subscription {
+	return t.reorgFeed.Subscribe(ch)
+}
+
+// SubscribeFinalized notifies ch each time the finalized header advances
+func (t *HeadTracker) SubscribeFinalized(ch chan<- *gethtypes.Header) event.Subscription {
+	return t.finalizedFeed.Subscribe(ch)
+}
+
+// Head returns the current canonical head (nil if the tracker is not initialized)
+func (t *HeadTracker) Head() *gethtypes.Header {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.headers.last()
+}
+
+// Safe returns the last known safe header
+func (t *HeadTracker) Safe() *gethtypes.Header {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.safe
+}
+
+// Finalized returns the last known finalized header
+func (t *HeadTracker) Finalized() *gethtypes.Header {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.finalized
+}
+
+// Confirmations returns the number of blocks (including the block itself) on top of which
+// the block with the given number is buried, 0 if the block is above the current head
+func (t *HeadTracker) Confirmations(number uint64) uint64 {
+	head := t.Head()
+	if head == nil || number > head.Number.Uint64() {
+		return 0
+	}
+	return head.Number.Uint64() - number + 1
+}
+
+// IsCanonical indicates whether the block with the given hash and number is part of the canonical chain
+//
+// Blocks older than the tracked headers are checked against the node.
+func (t *HeadTracker) IsCanonical(ctx context.Context, blockHash gethcommon.Hash, number uint64) (bool, error) {
+	t.mu.RLock()
+	head := t.headers.last()
+	known := t.headers.get(number)
+	first := t.headers.first()
+	t.mu.RUnlock()
+
+	switch {
+	case head == nil || number > head.Number.Uint64():
+		return false, nil
+	case known != nil:
+		return known.Hash() == blockHash, nil
+	case first != nil && number > first.Number.Uint64():
+		return false, nil
+	}
+
+	header, err := t.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return false, err
+	}
+
+	return header.Hash() == blockHash, nil
+}
+
+// WaitConfirmations waits for the block with the given hash and number to be buried under
+// numConfirmations blocks (including the block itself).
+//
+// It returns ErrReorged if the block is no longer canonical.
+func (t *HeadTracker) WaitConfirmations(ctx context.Context, blockHash gethcommon.Hash, number, numConfirmations uint64) error {
+	heads := make(chan *gethtypes.Header, 16)
+	sub := t.SubscribeHead(heads)
+	defer sub.Unsubscribe()
+
+	for {
+		if t.Confirmations(number) > 0 {
+			canonical, err := t.IsCanonical(ctx, blockHash, number)
+			if err != nil {
+				return err
+			}
+			if !canonical {
+				return ErrReorged
+			}
+			if t.Confirmations(number) >= numConfirmations {
+				return nil
+			}
+		}
+
+		select {
+		case <-heads:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// headerRing is a ring buffer of consecutive headers
+type headerRing struct {
+	buf   []*gethtypes.Header
+	start int
+	size  int
+}
+
+func newHeaderRing(capacity int) *headerRing {
+	return &headerRing{buf: make([]*gethtypes.Header, capacity)}
+}
+
+func (r *headerRing) at(i int) *gethtypes.Header {
+	return r.buf[(r.start+i)%len(r.buf)]
+}
+
+func (r *headerRing) first() *gethtypes.Header {
+	if r.size == 0 {
+		return nil
+	}
+	return r.at(0)
+}
+
+func (r *headerRing) last() *gethtypes.Header {
+	if r.size == 0 {
+		return nil
+	}
+	return r.at(r.size - 1)
+}
+
+// get returns the header with the given number if it is in the buffer
+func (r *headerRing) get(number uint64) *gethtypes.Header {
+	first := r.first()
+	if first == nil || number < first.Number.Uint64() {
+		return nil
+	}
+
+	i := number - first.Number.Uint64()
+	if i >= uint64(r.size) {
+		return nil
+	}
+
+	return r.at(int(i))
+}
+
+// push appends header, overwriting the oldest header if the buffer is full
+func (r *headerRing) push(header *gethtypes.Header) {
+	if r.size < len(r.buf) {
+		r.buf[(r.start+r.size)%len(r.buf)] = header
+		r.size++
+		return
+	}
+
+	r.buf[r.start] = header
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+// truncate removes headers with a number greater or equal to number and returns them in ascending order
+func (r *headerRing) truncate(number uint64) []*gethtypes.Header {
+	var removed []*gethtypes.Header
+	for r.size > 0 && r.last().Number.Uint64() >= number {
+		removed = append([]*gethtypes.Header{r.last()}, removed...)
+		r.buf[(r.start+r.size-1)%len(r.buf)] = nil
+		r.size--
+	}
+	return removed
+}
+
+func (r *headerRing) reset() {
+	for i := range r.buf {
+		r.buf[i] = nil
+	}
+	r.start, r.size = 0, 0
+}
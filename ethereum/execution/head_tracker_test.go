@@ -0,0 +1,171 @@
+//go:build !integration
+
+//revive:disable-next-line:package-directory-mismatch
+package ethel
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang/mock/gomock"
+	kilntypes "github.com/kilnfi/go-utils/common/types"
+	"github.com/kilnfi/go-utils/ethereum/execution/client/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testChain returns n headers starting at number start on top of parent, fork distinguishes branches
+func testChain(parent *gethtypes.Header, start uint64, n int, fork byte) []*gethtypes.Header {
+	headers := make([]*gethtypes.Header, n)
+	parentHash := gethcommon.Hash{}
+	if parent != nil {
+		parentHash = parent.Hash()
+	}
+	for i := range headers {
+		headers[i] = &gethtypes.Header{
+			ParentHash: parentHash,
+			Number:     new(big.Int).SetUint64(start + uint64(i)),
+			Difficulty: big.NewInt(0),
+			Extra:      []byte{fork},
+		}
+		parentHash = headers[i].Hash()
+	}
+	return headers
+}
+
+func newTestHeadTracker(t *testing.T, depth int) (*HeadTracker, *mock.MockClient) {
+	t.Helper()
+	cli := mock.NewMockClient(gomock.NewController(t))
+	return NewHeadTracker((&HeadTrackerConfig{Depth: depth}).SetDefault(), cli), cli
+}
+
+func expectTags(cli *mock.MockClient, safe, finalized *gethtypes.Header) {
+	cli.EXPECT().HeaderByNumber(gomock.Any(), big.NewInt(int64(gethrpc.SafeBlockNumber))).Return(safe, nil).AnyTimes()
+	cli.EXPECT().HeaderByNumber(gomock.Any(), big.NewInt(int64(gethrpc.FinalizedBlockNumber))).Return(finalized, nil).AnyTimes()
+}
+
+func TestHeadTrackerExtend(t *testing.T) {
+	tracker, cli := newTestHeadTracker(t, 4)
+	chain := testChain(nil, 100, 6, 0)
+	expectTags(cli, chain[1], chain[0])
+
+	heads := make(chan *gethtypes.Header, 10)
+	sub := tracker.SubscribeHead(heads)
+	defer sub.Unsubscribe()
+
+	finalized := make(chan *gethtypes.Header, 10)
+	finalizedSub := tracker.SubscribeFinalized(finalized)
+	defer finalizedSub.Unsubscribe()
+
+	cli.EXPECT().HeaderByNumber(gomock.Any(), gomock.Nil()).Return(chain[0], nil)
+	require.NoError(t, tracker.Init(t.Context()))
+	assert.Equal(t, chain[0].Hash(), (<-heads).Hash())
+	assert.Equal(t, chain[0].Hash(), (<-finalized).Hash())
+
+	// missing headers are fetched to fill the gap
+	cli.EXPECT().HeaderByHash(gomock.Any(), chain[2].Hash()).Return(chain[2], nil)
+	cli.EXPECT().HeaderByHash(gomock.Any(), chain[1].Hash()).Return(chain[1], nil)
+	require.NoError(t, tracker.update(t.Context(), chain[3]))
+	for _, h := range chain[1:4] {
+		assert.Equal(t, h.Hash(), (<-heads).Hash())
+	}
+
+	require.NoError(t, tracker.update(t.Context(), chain[4]))
+	require.NoError(t, tracker.update(t.Context(), chain[5]))
+	assert.Equal(t, chain[5].Hash(), tracker.Head().Hash())
+	assert.Equal(t, chain[1].Hash(), tracker.Safe().Hash())
+	assert.Equal(t, chain[0].Hash(), tracker.Finalized().Hash())
+
+	// ring buffer only keeps the last 4 headers
+	assert.Nil(t, tracker.headers.get(101))
+	assert.Equal(t, chain[2].Hash(), tracker.headers.first().Hash())
+
+	assert.Equal(t, uint64(3), tracker.Confirmations(103))
+	assert.Equal(t, uint64(0), tracker.Confirmations(106))
+
+	// already known heads are ignored
+	require.NoError(t, tracker.update(t.Context(), chain[4]))
+	assert.Equal(t, chain[5].Hash(), tracker.Head().Hash())
+}
+
+func TestHeadTrackerReorg(t *testing.T) {
+	tracker, cli := newTestHeadTracker(t, 8)
+	chain := testChain(nil, 100, 5, 0)
+	fork := testChain(chain[2], 103, 3, 1)
+	expectTags(cli, chain[0], chain[0])
+
+	for _, h := range chain {
+		require.NoError(t, tracker.update(t.Context(), h))
+	}
+
+	reorgs := make(chan *ReorgEvent, 1)
+	sub := tracker.SubscribeReorg(reorgs)
+	defer sub.Unsubscribe()
+
+	cli.EXPECT().HeaderByHash(gomock.Any(), fork[1].Hash()).Return(fork[1], nil)
+	cli.EXPECT().HeaderByHash(gomock.Any(), fork[0].Hash()).Return(fork[0], nil)
+	require.NoError(t, tracker.update(t.Context(), fork[2]))
+
+	reorg := <-reorgs
+	assert.Equal(t, 2, reorg.Depth)
+	assert.Equal(t, chain[2].Hash(), reorg.Ancestor.Hash())
+	require.Len(t, reorg.Old, 2)
+	assert.Equal(t, chain[3].Hash(), reorg.Old[0].Hash())
+	require.Len(t, reorg.New, 3)
+	assert.Equal(t, fork[0].Hash(), reorg.New[0].Hash())
+	assert.Equal(t, fork[2].Hash(), tracker.Head().Hash())
+
+	canonical, err := tracker.IsCanonical(t.Context(), chain[3].Hash(), 103)
+	require.NoError(t, err)
+	assert.False(t, canonical)
+
+	canonical, err = tracker.IsCanonical(t.Context(), fork[0].Hash(), 103)
+	require.NoError(t, err)
+	assert.True(t, canonical)
+
+	assert.ErrorIs(t, tracker.WaitConfirmations(t.Context(), chain[3].Hash(), 103, 10), ErrReorged)
+	require.NoError(t, tracker.WaitConfirmations(t.Context(), fork[0].Hash(), 103, 3))
+}
+
+func TestHeaderRing(t *testing.T) {
+	r := newHeaderRing(3)
+	chain := testChain(nil, 10, 5, 0)
+	for _, h := range chain {
+		r.push(h)
+	}
+
+	assert.Equal(t, chain[2].Hash(), r.first().Hash())
+	assert.Equal(t, chain[4].Hash(), r.last().Hash())
+	assert.Equal(t, chain[3].Hash(), r.get(13).Hash())
+	assert.Nil(t, r.get(11))
+	assert.Nil(t, r.get(15))
+
+	removed := r.truncate(13)
+	require.Len(t, removed, 2)
+	assert.Equal(t, chain[3].Hash(), removed[0].Hash())
+	assert.Equal(t, chain[2].Hash(), r.last().Hash())
+
+	r.push(chain[3])
+	assert.Equal(t, chain[3].Hash(), r.last().Hash())
+}
+
+func TestHeadTrackerPolling(t *testing.T) {
+	cli := mock.NewMockClient(gomock.NewController(t))
+	tracker := NewHeadTracker((&HeadTrackerConfig{PollInterval: &kilntypes.Duration{Duration: time.Millisecond}}).SetDefault(), cli)
+	chain := testChain(nil, 100, 2, 0)
+	expectTags(cli, chain[0], chain[0])
+
+	cli.EXPECT().HeaderByNumber(gomock.Any(), gomock.Nil()).Return(chain[1], nil).MinTimes(1)
+
+	heads := make(chan *gethtypes.Header, 1)
+	sub := tracker.SubscribeHead(heads)
+	defer sub.Unsubscribe()
+
+	require.NoError(t, tracker.Start(t.Context()))
+	assert.Equal(t, chain[1].Hash(), (<-heads).Hash())
+	require.NoError(t, tracker.Stop(t.Context()))
+}
@@ -0,0 +1,70 @@
+package multicall
+
+import (
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// Multicall3Address is the address Multicall3 is deployed at on most EVM chains
+var Multicall3Address = gethcommon.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+const multicall3ABI = `[
+	{
+		"inputs": [
+			{
+				"components": [
+					{"internalType": "address", "name": "target", "type": "address"},
+					{"internalType": "bool", "name": "allowFailure", "type": "bool"},
+					{"internalType": "bytes", "name": "callData", "type": "bytes"}
+				],
+				"internalType": "struct Multicall3.Call3[]",
+				"name": "calls",
+				"type": "tuple[]"
+			}
+		],
+		"name": "aggregate3",
+		"outputs": [
+			{
+				"components": [
+					{"internalType": "bool", "name": "success", "type": "bool"},
+					{"internalType": "bytes", "name": "returnData", "type": "bytes"}
+				],
+				"internalType": "struct Multicall3.Result[]",
+				"name": "returnData",
+				"type": "tuple[]"
+			}
+		],
+		"stateMutability": "payable",
+		"type": "function"
+	}
+]`
+
+var parsedABI = mustParseABI(multicall3ABI)
+
+func mustParseABI(s string) gethabi.ABI {
+	parsed, err := gethabi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// call3 is the Multicall3.Call3 struct
+type call3 struct {
+	Target       gethcommon.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// result3 is the Multicall3.Result struct
+type result3 struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// call3Size returns the size of an ABI encoded call3 element (including its offset in the array)
+func call3Size(data []byte) int {
+	return 5*32 + (len(data)+31)/32*32
+}
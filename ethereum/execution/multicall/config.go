@@ -0,0 +1,62 @@
+package multicall
+
+import (
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	kilntypes "github.com/kilnfi/go-utils/common/types"
+)
+
+// Config for the multicaller
+type Config struct {
+	// Address of the Multicall3 contract (defaults to the canonical deployment address)
+	Address gethcommon.Address
+
+	// MaxCallsPerBatch is the maximum number of calls aggregated in a single aggregate3 call
+	MaxCallsPerBatch int
+
+	// MaxCalldataSize is the maximum size in bytes of the calldata of a single aggregate3 call
+	MaxCalldataSize int
+
+	// MaxGasPerBatch is the maximum cumulated gas of the calls aggregated in a single aggregate3 call,
+	// it should be lower than the node eth_call gas cap
+	MaxGasPerBatch uint64
+
+	// DefaultGasPerCall is the gas accounted for a call that does not set a gas limit
+	DefaultGasPerCall uint64
+
+	// Wait is the time CallContract waits for other calls to aggregate before sending a batch
+	Wait *kilntypes.Duration
+
+	// FallbackToBatch enables falling back to a JSON-RPC batch of eth_call
+	// if an aggregate3 call fails (e.g. Multicall3 is not deployed)
+	FallbackToBatch bool
+}
+
+func (cfg *Config) SetDefault() *Config {
+	if cfg.Address == (gethcommon.Address{}) {
+		cfg.Address = Multicall3Address
+	}
+
+	if cfg.MaxCallsPerBatch == 0 {
+		cfg.MaxCallsPerBatch = 500
+	}
+
+	if cfg.MaxCalldataSize == 0 {
+		cfg.MaxCalldataSize = 128 * 1024
+	}
+
+	if cfg.MaxGasPerBatch == 0 {
+		cfg.MaxGasPerBatch = 25_000_000
+	}
+
+	if cfg.DefaultGasPerCall == 0 {
+		cfg.DefaultGasPerCall = 50_000
+	}
+
+	if cfg.Wait == nil {
+		cfg.Wait = &kilntypes.Duration{Duration: 10 * time.Millisecond}
+	}
+
+	return cfg
+}
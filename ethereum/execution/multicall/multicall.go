@@ -0,0 +1,275 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethbind "github.com/ethereum/go-ethereum/accounts/abi/bind"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrCallFailed is wrapped by the error of an aggregated call that reverted
+var ErrCallFailed = errors.New("multicall: call failed")
+
+// BatchCaller is implemented by backends able to send contract calls in a JSON-RPC batch
+type BatchCaller interface {
+	BatchCallContract(ctx context.Context, msgs []geth.CallMsg, blockNumber *big.Int) ([]*types.CallResult, error)
+}
+
+var _ gethbind.ContractCaller = (*Multicaller)(nil)
+
+// Multicaller aggregates contract reads into Multicall3 aggregate3 calls
+//
+// It implements bind.ContractCaller so it can be used as backend by generated bindings,
+// in which case concurrent CallContract calls on the same block are aggregated.
+type Multicaller struct {
+	cfg     *Config
+	backend gethbind.ContractCaller
+
+	mu      sync.Mutex
+	pending map[string]*batch
+
+	logger logrus.FieldLogger
+}
+
+type request struct {
+	msg geth.CallMsg
+	res chan *types.CallResult
+}
+
+type batch struct {
+	ctx         context.Context
+	blockNumber *big.Int
+	reqs        []*request
+	timer       *time.Timer
+}
+
+// New creates a multicaller sending calls to backend
+//
+// If cfg.FallbackToBatch is set, backend should implement BatchCaller.
+func New(cfg *Config, backend gethbind.ContractCaller) *Multicaller {
+	m := &Multicaller{
+		cfg:     cfg,
+		backend: backend,
+		pending: make(map[string]*batch),
+	}
+
+	m.SetLogger(logrus.StandardLogger())
+
+	return m
+}
+
+func (m *Multicaller) Logger() logrus.FieldLogger {
+	return m.logger
+}
+
+func (m *Multicaller) SetLogger(logger logrus.FieldLogger) {
+	m.logger = logger.WithField("component", "multicall")
+}
+
+// Aggregate executes all calls at the given block using as few aggregate3 calls as possible.
+// The block number can be nil, in which case calls are executed at the latest block.
+//
+// Calls are allowed to fail individually, in which case the error of the call result wraps ErrCallFailed.
+// Calls must have a recipient and no value. As calls are executed by Multicall3, their sender is ignored.
+func (m *Multicaller) Aggregate(ctx context.Context, msgs []geth.CallMsg, blockNumber *big.Int) ([]*types.CallResult, error) {
+	for i := range msgs {
+		if msgs[i].To == nil {
+			return nil, fmt.Errorf("call %v has no recipient", i)
+		}
+		if msgs[i].Value != nil && msgs[i].Value.Sign() != 0 {
+			return nil, fmt.Errorf("call %v transfers value", i)
+		}
+	}
+
+	results := make([]*types.CallResult, 0, len(msgs))
+	for _, chunk := range m.chunks(msgs) {
+		res, err := m.aggregate(ctx, chunk, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res...)
+	}
+
+	return results, nil
+}
+
+// chunks splits calls so each aggregate3 call respects the configured limits
+func (m *Multicaller) chunks(msgs []geth.CallMsg) [][]geth.CallMsg {
+	var (
+		chunks     [][]geth.CallMsg
+		start      int
+		size       int
+		cumulGas   uint64
+		chunkCalls int
+	)
+	for i := range msgs {
+		callSize := call3Size(msgs[i].Data)
+		callGas := msgs[i].Gas
+		if callGas == 0 {
+			callGas = m.cfg.DefaultGasPerCall
+		}
+
+		if chunkCalls > 0 && (chunkCalls+1 > m.cfg.MaxCallsPerBatch ||
+			size+callSize > m.cfg.MaxCalldataSize ||
+			cumulGas+callGas > m.cfg.MaxGasPerBatch) {
+			chunks = append(chunks, msgs[start:i])
+			start, size, cumulGas, chunkCalls = i, 0, 0, 0
+		}
+
+		size += callSize
+		cumulGas += callGas
+		chunkCalls++
+	}
+
+	if chunkCalls > 0 {
+		chunks = append(chunks, msgs[start:])
+	}
+
+	return chunks
+}
+
+func (m *Multicaller) aggregate(ctx context.Context, msgs []geth.CallMsg, blockNumber *big.Int) ([]*types.CallResult, error) {
+	results, err := m.aggregate3(ctx, msgs, blockNumber)
+	if err == nil {
+		return results, nil
+	}
+
+	batchCaller, ok := m.backend.(BatchCaller)
+	if !m.cfg.FallbackToBatch || !ok {
+		return nil, err
+	}
+
+	m.logger.WithError(err).WithField("calls", len(msgs)).Warnf("aggregate3 call failed, fallback to JSON-RPC batch")
+
+	return batchCaller.BatchCallContract(ctx, msgs, blockNumber)
+}
+
+func (m *Multicaller) aggregate3(ctx context.Context, msgs []geth.CallMsg, blockNumber *big.Int) ([]*types.CallResult, error) {
+	calls := make([]call3, len(msgs))
+	for i := range msgs {
+		calls[i] = call3{
+			Target:       *msgs[i].To,
+			AllowFailure: true,
+			CallData:     msgs[i].Data,
+		}
+	}
+
+	data, err := parsedABI.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack aggregate3 call: %w", err)
+	}
+
+	output, err := m.backend.CallContract(ctx, geth.CallMsg{To: &m.cfg.Address, Data: data}, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate3 call failed: %w", err)
+	}
+
+	var res []result3
+	if err := parsedABI.UnpackIntoInterface(&res, "aggregate3", output); err != nil {
+		return nil, fmt.Errorf("failed to unpack aggregate3 result: %w", err)
+	}
+
+	if len(res) != len(msgs) {
+		return nil, fmt.Errorf("aggregate3 returned %v results for %v calls", len(res), len(msgs))
+	}
+
+	results := make([]*types.CallResult, len(res))
+	for i := range res {
+		results[i] = &types.CallResult{ReturnData: res[i].ReturnData}
+		if !res[i].Success {
+			results[i].Err = fmt.Errorf("%w: revert data %#x", ErrCallFailed, res[i].ReturnData)
+		}
+	}
+
+	return results, nil
+}
+
+// CodeAt returns the code of the given account
+func (m *Multicaller) CodeAt(ctx context.Context, contract gethcommon.Address, blockNumber *big.Int) ([]byte, error) {
+	return m.backend.CodeAt(ctx, contract, blockNumber)
+}
+
+// CallContract executes a contract call
+//
+// The call is aggregated with concurrent calls on the same block, waiting at most cfg.Wait
+// for other calls to arrive. Calls that can not be aggregated (having a sender or a value) are sent directly.
+func (m *Multicaller) CallContract(ctx context.Context, msg geth.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if msg.To == nil || msg.From != (gethcommon.Address{}) || (msg.Value != nil && msg.Value.Sign() != 0) {
+		return m.backend.CallContract(ctx, msg, blockNumber)
+	}
+
+	req := &request{
+		msg: msg,
+		res: make(chan *types.CallResult, 1),
+	}
+	m.enqueue(ctx, req, blockNumber)
+
+	select {
+	case res := <-req.res:
+		return res.ReturnData, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *Multicaller) enqueue(ctx context.Context, req *request, blockNumber *big.Int) {
+	key := types.ToBlockNumArg(blockNumber)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.pending[key]
+	if !ok {
+		b = &batch{
+			// the batch is shared by several callers so it must not be canceled by the first one
+			ctx:         context.WithoutCancel(ctx),
+			blockNumber: blockNumber,
+		}
+		b.timer = time.AfterFunc(m.cfg.Wait.Duration, func() { m.flush(key, b) })
+		m.pending[key] = b
+	}
+
+	b.reqs = append(b.reqs, req)
+	if len(b.reqs) >= m.cfg.MaxCallsPerBatch {
+		b.timer.Stop()
+		delete(m.pending, key)
+		go m.execute(b)
+	}
+}
+
+func (m *Multicaller) flush(key string, b *batch) {
+	m.mu.Lock()
+	if m.pending[key] != b {
+		// batch has already been executed because it was full
+		m.mu.Unlock()
+		return
+	}
+	delete(m.pending, key)
+	m.mu.Unlock()
+
+	m.execute(b)
+}
+
+func (m *Multicaller) execute(b *batch) {
+	msgs := make([]geth.CallMsg, len(b.reqs))
+	for i, req := range b.reqs {
+		msgs[i] = req.msg
+	}
+
+	results, err := m.Aggregate(b.ctx, msgs, b.blockNumber)
+	for i, req := range b.reqs {
+		if err != nil {
+			req.res <- &types.CallResult{Err: err}
+			continue
+		}
+		req.res <- results[i]
+	}
+}
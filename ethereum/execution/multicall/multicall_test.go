@@ -0,0 +1,164 @@
+//go:build !integration
+
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	kilntypes "github.com/kilnfi/go-utils/common/types"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var failData = []byte{0xff}
+
+// testBackend executes aggregate3 calls by echoing each call data, calls with failData revert
+type testBackend struct {
+	aggregateCalls atomic.Int32
+	directCalls    atomic.Int32
+	failAggregate  bool
+	batchCalls     atomic.Int32
+}
+
+func (b *testBackend) CodeAt(context.Context, gethcommon.Address, *big.Int) ([]byte, error) {
+	return []byte{0x60}, nil
+}
+
+func (b *testBackend) CallContract(_ context.Context, msg geth.CallMsg, _ *big.Int) ([]byte, error) {
+	if *msg.To != Multicall3Address {
+		b.directCalls.Add(1)
+		return msg.Data, nil
+	}
+
+	b.aggregateCalls.Add(1)
+	if b.failAggregate {
+		return nil, errors.New("execution reverted")
+	}
+
+	method := parsedABI.Methods["aggregate3"]
+	values, err := method.Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	var calls []call3
+	if err := method.Inputs.Copy(&calls, values); err != nil {
+		return nil, err
+	}
+
+	res := make([]result3, len(calls))
+	for i, call := range calls {
+		res[i] = result3{Success: !bytes.Equal(call.CallData, failData), ReturnData: call.CallData}
+	}
+
+	return method.Outputs.Pack(res)
+}
+
+func (b *testBackend) BatchCallContract(_ context.Context, msgs []geth.CallMsg, _ *big.Int) ([]*types.CallResult, error) {
+	b.batchCalls.Add(1)
+	res := make([]*types.CallResult, len(msgs))
+	for i := range msgs {
+		res[i] = &types.CallResult{ReturnData: msgs[i].Data}
+	}
+	return res, nil
+}
+
+func testMsgs(n int) []geth.CallMsg {
+	to := gethcommon.HexToAddress("0x0000000000000000000000000000000000000001")
+	msgs := make([]geth.CallMsg, n)
+	for i := range msgs {
+		msgs[i] = geth.CallMsg{To: &to, Data: big.NewInt(int64(i + 1)).Bytes()}
+	}
+	return msgs
+}
+
+func TestAggregate(t *testing.T) {
+	backend := new(testBackend)
+	m := New((&Config{MaxCallsPerBatch: 4}).SetDefault(), backend)
+
+	msgs := testMsgs(10)
+	msgs[3].Data = failData
+
+	res, err := m.Aggregate(t.Context(), msgs, nil)
+	require.NoError(t, err)
+	require.Len(t, res, 10)
+	assert.Equal(t, int32(3), backend.aggregateCalls.Load())
+
+	for i := range res {
+		if i == 3 {
+			require.ErrorIs(t, res[i].Err, ErrCallFailed)
+			continue
+		}
+		require.NoError(t, res[i].Err)
+		assert.Equal(t, msgs[i].Data, res[i].ReturnData)
+	}
+}
+
+func TestAggregateFallbackToBatch(t *testing.T) {
+	backend := &testBackend{failAggregate: true}
+
+	m := New(new(Config).SetDefault(), backend)
+	_, err := m.Aggregate(t.Context(), testMsgs(2), nil)
+	require.Error(t, err)
+
+	m = New((&Config{FallbackToBatch: true}).SetDefault(), backend)
+	res, err := m.Aggregate(t.Context(), testMsgs(2), nil)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, int32(1), backend.batchCalls.Load())
+}
+
+func TestChunks(t *testing.T) {
+	m := New((&Config{MaxGasPerBatch: 100, DefaultGasPerCall: 40}).SetDefault(), nil)
+	chunks := m.chunks(testMsgs(5))
+	require.Len(t, chunks, 3)
+	assert.Len(t, chunks[0], 2)
+	assert.Len(t, chunks[2], 1)
+
+	m = New((&Config{MaxCalldataSize: 2 * call3Size(nil)}).SetDefault(), nil)
+	msgs := testMsgs(3)
+	msgs[1].Data = make([]byte, 64)
+	chunks = m.chunks(msgs)
+	require.Len(t, chunks, 3)
+}
+
+func TestCallContract(t *testing.T) {
+	backend := new(testBackend)
+	m := New((&Config{Wait: &kilntypes.Duration{Duration: 50 * time.Millisecond}}).SetDefault(), backend)
+
+	msgs := testMsgs(20)
+	msgs[5].Data = failData
+	var wg sync.WaitGroup
+	for i := range msgs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := m.CallContract(t.Context(), msgs[i], big.NewInt(10))
+			if i == 5 {
+				assert.ErrorIs(t, err, ErrCallFailed)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, msgs[i].Data, res)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), backend.aggregateCalls.Load())
+
+	// calls with a sender are not aggregated
+	msg := testMsgs(1)[0]
+	msg.From = gethcommon.HexToAddress("0x0000000000000000000000000000000000000002")
+	_, err := m.CallContract(t.Context(), msg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), backend.directCalls.Load())
+}
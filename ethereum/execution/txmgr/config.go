@@ -0,0 +1,55 @@
+package txmgr
+
+import (
+	"math/big"
+	"time"
+
+	kilntypes "github.com/kilnfi/go-utils/common/types"
+)
+
+// Config for the transaction manager
+type Config struct {
+	// NumConfirmations is the number of blocks (including the inclusion block) a transaction
+	// must be buried under before being considered confirmed
+	NumConfirmations uint64
+
+	// ResubmitInterval is the time to wait for a transaction to be mined before bumping its fees
+	ResubmitInterval *kilntypes.Duration
+
+	// ReceiptQueryInterval is the interval at which receipts are polled
+	ReceiptQueryInterval *kilntypes.Duration
+
+	// PriceBump is the percentage by which fees are bumped on replacement
+	// (EIP-1559 replacement rules require at least 10%)
+	PriceBump uint64
+
+	// MaxGasFeeCap is an optional ceiling for the gas fee cap in Wei (nil = no ceiling)
+	MaxGasFeeCap *big.Int
+
+	// GasLimitMultiplier is applied to the estimated gas limit when no gas limit is provided
+	GasLimitMultiplier float64
+}
+
+func (cfg *Config) SetDefault() *Config {
+	if cfg.NumConfirmations == 0 {
+		cfg.NumConfirmations = 1
+	}
+
+	if cfg.ResubmitInterval == nil {
+		cfg.ResubmitInterval = &kilntypes.Duration{Duration: 48 * time.Second}
+	}
+
+	if cfg.ReceiptQueryInterval == nil {
+		cfg.ReceiptQueryInterval = &kilntypes.Duration{Duration: 12 * time.Second}
+	}
+
+	if cfg.PriceBump < minPriceBump {
+		cfg.PriceBump = minPriceBump
+	}
+
+	if cfg.GasLimitMultiplier == 0 {
+		cfg.GasLimitMultiplier = 1.0
+	}
+
+	return cfg
+}
@@ -0,0 +1,549 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/kilnfi/go-utils/ethereum/execution/client"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
+	"github.com/sirupsen/logrus"
+)
+
+// minPriceBump is the minimum fee bump (in percent) accepted by nodes to replace a transaction
+const minPriceBump = 10
+
+var (
+	// ErrNonceConsumed is returned when the nonce of a pending transaction has been
+	// consumed by a transaction that is not tracked by the manager
+	ErrNonceConsumed = errors.New("nonce consumed by an untracked transaction")
+
+	errFeeCapExceeded = errors.New("bumped gas fee cap exceeds configured maximum")
+)
+
+// TxCandidate is a transaction to be sent by the manager
+type TxCandidate struct {
+	From     gethcommon.Address  // Ethereum account to send the transaction from
+	To       *gethcommon.Address // Recipient of the transaction (nil = contract creation)
+	Data     []byte              // Transaction calldata
+	Value    *big.Int            // Funds to transfer along the transaction (nil = 0 = no funds)
+	GasLimit uint64              // Gas limit to set for the transaction execution (0 = estimate)
+}
+
+type nonceTracker struct {
+	mu     sync.Mutex
+	next   uint64
+	floor  uint64
+	synced bool
+}
+
+// Manager sends transactions and follows them until confirmation
+//
+// It allocates nonces locally per sender, bumps fees of transactions that are not mined in a timely
+// manner following EIP-1559 replacement rules and persists in-flight transactions in a Store so
+// a restarted manager can resume monitoring without double-sending.
+type Manager struct {
+	cfg    *Config
+	client client.Client
+	signTx types.SignTxFunc
+	store  Store
+
+	mu     sync.Mutex
+	nonces map[gethcommon.Address]*nonceTracker
+
+	logger logrus.FieldLogger
+}
+
+// New creates a new transaction manager
+func New(cfg *Config, cli client.Client, signTx types.SignTxFunc, store Store) *Manager {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
+	m := &Manager{
+		cfg:    cfg,
+		client: cli,
+		signTx: signTx,
+		store:  store,
+		nonces: make(map[gethcommon.Address]*nonceTracker),
+	}
+
+	m.SetLogger(logrus.StandardLogger())
+
+	return m
+}
+
+func (m *Manager) Logger() logrus.FieldLogger {
+	return m.logger
+}
+
+func (m *Manager) SetLogger(logger logrus.FieldLogger) {
+	m.logger = logger.WithField("component", "txmgr")
+}
+
+// Init loads in-flight transactions from the store so nonces allocated before a restart are not reused
+func (m *Manager) Init(ctx context.Context) error {
+	ptxs, err := m.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load pending transactions: %w", err)
+	}
+
+	for _, ptx := range ptxs {
+		tracker := m.tracker(ptx.From)
+		tracker.mu.Lock()
+		if ptx.Nonce+1 > tracker.floor {
+			tracker.floor = ptx.Nonce + 1
+		}
+		tracker.synced = false
+		tracker.mu.Unlock()
+	}
+
+	if len(ptxs) > 0 {
+		m.logger.WithField("count", len(ptxs)).Info("loaded pending transactions")
+	}
+
+	return nil
+}
+
+// Pending returns all in-flight transactions
+func (m *Manager) Pending(ctx context.Context) ([]*PendingTx, error) {
+	return m.store.List(ctx)
+}
+
+// ResetNonce forces the nonce of the given sender to be resynced against the node on next allocation
+func (m *Manager) ResetNonce(from gethcommon.Address) {
+	tracker := m.tracker(from)
+	tracker.mu.Lock()
+	tracker.synced = false
+	tracker.mu.Unlock()
+}
+
+// Send publishes the candidate and blocks until it is confirmed
+//
+// The returned receipt may have a failed status, it is the responsibility of the caller to check it.
+func (m *Manager) Send(ctx context.Context, candidate *TxCandidate) (*gethtypes.Receipt, error) {
+	ptx, err := m.Publish(ctx, candidate)
+	// on send errors that are not rejections the transaction may have been sent, keep waiting for it
+	if ptx == nil {
+		return nil, err
+	}
+
+	return m.WaitConfirmed(ctx, ptx)
+}
+
+// Publish allocates a nonce, signs and sends the candidate without waiting for it to be mined
+//
+// If the node rejects the transaction, it is dropped and its nonce released. If sending fails for
+// another reason (e.g. a timeout) the transaction may still have reached the node: it is kept in the
+// store and returned along with the error, to be resolved with WaitConfirmed or Recover.
+func (m *Manager) Publish(ctx context.Context, candidate *TxCandidate) (*PendingTx, error) {
+	tracker := m.tracker(candidate.From)
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if !tracker.synced {
+		if err := m.syncNonce(ctx, candidate.From, tracker); err != nil {
+			return nil, err
+		}
+	}
+	nonce := tracker.next
+
+	logger := m.logger.WithField("from", candidate.From).WithField("nonce", nonce)
+
+	tx, err := m.craftTx(ctx, candidate, nonce)
+	if err != nil {
+		logger.WithError(err).Error("failed to craft transaction")
+		return nil, err
+	}
+
+	signedTx, err := m.sign(ctx, candidate.From, tx)
+	if err != nil {
+		logger.WithError(err).Error("failed to sign transaction")
+		return nil, err
+	}
+
+	ptx := &PendingTx{
+		From:  candidate.From,
+		Nonce: nonce,
+		Txs:   []*gethtypes.Transaction{signedTx},
+	}
+
+	// Persist before sending so a crash right after sending can not lead to a double-send
+	if err := m.store.Put(ctx, ptx); err != nil {
+		return nil, fmt.Errorf("failed to persist pending transaction: %w", err)
+	}
+
+	err = m.client.SendTransaction(ctx, signedTx)
+	switch {
+	case err == nil, isAlreadyKnown(err):
+	case isRejected(err):
+		logger.WithError(err).Error("transaction rejected")
+		if delErr := m.store.Delete(ctx, ptx.From, ptx.Nonce); delErr != nil {
+			logger.WithError(delErr).Error("failed to delete pending transaction")
+		}
+		// nonce has not been used, resync on next allocation
+		tracker.synced = false
+		return nil, err
+	default:
+		// the node may have received the transaction (e.g. the connection dropped after sending)
+		// so it is kept and its nonce considered used, WaitConfirmed replaces it if it is never mined
+		logger.WithError(err).Error("failed to send transaction")
+		tracker.next = nonce + 1
+		return ptx, err
+	}
+
+	tracker.next = nonce + 1
+
+	logger.WithField("tx.hash", signedTx.Hash()).Info("transaction sent")
+
+	return ptx, nil
+}
+
+// WaitConfirmed monitors a pending transaction until one of its versions is confirmed.
+// Fees are bumped every ResubmitInterval while no version has been mined, replacement versions
+// are persisted in the store and ptx is left unmodified.
+func (m *Manager) WaitConfirmed(ctx context.Context, ptx *PendingTx) (*gethtypes.Receipt, error) {
+	logger := m.logger.WithField("from", ptx.From).WithField("nonce", ptx.Nonce)
+
+	ticker := time.NewTicker(m.cfg.ReceiptQueryInterval.Duration)
+	defer ticker.Stop()
+
+	lastSent := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		receipt, err := m.receipt(ctx, ptx)
+		if err != nil {
+			logger.WithError(err).Warn("failed to fetch transaction receipt")
+			continue
+		}
+
+		if receipt != nil {
+			confirmed, err := m.isConfirmed(ctx, receipt)
+			if err != nil {
+				logger.WithError(err).Warn("failed to fetch block number")
+				continue
+			}
+
+			if confirmed {
+				if err := m.store.Delete(ctx, ptx.From, ptx.Nonce); err != nil {
+					logger.WithError(err).Error("failed to delete pending transaction")
+				}
+				logger.
+					WithField("tx.hash", receipt.TxHash).
+					WithField("block.number", receipt.BlockNumber).
+					WithField("tx.status", receipt.Status).
+					Info("transaction confirmed")
+				return receipt, nil
+			}
+
+			// Mined but not confirmed yet, do not bump
+			continue
+		}
+
+		if time.Since(lastSent) < m.cfg.ResubmitInterval.Duration {
+			continue
+		}
+
+		bumped, err := m.bump(ctx, ptx)
+		if bumped != nil {
+			// a new version has been persisted, even if sending it failed do not bump it before the interval
+			ptx = bumped
+			lastSent = time.Now()
+		}
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrNonceConsumed):
+			if delErr := m.store.Delete(ctx, ptx.From, ptx.Nonce); delErr != nil {
+				logger.WithError(delErr).Error("failed to delete pending transaction")
+			}
+			m.ResetNonce(ptx.From)
+			return nil, err
+		case errors.Is(err, errFeeCapExceeded):
+			logger.WithError(err).Warn("could not bump transaction fees, keep waiting")
+			lastSent = time.Now()
+		default:
+			logger.WithError(err).Warn("failed to bump transaction fees")
+		}
+	}
+}
+
+// Recover resumes monitoring of every in-flight transaction found in the store and waits for all of them
+func (m *Manager) Recover(ctx context.Context) ([]*gethtypes.Receipt, error) {
+	ptxs, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := make([]*gethtypes.Receipt, len(ptxs))
+	errs := make([]error, len(ptxs))
+
+	var wg sync.WaitGroup
+	for i, ptx := range ptxs {
+		wg.Add(1)
+		go func(i int, ptx *PendingTx) {
+			defer wg.Done()
+			receipts[i], errs[i] = m.WaitConfirmed(ctx, ptx)
+		}(i, ptx)
+	}
+	wg.Wait()
+
+	return receipts, errors.Join(errs...)
+}
+
+func (m *Manager) tracker(from gethcommon.Address) *nonceTracker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tracker, ok := m.nonces[from]
+	if !ok {
+		tracker = new(nonceTracker)
+		m.nonces[from] = tracker
+	}
+	return tracker
+}
+
+func (m *Manager) syncNonce(ctx context.Context, from gethcommon.Address, tracker *nonceTracker) error {
+	nonce, err := m.client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return fmt.Errorf("failed to fetch pending nonce: %w", err)
+	}
+
+	if nonce < tracker.floor {
+		nonce = tracker.floor
+	}
+
+	tracker.next = nonce
+	tracker.synced = true
+
+	return nil
+}
+
+func (m *Manager) craftTx(ctx context.Context, candidate *TxCandidate, nonce uint64) (*gethtypes.Transaction, error) {
+	chainID, err := m.client.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gasTipCap, gasFeeCap, err := m.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.cfg.MaxGasFeeCap != nil && gasFeeCap.Cmp(m.cfg.MaxGasFeeCap) > 0 {
+		gasFeeCap = new(big.Int).Set(m.cfg.MaxGasFeeCap)
+		if gasTipCap.Cmp(gasFeeCap) > 0 {
+			gasTipCap = new(big.Int).Set(gasFeeCap)
+		}
+	}
+
+	value := candidate.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	gasLimit := candidate.GasLimit
+	if gasLimit == 0 {
+		estimated, err := m.client.EstimateGas(ctx, geth.CallMsg{
+			From:      candidate.From,
+			To:        candidate.To,
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+			Value:     value,
+			Data:      candidate.Data,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate gas: %w", err)
+		}
+		gasLimit = uint64(math.Ceil(float64(estimated) * m.cfg.GasLimitMultiplier))
+	}
+
+	return gethtypes.NewTx(&gethtypes.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       gasLimit,
+		To:        candidate.To,
+		Value:     value,
+		Data:      candidate.Data,
+	}), nil
+}
+
+// suggestFees returns the current gas tip cap and a gas fee cap allowing for a doubling of the base fee
+func (m *Manager) suggestFees(ctx context.Context) (gasTipCap, gasFeeCap *big.Int, err error) {
+	gasTipCap, err = m.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+	}
+
+	head, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch head header: %w", err)
+	}
+
+	if head.BaseFee == nil {
+		return nil, nil, errors.New("head header has no base fee (EIP-1559 not supported)")
+	}
+
+	gasFeeCap = new(big.Int).Add(
+		gasTipCap,
+		new(big.Int).Mul(head.BaseFee, big.NewInt(2)),
+	)
+
+	return gasTipCap, gasFeeCap, nil
+}
+
+func (m *Manager) sign(ctx context.Context, from gethcommon.Address, tx *gethtypes.Transaction) (*gethtypes.Transaction, error) {
+	return m.signTx(ctx, from, tx, tx.ChainId())
+}
+
+// bump replaces the latest version of a pending transaction with one paying higher fees
+//
+// ptx is not modified, the pending transaction including the replacement is returned once persisted
+// (even if sending the replacement failed, as it may still have reached the network).
+func (m *Manager) bump(ctx context.Context, ptx *PendingTx) (*PendingTx, error) {
+	latest := ptx.Latest()
+
+	gasTipCap, gasFeeCap, err := m.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gasTipCap = bigMax(gasTipCap, bumpFee(latest.GasTipCap(), m.cfg.PriceBump))
+	gasFeeCap = bigMax(gasFeeCap, bumpFee(latest.GasFeeCap(), m.cfg.PriceBump))
+	gasFeeCap = bigMax(gasFeeCap, gasTipCap)
+
+	if m.cfg.MaxGasFeeCap != nil && gasFeeCap.Cmp(m.cfg.MaxGasFeeCap) > 0 {
+		return nil, fmt.Errorf("%w (fee cap=%v max=%v)", errFeeCapExceeded, gasFeeCap, m.cfg.MaxGasFeeCap)
+	}
+
+	tx := gethtypes.NewTx(&gethtypes.DynamicFeeTx{
+		ChainID:   latest.ChainId(),
+		Nonce:     latest.Nonce(),
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       latest.Gas(),
+		To:        latest.To(),
+		Value:     latest.Value(),
+		Data:      latest.Data(),
+	})
+
+	signedTx, err := m.sign(ctx, ptx.From, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	bumped := ptx.clone()
+	bumped.Txs = append(bumped.Txs, signedTx)
+	if err := m.store.Put(ctx, bumped); err != nil {
+		return nil, fmt.Errorf("failed to persist pending transaction: %w", err)
+	}
+
+	logger := m.logger.
+		WithField("from", ptx.From).
+		WithField("nonce", ptx.Nonce).
+		WithField("tx.hash", signedTx.Hash()).
+		WithField("tx.gasTipCap", gasTipCap).
+		WithField("tx.gasFeeCap", gasFeeCap)
+
+	err = m.client.SendTransaction(ctx, signedTx)
+	switch {
+	case err == nil, isAlreadyKnown(err):
+		logger.Info("replacement transaction sent")
+		return bumped, nil
+	case isNonceTooLow(err):
+		// One of our versions may just have been mined, otherwise the nonce was consumed by someone else
+		receipt, rErr := m.receipt(ctx, bumped)
+		if rErr == nil && receipt == nil {
+			return bumped, fmt.Errorf("%w (from=%v nonce=%v)", ErrNonceConsumed, ptx.From, ptx.Nonce)
+		}
+		return bumped, nil
+	default:
+		return bumped, err
+	}
+}
+
+// receipt returns the receipt of the first mined version of a pending transaction (nil if none is mined)
+func (m *Manager) receipt(ctx context.Context, ptx *PendingTx) (*gethtypes.Receipt, error) {
+	for i := len(ptx.Txs) - 1; i >= 0; i-- {
+		receipt, err := m.client.TransactionReceipt(ctx, ptx.Txs[i].Hash())
+		if errors.Is(err, geth.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			return receipt, nil
+		}
+	}
+	return nil, nil //nolint:nilnil // nil receipt means none of the versions is mined yet
+}
+
+func (m *Manager) isConfirmed(ctx context.Context, receipt *gethtypes.Receipt) (bool, error) {
+	head, err := m.client.BlockNumber(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return head+1 >= receipt.BlockNumber.Uint64()+m.cfg.NumConfirmations, nil
+}
+
+// bumpFee returns fee increased by bump percent, rounded up
+func bumpFee(fee *big.Int, bump uint64) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+bump))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func isAlreadyKnown(err error) bool {
+	return strings.Contains(err.Error(), "already known")
+}
+
+// rejectionErrors are errors returned by nodes that reject a transaction from their pool
+var rejectionErrors = []string{
+	"nonce too low",
+	"nonce too high",
+	"insufficient funds",
+	"intrinsic gas too low",
+	"underpriced",
+	"fee per gas",
+	"exceeds block gas limit",
+	"invalid sender",
+	"oversized data",
+	"negative value",
+	"txpool is full",
+}
+
+// isRejected indicates whether err proves the node rejected the transaction
+func isRejected(err error) bool {
+	for _, msg := range rejectionErrors {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNonceTooLow(err error) bool {
+	return strings.Contains(err.Error(), "nonce too low")
+}
@@ -0,0 +1,306 @@
+//go:build !integration
+
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/golang/mock/gomock"
+	kilntypes "github.com/kilnfi/go-utils/common/types"
+	"github.com/kilnfi/go-utils/ethereum/execution/client/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testKey, _ = gethcrypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr   = gethcrypto.PubkeyToAddress(testKey.PublicKey)
+	testTo     = gethcommon.HexToAddress("0x4592d8f8d7b001e72cb26a73e4fa1806a51ac79d")
+)
+
+func testSignTx(_ context.Context, _ gethcommon.Address, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
+	return gethtypes.SignTx(tx, gethtypes.LatestSignerForChainID(chainID), testKey)
+}
+
+func newTestManager(t *testing.T, cli *mock.MockClient, store Store) *Manager {
+	t.Helper()
+	cfg := (&Config{
+		ResubmitInterval:     &kilntypes.Duration{Duration: 20 * time.Millisecond},
+		ReceiptQueryInterval: &kilntypes.Duration{Duration: 5 * time.Millisecond},
+		NumConfirmations:     2,
+	}).SetDefault()
+	return New(cfg, cli, testSignTx, store)
+}
+
+func expectFees(cli *mock.MockClient) {
+	cli.EXPECT().ChainID(gomock.Any()).Return(big.NewInt(1), nil).AnyTimes()
+	cli.EXPECT().SuggestGasTipCap(gomock.Any()).Return(big.NewInt(1000), nil).AnyTimes()
+	cli.EXPECT().HeaderByNumber(gomock.Any(), gomock.Nil()).Return(&gethtypes.Header{BaseFee: big.NewInt(10000)}, nil).AnyTimes()
+}
+
+func TestPublishAllocatesNonces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mock.NewMockClient(ctrl)
+	expectFees(cli)
+
+	m := newTestManager(t, cli, nil)
+
+	cli.EXPECT().PendingNonceAt(gomock.Any(), testAddr).Return(uint64(5), nil).Times(1)
+	cli.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	ptx1, err := m.Publish(t.Context(), &TxCandidate{From: testAddr, To: &testTo, GasLimit: 21000})
+	require.NoError(t, err)
+	ptx2, err := m.Publish(t.Context(), &TxCandidate{From: testAddr, To: &testTo, GasLimit: 21000})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(5), ptx1.Nonce)
+	assert.Equal(t, uint64(6), ptx2.Nonce)
+	assert.Equal(t, big.NewInt(1000), ptx1.Latest().GasTipCap())
+	assert.Equal(t, big.NewInt(21000), ptx1.Latest().GasFeeCap())
+
+	pending, err := m.Pending(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+}
+
+func TestPublishSendFailureReleasesNonce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mock.NewMockClient(ctrl)
+	expectFees(cli)
+
+	m := newTestManager(t, cli, nil)
+
+	gomock.InOrder(
+		cli.EXPECT().PendingNonceAt(gomock.Any(), testAddr).Return(uint64(5), nil),
+		cli.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(errors.New("insufficient funds for gas * price + value")),
+		cli.EXPECT().PendingNonceAt(gomock.Any(), testAddr).Return(uint64(5), nil),
+		cli.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	_, err := m.Publish(t.Context(), &TxCandidate{From: testAddr, To: &testTo, GasLimit: 21000})
+	require.Error(t, err)
+
+	ptx, err := m.Publish(t.Context(), &TxCandidate{From: testAddr, To: &testTo, GasLimit: 21000})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), ptx.Nonce)
+
+	pending, err := m.Pending(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
+
+func TestPublishSendErrorKeepsTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mock.NewMockClient(ctrl)
+	expectFees(cli)
+
+	m := newTestManager(t, cli, nil)
+
+	// the connection drops after the node received the transaction
+	gomock.InOrder(
+		cli.EXPECT().PendingNonceAt(gomock.Any(), testAddr).Return(uint64(5), nil),
+		cli.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(context.DeadlineExceeded),
+		cli.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	ptx, err := m.Publish(t.Context(), &TxCandidate{From: testAddr, To: &testTo, GasLimit: 21000})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotNil(t, ptx)
+	assert.Equal(t, uint64(5), ptx.Nonce)
+
+	pending, err := m.Pending(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, ptx.Latest().Hash(), pending[0].Latest().Hash())
+
+	// nonce is considered used
+	ptx, err = m.Publish(t.Context(), &TxCandidate{From: testAddr, To: &testTo, GasLimit: 21000})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), ptx.Nonce)
+}
+
+func TestSendBumpsFeesUntilConfirmed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mock.NewMockClient(ctrl)
+	expectFees(cli)
+
+	m := newTestManager(t, cli, nil)
+
+	var sent []*gethtypes.Transaction
+	cli.EXPECT().PendingNonceAt(gomock.Any(), testAddr).Return(uint64(0), nil)
+	cli.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, tx *gethtypes.Transaction) error {
+			sent = append(sent, tx)
+			return nil
+		},
+	).MinTimes(2)
+	cli.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, hash gethcommon.Hash) (*gethtypes.Receipt, error) {
+			// Only the first replacement gets mined
+			if len(sent) > 1 && hash == sent[1].Hash() {
+				return &gethtypes.Receipt{TxHash: hash, BlockNumber: big.NewInt(100), Status: gethtypes.ReceiptStatusSuccessful}, nil
+			}
+			return nil, geth.NotFound
+		},
+	).AnyTimes()
+	cli.EXPECT().BlockNumber(gomock.Any()).Return(uint64(101), nil).AnyTimes()
+
+	receipt, err := m.Send(t.Context(), &TxCandidate{From: testAddr, To: &testTo, GasLimit: 21000})
+	require.NoError(t, err)
+	require.Len(t, sent, 2)
+	assert.Equal(t, sent[1].Hash(), receipt.TxHash)
+	assert.Equal(t, sent[0].Nonce(), sent[1].Nonce())
+	assert.Equal(t, big.NewInt(1100), sent[1].GasTipCap())
+	assert.Equal(t, big.NewInt(23100), sent[1].GasFeeCap())
+
+	pending, err := m.Pending(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestBumpSendFailureWaitsResubmitInterval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mock.NewMockClient(ctrl)
+	expectFees(cli)
+
+	cfg := (&Config{
+		ResubmitInterval:     &kilntypes.Duration{Duration: 50 * time.Millisecond},
+		ReceiptQueryInterval: &kilntypes.Duration{Duration: 5 * time.Millisecond},
+	}).SetDefault()
+	m := New(cfg, cli, testSignTx, nil)
+
+	var replacements int
+	gomock.InOrder(
+		cli.EXPECT().PendingNonceAt(gomock.Any(), testAddr).Return(uint64(0), nil),
+		cli.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(nil),
+	)
+	cli.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, *gethtypes.Transaction) error {
+			replacements++
+			return errors.New("replacement transaction underpriced")
+		},
+	).AnyTimes()
+	cli.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(nil, geth.NotFound).AnyTimes()
+
+	ptx, err := m.Publish(t.Context(), &TxCandidate{From: testAddr, To: &testTo, GasLimit: 21000})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 130*time.Millisecond)
+	defer cancel()
+	_, err = m.WaitConfirmed(ctx, ptx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// a failed replacement is bumped again only after the resubmit interval
+	assert.GreaterOrEqual(t, replacements, 1)
+	assert.LessOrEqual(t, replacements, 3)
+
+	pending, err := m.Pending(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Len(t, pending[0].Txs, replacements+1)
+}
+
+func TestPendingWhileBumping(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mock.NewMockClient(ctrl)
+	expectFees(cli)
+
+	m := newTestManager(t, cli, nil)
+
+	cli.EXPECT().PendingNonceAt(gomock.Any(), testAddr).Return(uint64(0), nil)
+	cli.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(nil).MinTimes(3)
+	cli.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).Return(nil, geth.NotFound).AnyTimes()
+
+	ptx, err := m.Publish(t.Context(), &TxCandidate{From: testAddr, To: &testTo, GasLimit: 21000})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	done := make(chan error)
+	go func() {
+		_, err := m.WaitConfirmed(ctx, ptx)
+		done <- err
+	}()
+
+	// read pending transactions while they are bumped (run with -race)
+	deadline := time.Now().Add(5 * time.Second)
+	for versions := 0; versions < 3; {
+		require.True(t, time.Now().Before(deadline), "transaction not bumped twice")
+
+		pending, err := m.Pending(t.Context())
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		for _, tx := range pending[0].Txs {
+			_ = tx.Hash()
+		}
+		versions = len(pending[0].Txs)
+		_ = ptx.Latest()
+	}
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	assert.Len(t, ptx.Txs, 1, "caller value is not modified")
+}
+
+func TestInitResumesFromStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mock.NewMockClient(ctrl)
+	expectFees(cli)
+
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	tx, err := testSignTx(t.Context(), testAddr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     7,
+		GasTipCap: big.NewInt(1000),
+		GasFeeCap: big.NewInt(21000),
+		Gas:       21000,
+		To:        &testTo,
+		Value:     big.NewInt(0),
+	}), big.NewInt(1))
+	require.NoError(t, err)
+	require.NoError(t, store.Put(t.Context(), &PendingTx{From: testAddr, Nonce: 7, Txs: []*gethtypes.Transaction{tx}}))
+
+	m := newTestManager(t, cli, store)
+	require.NoError(t, m.Init(t.Context()))
+
+	// Node is not aware of the persisted transaction yet so its pending nonce lags behind
+	cli.EXPECT().PendingNonceAt(gomock.Any(), testAddr).Return(uint64(7), nil)
+	cli.EXPECT().SendTransaction(gomock.Any(), gomock.Any()).Return(nil)
+
+	ptx, err := m.Publish(t.Context(), &TxCandidate{From: testAddr, To: &testTo, GasLimit: 21000})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), ptx.Nonce)
+
+	cli.EXPECT().TransactionReceipt(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, hash gethcommon.Hash) (*gethtypes.Receipt, error) {
+			return &gethtypes.Receipt{TxHash: hash, BlockNumber: big.NewInt(10)}, nil
+		},
+	).AnyTimes()
+	cli.EXPECT().BlockNumber(gomock.Any()).Return(uint64(20), nil).AnyTimes()
+
+	receipts, err := m.Recover(t.Context())
+	require.NoError(t, err)
+	require.Len(t, receipts, 2)
+	assert.Equal(t, tx.Hash(), receipts[0].TxHash)
+
+	pending, err := m.Pending(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestBumpFee(t *testing.T) {
+	assert.Equal(t, int64(110), bumpFee(big.NewInt(100), 10).Int64())
+	assert.Equal(t, int64(13), bumpFee(big.NewInt(11), 10).Int64(), "bumped fee must be rounded up")
+	assert.Equal(t, int64(0), bumpFee(big.NewInt(0), 10).Int64())
+}
@@ -0,0 +1,186 @@
+package txmgr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// PendingTx is an in-flight transaction tracked by the manager
+//
+// It holds every signed version of the transaction that has been sent for a given sender and nonce
+// (the original one and all its fee-bumped replacements) as any of them may end up being mined.
+type PendingTx struct {
+	From  gethcommon.Address       `json:"from"`
+	Nonce uint64                   `json:"nonce"`
+	Txs   []*gethtypes.Transaction `json:"txs"`
+}
+
+// Latest returns the last signed version of the transaction
+func (ptx *PendingTx) Latest() *gethtypes.Transaction {
+	if len(ptx.Txs) == 0 {
+		return nil
+	}
+	return ptx.Txs[len(ptx.Txs)-1]
+}
+
+// clone returns a copy of ptx that does not share its list of versions
+//
+// Transactions are immutable so they are not copied.
+func (ptx *PendingTx) clone() *PendingTx {
+	cpy := *ptx
+	cpy.Txs = append([]*gethtypes.Transaction(nil), ptx.Txs...)
+	return &cpy
+}
+
+// Store persists in-flight transactions so a restarted manager does not double-send
+type Store interface {
+	// Put inserts or replaces the pending transaction for (ptx.From, ptx.Nonce)
+	Put(ctx context.Context, ptx *PendingTx) error
+
+	// Delete removes the pending transaction for (from, nonce)
+	Delete(ctx context.Context, from gethcommon.Address, nonce uint64) error
+
+	// List returns all pending transactions ordered by sender and nonce
+	//
+	// Returned values are owned by the caller and are not modified by later calls to Put.
+	List(ctx context.Context) ([]*PendingTx, error)
+}
+
+type storeKey struct {
+	from  gethcommon.Address
+	nonce uint64
+}
+
+// MemoryStore is a non-persistent Store
+//
+// It stores copies of pending transactions so callers never share values with the store.
+type MemoryStore struct {
+	mu  sync.Mutex
+	txs map[storeKey]*PendingTx
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		txs: make(map[storeKey]*PendingTx),
+	}
+}
+
+func (s *MemoryStore) Put(_ context.Context, ptx *PendingTx) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txs[storeKey{ptx.From, ptx.Nonce}] = ptx.clone()
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, from gethcommon.Address, nonce uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.txs, storeKey{from, nonce})
+	return nil
+}
+
+func (s *MemoryStore) List(_ context.Context) ([]*PendingTx, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ptxs := make([]*PendingTx, 0, len(s.txs))
+	for _, ptx := range s.txs {
+		ptxs = append(ptxs, ptx.clone())
+	}
+	sortPendingTxs(ptxs)
+	return ptxs, nil
+}
+
+// FileStore is a Store persisting each pending transaction as a JSON file in a directory
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates a file store in dir (created if it does not exist)
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create txmgr store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) filename(from gethcommon.Address, nonce uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s-%020d.json", strings.ToLower(from.Hex()), nonce))
+}
+
+func (s *FileStore) Put(_ context.Context, ptx *PendingTx) error {
+	b, err := json.Marshal(ptx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Write to a temporary file then rename so a crash never leaves a truncated file behind
+	filename := s.filename(ptx.From, ptx.Nonce)
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filename)
+}
+
+func (s *FileStore) Delete(_ context.Context, from gethcommon.Address, nonce uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.filename(from, nonce))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) List(_ context.Context) ([]*PendingTx, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	filenames, err := filepath.Glob(filepath.Join(s.dir, "0x*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	ptxs := make([]*PendingTx, 0, len(filenames))
+	for _, filename := range filenames {
+		b, err := os.ReadFile(filename) //nolint:gosec // filename is built from the store directory
+		if err != nil {
+			return nil, err
+		}
+
+		ptx := new(PendingTx)
+		if err := json.Unmarshal(b, ptx); err != nil {
+			return nil, fmt.Errorf("invalid pending transaction file %q: %w", filename, err)
+		}
+		ptxs = append(ptxs, ptx)
+	}
+	sortPendingTxs(ptxs)
+
+	return ptxs, nil
+}
+
+func sortPendingTxs(ptxs []*PendingTx) {
+	sort.Slice(ptxs, func(i, j int) bool {
+		if c := ptxs[i].From.Cmp(ptxs[j].From); c != 0 {
+			return c < 0
+		}
+		return ptxs[i].Nonce < ptxs[j].Nonce
+	})
+}
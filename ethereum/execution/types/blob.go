@@ -0,0 +1,97 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+const (
+	fieldElementsPerBlob = 4096
+	bytesPerFieldElement = 32
+
+	// usableBytesPerFieldElement is the number of data bytes stored in a field element.
+	// The first byte of each field element is always left to zero so the element is lower than the BLS modulus.
+	usableBytesPerFieldElement = bytesPerFieldElement - 1
+
+	// MaxBytesPerBlob is the maximum number of data bytes that can be encoded in a single blob
+	MaxBytesPerBlob = fieldElementsPerBlob * usableBytesPerFieldElement
+)
+
+// EncodeBlobs encodes raw data into blobs storing 31 bytes of data in each field element.
+// The last blob is zero padded.
+func EncodeBlobs(data []byte) ([]kzg4844.Blob, error) {
+	if len(data) == 0 {
+		return nil, errors.New("can not encode empty data into blobs")
+	}
+
+	blobs := make([]kzg4844.Blob, (len(data)+MaxBytesPerBlob-1)/MaxBytesPerBlob)
+	for i := range blobs {
+		chunk := data[i*MaxBytesPerBlob:]
+		if len(chunk) > MaxBytesPerBlob {
+			chunk = chunk[:MaxBytesPerBlob]
+		}
+
+		for fe := 0; fe*usableBytesPerFieldElement < len(chunk); fe++ {
+			end := (fe + 1) * usableBytesPerFieldElement
+			if end > len(chunk) {
+				end = len(chunk)
+			}
+			copy(blobs[i][fe*bytesPerFieldElement+1:], chunk[fe*usableBytesPerFieldElement:end])
+		}
+	}
+
+	return blobs, nil
+}
+
+// DecodeBlobs decodes blobs encoded with EncodeBlobs. The result includes the zero padding of the last blob.
+func DecodeBlobs(blobs []kzg4844.Blob) []byte {
+	data := make([]byte, 0, len(blobs)*MaxBytesPerBlob)
+	for i := range blobs {
+		for fe := 0; fe < fieldElementsPerBlob; fe++ {
+			data = append(data, blobs[i][fe*bytesPerFieldElement+1:(fe+1)*bytesPerFieldElement]...)
+		}
+	}
+	return data
+}
+
+// NewBlobTxSidecar encodes raw data into blobs and computes KZG commitments and proofs locally.
+//
+// version is either gethtypes.BlobSidecarVersion0 (one proof per blob, pre-Osaka)
+// or gethtypes.BlobSidecarVersion1 (cell proofs, Osaka onward).
+func NewBlobTxSidecar(data []byte, version byte) (*gethtypes.BlobTxSidecar, error) {
+	blobs, err := EncodeBlobs(data)
+	if err != nil {
+		return nil, err
+	}
+
+	commitments := make([]kzg4844.Commitment, len(blobs))
+	var proofs []kzg4844.Proof
+	for i := range blobs {
+		commitments[i], err = kzg4844.BlobToCommitment(&blobs[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute KZG commitment for blob %d: %w", i, err)
+		}
+
+		switch version {
+		case gethtypes.BlobSidecarVersion0:
+			proof, err := kzg4844.ComputeBlobProof(&blobs[i], commitments[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute KZG proof for blob %d: %w", i, err)
+			}
+			proofs = append(proofs, proof)
+		case gethtypes.BlobSidecarVersion1:
+			cellProofs, err := kzg4844.ComputeCellProofs(&blobs[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute KZG cell proofs for blob %d: %w", i, err)
+			}
+			proofs = append(proofs, cellProofs...)
+		default:
+			return nil, fmt.Errorf("unsupported blob sidecar version %d", version)
+		}
+	}
+
+	return gethtypes.NewBlobTxSidecar(version, blobs, commitments, proofs), nil
+}
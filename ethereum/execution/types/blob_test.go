@@ -0,0 +1,130 @@
+//go:build !integration
+
+package types
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeBlobs(t *testing.T) {
+	data := bytes.Repeat([]byte{0xff}, MaxBytesPerBlob+10)
+
+	blobs, err := EncodeBlobs(data)
+	require.NoError(t, err)
+	require.Len(t, blobs, 2)
+
+	// first byte of each field element must be left to zero
+	for i := range blobs {
+		for fe := 0; fe < fieldElementsPerBlob; fe++ {
+			require.Equal(t, byte(0), blobs[i][fe*bytesPerFieldElement])
+		}
+	}
+
+	decoded := DecodeBlobs(blobs)
+	assert.Len(t, decoded, 2*MaxBytesPerBlob)
+	assert.Equal(t, data, decoded[:len(data)])
+	assert.Equal(t, make([]byte, MaxBytesPerBlob-10), decoded[len(data):])
+
+	_, err = EncodeBlobs(nil)
+	require.Error(t, err)
+}
+
+func TestNewBlobTxSidecar(t *testing.T) {
+	for _, version := range []byte{gethtypes.BlobSidecarVersion0, gethtypes.BlobSidecarVersion1} {
+		sidecar, err := NewBlobTxSidecar([]byte("hello blobs"), version)
+		require.NoError(t, err)
+		assert.Equal(t, version, sidecar.Version)
+		assert.Len(t, sidecar.Blobs, 1)
+		require.Len(t, sidecar.BlobHashes(), 1)
+		// versioned hash of KZG commitments is prefixed with 0x01
+		assert.Equal(t, byte(0x01), sidecar.BlobHashes()[0][0])
+		require.NoError(t, sidecar.ValidateBlobCommitmentHashes(sidecar.BlobHashes()))
+	}
+
+	_, err := NewBlobTxSidecar([]byte("hello blobs"), 2)
+	require.Error(t, err)
+}
+
+type testBackend struct {
+	sent *gethtypes.Transaction
+}
+
+func (b *testBackend) PendingNonceAt(context.Context, gethcommon.Address) (uint64, error) {
+	return 7, nil
+}
+
+func (b *testBackend) HeaderByNumber(context.Context, *big.Int) (*gethtypes.Header, error) {
+	return &gethtypes.Header{BaseFee: big.NewInt(100)}, nil
+}
+
+func (b *testBackend) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return big.NewInt(10), nil
+}
+
+func (b *testBackend) BlobBaseFee(context.Context) (*big.Int, error) {
+	return big.NewInt(5), nil
+}
+
+func (b *testBackend) EstimateGas(context.Context, geth.CallMsg) (uint64, error) {
+	return 21000, nil
+}
+
+func (b *testBackend) SendTransaction(_ context.Context, tx *gethtypes.Transaction) error {
+	b.sent = tx
+	return nil
+}
+
+func TestTransactBlobTx(t *testing.T) {
+	key, err := gethcrypto.GenerateKey()
+	require.NoError(t, err)
+	from := gethcrypto.PubkeyToAddress(key.PublicKey)
+
+	sidecar, err := NewBlobTxSidecar([]byte("hello blobs"), gethtypes.BlobSidecarVersion0)
+	require.NoError(t, err)
+
+	signTx := func(_ context.Context, _ gethcommon.Address, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
+		return gethtypes.SignTx(tx, gethtypes.LatestSignerForChainID(chainID), key)
+	}
+
+	backend := new(testBackend)
+	to := gethcommon.HexToAddress("0xff00000000000000000000000000000000000000")
+	opts := &TransactOpts{From: from, Blobs: sidecar, Send: true}
+
+	tx, err := opts.Transact(t.Context(), backend, big.NewInt(1), &to, nil, signTx)
+	require.NoError(t, err)
+	assert.Equal(t, backend.sent, tx)
+
+	assert.Equal(t, uint8(gethtypes.BlobTxType), tx.Type())
+	assert.Equal(t, uint64(7), tx.Nonce())
+	assert.Equal(t, uint64(21000), tx.Gas())
+	assert.Equal(t, int64(10), tx.GasTipCap().Int64())
+	assert.Equal(t, int64(210), tx.GasFeeCap().Int64())
+	assert.Equal(t, int64(10), tx.BlobGasFeeCap().Int64())
+	assert.Equal(t, sidecar.BlobHashes(), tx.BlobHashes())
+	assert.Equal(t, sidecar, tx.BlobTxSidecar())
+
+	// network encoding embeds the sidecar
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	decoded := new(gethtypes.Transaction)
+	require.NoError(t, decoded.UnmarshalBinary(raw))
+	require.NotNil(t, decoded.BlobTxSidecar())
+	assert.Equal(t, sidecar.Commitments, decoded.BlobTxSidecar().Commitments)
+
+	sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(big.NewInt(1)), tx)
+	require.NoError(t, err)
+	assert.Equal(t, from, sender)
+
+	_, err = opts.Transact(t.Context(), backend, big.NewInt(1), nil, nil, signTx)
+	require.Error(t, err)
+}
@@ -23,3 +23,9 @@ func (opts *CallOpts) ToOpts(ctx context.Context) *gethbind.CallOpts {
 		BlockNumber: opts.BlockNumber,
 	}
 }
+
+// CallResult is the result of a contract call executed as part of a batch
+type CallResult struct {
+	ReturnData []byte
+	Err        error // Error of the call, if any (e.g. execution reverted)
+}
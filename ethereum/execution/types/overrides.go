@@ -0,0 +1,93 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethhexutil "github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// StateOverride is the set of accounts to override before executing a call
+type StateOverride map[gethcommon.Address]*OverrideAccount
+
+// OverrideAccount holds the fields of an account to override before executing a call.
+// Unset fields are left unchanged.
+//
+// State replaces the whole account storage while StateDiff only replaces the given slots,
+// so at most one of them can be set.
+type OverrideAccount struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[gethcommon.Hash]gethcommon.Hash
+	StateDiff map[gethcommon.Hash]gethcommon.Hash
+}
+
+type overrideAccountMarshaling struct {
+	Nonce     *gethhexutil.Uint64                  `json:"nonce,omitempty"`
+	Code      *gethhexutil.Bytes                   `json:"code,omitempty"`
+	Balance   *gethhexutil.Big                     `json:"balance,omitempty"`
+	State     *map[gethcommon.Hash]gethcommon.Hash `json:"state,omitempty"`
+	StateDiff *map[gethcommon.Hash]gethcommon.Hash `json:"stateDiff,omitempty"`
+}
+
+// MarshalJSON encodes the override following geth eth_call override specification
+func (a *OverrideAccount) MarshalJSON() ([]byte, error) {
+	if a.State != nil && a.StateDiff != nil {
+		return nil, errors.New("state and stateDiff can not be both overridden")
+	}
+
+	res := &overrideAccountMarshaling{
+		Nonce:   (*gethhexutil.Uint64)(a.Nonce),
+		Balance: (*gethhexutil.Big)(a.Balance),
+	}
+	if a.Code != nil {
+		res.Code = (*gethhexutil.Bytes)(&a.Code)
+	}
+	// an empty State clears the whole account storage so it is encoded as long as it is set
+	if a.State != nil {
+		res.State = &a.State
+	}
+	if a.StateDiff != nil {
+		res.StateDiff = &a.StateDiff
+	}
+
+	return json.Marshal(res)
+}
+
+// BlockOverrides holds the fields of the block context to override before executing a call.
+// Unset fields are left unchanged.
+type BlockOverrides struct {
+	Number       *big.Int
+	Time         *uint64
+	GasLimit     *uint64
+	FeeRecipient *gethcommon.Address
+	PrevRandao   *gethcommon.Hash
+	BaseFee      *big.Int
+	BlobBaseFee  *big.Int
+}
+
+type blockOverridesMarshaling struct {
+	Number       *gethhexutil.Big    `json:"number,omitempty"`
+	Time         *gethhexutil.Uint64 `json:"time,omitempty"`
+	GasLimit     *gethhexutil.Uint64 `json:"gasLimit,omitempty"`
+	FeeRecipient *gethcommon.Address `json:"feeRecipient,omitempty"`
+	PrevRandao   *gethcommon.Hash    `json:"prevRandao,omitempty"`
+	BaseFee      *gethhexutil.Big    `json:"baseFeePerGas,omitempty"`
+	BlobBaseFee  *gethhexutil.Big    `json:"blobBaseFee,omitempty"`
+}
+
+// MarshalJSON encodes the override following geth eth_call override specification
+func (o *BlockOverrides) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&blockOverridesMarshaling{
+		Number:       (*gethhexutil.Big)(o.Number),
+		Time:         (*gethhexutil.Uint64)(o.Time),
+		GasLimit:     (*gethhexutil.Uint64)(o.GasLimit),
+		FeeRecipient: o.FeeRecipient,
+		PrevRandao:   o.PrevRandao,
+		BaseFee:      (*gethhexutil.Big)(o.BaseFee),
+		BlobBaseFee:  (*gethhexutil.Big)(o.BlobBaseFee),
+	})
+}
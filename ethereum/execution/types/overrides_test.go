@@ -0,0 +1,35 @@
+//go:build !integration
+
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrideAccountMarshalJSON(t *testing.T) {
+	b, err := json.Marshal(&OverrideAccount{Code: []byte{}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"code":"0x"}`, string(b))
+
+	// empty state clears the account storage
+	b, err = json.Marshal(&OverrideAccount{State: map[gethcommon.Hash]gethcommon.Hash{}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"state":{}}`, string(b))
+
+	b, err = json.Marshal(&OverrideAccount{StateDiff: map[gethcommon.Hash]gethcommon.Hash{{0x1}: {0x2}}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"stateDiff":{"0x0100000000000000000000000000000000000000000000000000000000000000":"0x0200000000000000000000000000000000000000000000000000000000000000"}}`, string(b))
+
+	_, err = json.Marshal(StateOverride{
+		gethcommon.Address{}: {
+			State:     map[gethcommon.Hash]gethcommon.Hash{},
+			StateDiff: map[gethcommon.Hash]gethcommon.Hash{},
+		},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+)
+
+// AccountResult is the result of an eth_getProof call
+type AccountResult struct {
+	Address      gethcommon.Address
+	AccountProof [][]byte
+	Balance      *big.Int
+	CodeHash     gethcommon.Hash
+	Nonce        uint64
+	StorageHash  gethcommon.Hash
+	StorageProof []*StorageResult
+}
+
+// StorageResult is the proof of a storage slot returned by eth_getProof
+type StorageResult struct {
+	Key   gethcommon.Hash
+	Value *big.Int
+	Proof [][]byte
+}
+
+// Verify checks the account proof against stateRoot and every storage proof against the account storage root.
+//
+// Proofs of absence are accepted if the returned account (or slot) is empty.
+func (res *AccountResult) Verify(stateRoot gethcommon.Hash) error {
+	value, err := trie.VerifyProof(stateRoot, gethcrypto.Keccak256(res.Address.Bytes()), proofDB(res.AccountProof))
+	if err != nil {
+		return fmt.Errorf("invalid account proof for %v: %w", res.Address, err)
+	}
+
+	balance, overflow := uint256.FromBig(res.Balance)
+	if res.Balance == nil || overflow {
+		return fmt.Errorf("invalid balance %v for %v", res.Balance, res.Address)
+	}
+
+	if value == nil {
+		if res.Nonce != 0 || !balance.IsZero() || res.StorageHash != gethtypes.EmptyRootHash || res.CodeHash != gethtypes.EmptyCodeHash {
+			return fmt.Errorf("account %v is not empty but proof is a proof of absence", res.Address)
+		}
+	} else {
+		expected, err := rlp.EncodeToBytes(&gethtypes.StateAccount{
+			Nonce:    res.Nonce,
+			Balance:  balance,
+			Root:     res.StorageHash,
+			CodeHash: res.CodeHash.Bytes(),
+		})
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(value, expected) {
+			return fmt.Errorf("account %v does not match account proof", res.Address)
+		}
+	}
+
+	for _, storage := range res.StorageProof {
+		if err := storage.Verify(res.StorageHash); err != nil {
+			return fmt.Errorf("invalid storage proof for %v: %w", res.Address, err)
+		}
+	}
+
+	return nil
+}
+
+// Verify checks the storage proof against the account storage root
+func (res *StorageResult) Verify(storageRoot gethcommon.Hash) error {
+	value, err := trie.VerifyProof(storageRoot, gethcrypto.Keccak256(res.Key.Bytes()), proofDB(res.Proof))
+	if err != nil {
+		return fmt.Errorf("slot %v: %w", res.Key, err)
+	}
+
+	if res.Value == nil || res.Value.Sign() < 0 {
+		return fmt.Errorf("slot %v: invalid value %v", res.Key, res.Value)
+	}
+
+	if value == nil {
+		if res.Value.Sign() != 0 {
+			return fmt.Errorf("slot %v is not empty but proof is a proof of absence", res.Key)
+		}
+		return nil
+	}
+
+	// storage values are stored as RLP encoded trimmed big endian integers
+	var content []byte
+	if err := rlp.DecodeBytes(value, &content); err != nil {
+		return fmt.Errorf("slot %v: invalid proven value: %w", res.Key, err)
+	}
+
+	if new(big.Int).SetBytes(content).Cmp(res.Value) != 0 {
+		return fmt.Errorf("slot %v value does not match storage proof", res.Key)
+	}
+
+	return nil
+}
+
+func proofDB(proof [][]byte) *memorydb.Database {
+	db := memorydb.New()
+	for _, node := range proof {
+		_ = db.Put(gethcrypto.Keccak256(node), node)
+	}
+	return db
+}
@@ -0,0 +1,109 @@
+//go:build !integration
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type proofList [][]byte
+
+func (l *proofList) Put(_, value []byte) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *proofList) Delete([]byte) error {
+	panic("not supported")
+}
+
+func prove(t *testing.T, tr *trie.Trie, key []byte) [][]byte {
+	t.Helper()
+	var proof proofList
+	require.NoError(t, tr.Prove(gethcrypto.Keccak256(key), &proof))
+	return proof
+}
+
+func testAccountResult(t *testing.T) (*trie.Trie, *AccountResult) {
+	t.Helper()
+
+	slot := gethcommon.BigToHash(big.NewInt(1))
+	emptySlot := gethcommon.BigToHash(big.NewInt(2))
+	storage := trie.NewEmpty(nil)
+	value, err := rlp.EncodeToBytes(big.NewInt(42).Bytes())
+	require.NoError(t, err)
+	require.NoError(t, storage.Update(gethcrypto.Keccak256(slot.Bytes()), value))
+	require.NoError(t, storage.Update(gethcrypto.Keccak256(gethcommon.BigToHash(big.NewInt(3)).Bytes()), value))
+
+	addr := gethcommon.HexToAddress("0x027f72bc0ca063e40577d30d336d52fd7bcc7375")
+	codeHash := gethcrypto.Keccak256Hash([]byte{0x60, 0x00})
+	account, err := rlp.EncodeToBytes(&gethtypes.StateAccount{
+		Nonce:    5,
+		Balance:  uint256.NewInt(1000),
+		Root:     storage.Hash(),
+		CodeHash: codeHash.Bytes(),
+	})
+	require.NoError(t, err)
+
+	state := trie.NewEmpty(nil)
+	require.NoError(t, state.Update(gethcrypto.Keccak256(addr.Bytes()), account))
+	require.NoError(t, state.Update(gethcrypto.Keccak256([]byte("other")), account))
+
+	return state, &AccountResult{
+		Address:      addr,
+		AccountProof: prove(t, state, addr.Bytes()),
+		Balance:      big.NewInt(1000),
+		CodeHash:     codeHash,
+		Nonce:        5,
+		StorageHash:  storage.Hash(),
+		StorageProof: []*StorageResult{
+			{Key: slot, Value: big.NewInt(42), Proof: prove(t, storage, slot.Bytes())},
+			{Key: emptySlot, Value: big.NewInt(0), Proof: prove(t, storage, emptySlot.Bytes())},
+		},
+	}
+}
+
+func TestVerifyAccountResult(t *testing.T) {
+	state, res := testAccountResult(t)
+	require.NoError(t, res.Verify(state.Hash()))
+
+	require.Error(t, res.Verify(gethcommon.Hash{0x01}))
+
+	res.Balance = big.NewInt(1001)
+	require.Error(t, res.Verify(state.Hash()))
+
+	state, res = testAccountResult(t)
+	res.StorageProof[0].Value = big.NewInt(43)
+	require.Error(t, res.Verify(state.Hash()))
+
+	state, res = testAccountResult(t)
+	res.StorageProof[1].Value = big.NewInt(1)
+	require.Error(t, res.Verify(state.Hash()))
+}
+
+func TestVerifyAbsentAccount(t *testing.T) {
+	state, _ := testAccountResult(t)
+
+	absent := gethcommon.HexToAddress("0xff00000000000000000000000000000000000000")
+	res := &AccountResult{
+		Address:      absent,
+		AccountProof: prove(t, state, absent.Bytes()),
+		Balance:      big.NewInt(0),
+		CodeHash:     gethtypes.EmptyCodeHash,
+		StorageHash:  gethtypes.EmptyRootHash,
+	}
+	assert.NoError(t, res.Verify(state.Hash()))
+
+	res.Nonce = 1
+	require.Error(t, res.Verify(state.Hash()))
+}
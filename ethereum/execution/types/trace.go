@@ -0,0 +1,123 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethhexutil "github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Built-in tracers with typed results
+const (
+	CallTracer     = "callTracer"
+	PrestateTracer = "prestateTracer"
+)
+
+// TraceConfig holds options of debug_trace* calls
+//
+// Tracer can be the name of a built-in tracer or the source of a custom JS tracer.
+// If Tracer is empty the node uses the struct logger.
+type TraceConfig struct {
+	Tracer       string          `json:"tracer,omitempty"`
+	TracerConfig json.RawMessage `json:"tracerConfig,omitempty"`
+	Timeout      string          `json:"timeout,omitempty"`
+	Reexec       *uint64         `json:"reexec,omitempty"`
+}
+
+// TxTraceResult is the trace of a single transaction returned by debug_traceBlockByNumber
+type TxTraceResult struct {
+	TxHash gethcommon.Hash `json:"txHash"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// CallFrame is a call as returned by the callTracer
+type CallFrame struct {
+	Type         string              `json:"type"`
+	From         gethcommon.Address  `json:"from"`
+	To           *gethcommon.Address `json:"to,omitempty"`
+	Value        *gethhexutil.Big    `json:"value,omitempty"`
+	Gas          gethhexutil.Uint64  `json:"gas"`
+	GasUsed      gethhexutil.Uint64  `json:"gasUsed"`
+	Input        gethhexutil.Bytes   `json:"input"`
+	Output       gethhexutil.Bytes   `json:"output,omitempty"`
+	Error        string              `json:"error,omitempty"`
+	RevertReason string              `json:"revertReason,omitempty"`
+	Calls        []*CallFrame        `json:"calls,omitempty"`
+	Logs         []*CallLog          `json:"logs,omitempty"`
+}
+
+// CallLog is a log emitted by a call, returned by the callTracer if withLog is set
+type CallLog struct {
+	Address gethcommon.Address `json:"address"`
+	Topics  []gethcommon.Hash  `json:"topics"`
+	Data    gethhexutil.Bytes  `json:"data"`
+}
+
+// PrestateAccount is the state of an account as returned by the prestateTracer
+type PrestateAccount struct {
+	Balance *gethhexutil.Big                    `json:"balance,omitempty"`
+	Nonce   uint64                              `json:"nonce,omitempty"`
+	Code    gethhexutil.Bytes                   `json:"code,omitempty"`
+	Storage map[gethcommon.Hash]gethcommon.Hash `json:"storage,omitempty"`
+}
+
+// PrestateTrace is the result of the prestateTracer
+//
+// Post is only set in diff mode, in which case Pre and Post only hold modified accounts and fields.
+type PrestateTrace struct {
+	Pre  map[gethcommon.Address]*PrestateAccount `json:"pre"`
+	Post map[gethcommon.Address]*PrestateAccount `json:"post,omitempty"`
+}
+
+// Transfer is an ETH transfer made by a call
+type Transfer struct {
+	Type  string
+	From  gethcommon.Address
+	To    gethcommon.Address
+	Value *big.Int
+	Depth int
+}
+
+// InternalTransfers flattens a call tree into the ETH transfers made by internal calls
+// (the transfer made by the transaction itself is not included).
+//
+// Calls that failed are skipped, together with their sub-calls, as their state changes are reverted.
+// Only calls actually moving funds are returned (CALL, CREATE, CREATE2 and SELFDESTRUCT with a non-zero value).
+func InternalTransfers(root *CallFrame) []*Transfer {
+	var transfers []*Transfer
+	if root == nil || root.Error != "" {
+		return transfers
+	}
+
+	var walk func(frame *CallFrame, depth int)
+	walk = func(frame *CallFrame, depth int) {
+		if frame.Error != "" {
+			return
+		}
+
+		switch frame.Type {
+		case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+			if frame.Value != nil && frame.Value.ToInt().Sign() > 0 && frame.To != nil {
+				transfers = append(transfers, &Transfer{
+					Type:  frame.Type,
+					From:  frame.From,
+					To:    *frame.To,
+					Value: new(big.Int).Set(frame.Value.ToInt()),
+					Depth: depth,
+				})
+			}
+		}
+
+		for _, call := range frame.Calls {
+			walk(call, depth+1)
+		}
+	}
+
+	for _, call := range root.Calls {
+		walk(call, 1)
+	}
+
+	return transfers
+}
@@ -0,0 +1,113 @@
+//go:build !integration
+
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCallTrace = `{
+	"type": "CALL",
+	"from": "0x0000000000000000000000000000000000000001",
+	"to": "0x0000000000000000000000000000000000000002",
+	"value": "0x1",
+	"gas": "0x5208",
+	"gasUsed": "0x5208",
+	"input": "0x",
+	"calls": [
+		{
+			"type": "CALL",
+			"from": "0x0000000000000000000000000000000000000002",
+			"to": "0x0000000000000000000000000000000000000003",
+			"value": "0x10",
+			"gas": "0x0",
+			"gasUsed": "0x0",
+			"input": "0x",
+			"calls": [
+				{
+					"type": "SELFDESTRUCT",
+					"from": "0x0000000000000000000000000000000000000003",
+					"to": "0x0000000000000000000000000000000000000004",
+					"value": "0x20",
+					"gas": "0x0",
+					"gasUsed": "0x0",
+					"input": "0x"
+				}
+			]
+		},
+		{
+			"type": "DELEGATECALL",
+			"from": "0x0000000000000000000000000000000000000002",
+			"to": "0x0000000000000000000000000000000000000005",
+			"value": "0x10",
+			"gas": "0x0",
+			"gasUsed": "0x0",
+			"input": "0x"
+		},
+		{
+			"type": "CALL",
+			"from": "0x0000000000000000000000000000000000000002",
+			"to": "0x0000000000000000000000000000000000000006",
+			"value": "0x0",
+			"gas": "0x0",
+			"gasUsed": "0x0",
+			"input": "0x"
+		},
+		{
+			"type": "CALL",
+			"from": "0x0000000000000000000000000000000000000002",
+			"to": "0x0000000000000000000000000000000000000007",
+			"value": "0x30",
+			"gas": "0x0",
+			"gasUsed": "0x0",
+			"input": "0x",
+			"error": "execution reverted",
+			"calls": [
+				{
+					"type": "CALL",
+					"from": "0x0000000000000000000000000000000000000007",
+					"to": "0x0000000000000000000000000000000000000008",
+					"value": "0x40",
+					"gas": "0x0",
+					"gasUsed": "0x0",
+					"input": "0x"
+				}
+			]
+		}
+	]
+}`
+
+func TestInternalTransfers(t *testing.T) {
+	root := new(CallFrame)
+	require.NoError(t, json.Unmarshal([]byte(testCallTrace), root))
+
+	assert.Equal(
+		t,
+		[]*Transfer{
+			{
+				Type:  "CALL",
+				From:  gethcommon.HexToAddress("0x0000000000000000000000000000000000000002"),
+				To:    gethcommon.HexToAddress("0x0000000000000000000000000000000000000003"),
+				Value: big.NewInt(16),
+				Depth: 1,
+			},
+			{
+				Type:  "SELFDESTRUCT",
+				From:  gethcommon.HexToAddress("0x0000000000000000000000000000000000000003"),
+				To:    gethcommon.HexToAddress("0x0000000000000000000000000000000000000004"),
+				Value: big.NewInt(32),
+				Depth: 2,
+			},
+		},
+		InternalTransfers(root),
+	)
+
+	root.Error = "out of gas"
+	assert.Empty(t, InternalTransfers(root))
+}
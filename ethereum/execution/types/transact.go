@@ -0,0 +1,404 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// TransactBackend is the set of node methods required by Transact
+type TransactBackend interface {
+	PendingNonceAt(ctx context.Context, account gethcommon.Address) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*gethtypes.Header, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	BlobBaseFee(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call geth.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *gethtypes.Transaction) error
+}
+
+// Transact builds a transaction calling to with data, signs it (unless NoSign) and sends it (if Send).
+//
+// It covers transaction types that can not be built through ToOpts:
+// an EIP-4844 blob transaction is built if Blobs is set, an EIP-7702 set-code transaction is built
+// if AuthorizationList is set, otherwise an EIP-1559 transaction is built (or a legacy transaction if GasPrice is set).
+func (opts *TransactOpts) Transact(
+	ctx context.Context,
+	backend TransactBackend,
+	chainID *big.Int,
+	to *gethcommon.Address,
+	data []byte,
+	signTx SignTxFunc,
+) (*gethtypes.Transaction, error) {
+	nonce, err := opts.nonce(ctx, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	value := opts.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	var txData gethtypes.TxData
+	switch {
+	case opts.Blobs != nil && len(opts.AuthorizationList) > 0:
+		err = errors.New("can not attach both blobs and an authorization list to a transaction")
+	case opts.Blobs != nil:
+		txData, err = opts.blobTx(ctx, backend, chainID, nonce, to, value, data)
+	case len(opts.AuthorizationList) > 0:
+		txData, err = opts.setCodeTx(ctx, backend, chainID, nonce, to, value, data)
+	case opts.GasPrice != nil:
+		txData, err = opts.legacyTx(ctx, backend, nonce, to, value, data)
+	default:
+		txData, err = opts.dynamicFeeTx(ctx, backend, chainID, nonce, to, value, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx := gethtypes.NewTx(txData)
+	if opts.NoSign {
+		return tx, nil
+	}
+
+	signedTx, err := signTx(ctx, opts.From, tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Send {
+		if err := backend.SendTransaction(ctx, signedTx); err != nil {
+			return nil, err
+		}
+	}
+
+	return signedTx, nil
+}
+
+func (opts *TransactOpts) nonce(ctx context.Context, backend TransactBackend) (uint64, error) {
+	if opts.Nonce != nil {
+		return opts.Nonce.Uint64(), nil
+	}
+
+	nonce, err := backend.PendingNonceAt(ctx, opts.From)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve account nonce: %w", err)
+	}
+
+	return nonce, nil
+}
+
+// fees returns the EIP-1559 fee caps, using FeeEstimator or the node's oracle for the ones that are not set
+func (opts *TransactOpts) fees(ctx context.Context, backend TransactBackend) (gasTipCap, gasFeeCap *big.Int, err error) {
+	if opts.GasTipCap != nil && opts.GasFeeCap != nil {
+		return opts.GasTipCap, opts.GasFeeCap, nil
+	}
+
+	if opts.FeeEstimator != nil {
+		gasTipCap, gasFeeCap, err = opts.FeeEstimator.EstimateFees(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to estimate fees: %w", err)
+		}
+	} else {
+		gasTipCap, err = backend.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+		}
+
+		head, err := backend.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if head.BaseFee == nil {
+			return nil, nil, errors.New("head header has no base fee (EIP-1559 not supported)")
+		}
+
+		gasFeeCap = new(big.Int).Add(gasTipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	}
+
+	gasTipCap, gasFeeCap = opts.mergeFees(gasTipCap, gasFeeCap)
+
+	return gasTipCap, gasFeeCap, nil
+}
+
+func (opts *TransactOpts) gasLimit(ctx context.Context, backend TransactBackend, msg *geth.CallMsg) (uint64, error) {
+	if opts.GasLimit != 0 {
+		return opts.GasLimit, nil
+	}
+
+	gasLimit, err := backend.EstimateGas(ctx, *msg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate gas needed: %w", err)
+	}
+
+	return gasLimit, nil
+}
+
+func (opts *TransactOpts) legacyTx(
+	ctx context.Context,
+	backend TransactBackend,
+	nonce uint64,
+	to *gethcommon.Address,
+	value *big.Int,
+	data []byte,
+) (*gethtypes.LegacyTx, error) {
+	gasLimit, err := opts.gasLimit(ctx, backend, &geth.CallMsg{
+		From:     opts.From,
+		To:       to,
+		GasPrice: opts.GasPrice,
+		Value:    value,
+		Data:     data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &gethtypes.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: opts.GasPrice,
+		Gas:      gasLimit,
+		To:       to,
+		Value:    value,
+		Data:     data,
+	}, nil
+}
+
+func (opts *TransactOpts) dynamicFeeTx(
+	ctx context.Context,
+	backend TransactBackend,
+	chainID *big.Int,
+	nonce uint64,
+	to *gethcommon.Address,
+	value *big.Int,
+	data []byte,
+) (*gethtypes.DynamicFeeTx, error) {
+	gasTipCap, gasFeeCap, err := opts.fees(ctx, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	gasLimit, err := opts.gasLimit(ctx, backend, &geth.CallMsg{
+		From:      opts.From,
+		To:        to,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Value:     value,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &gethtypes.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       gasLimit,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	}, nil
+}
+
+func (opts *TransactOpts) blobTx(
+	ctx context.Context,
+	backend TransactBackend,
+	chainID *big.Int,
+	nonce uint64,
+	to *gethcommon.Address,
+	value *big.Int,
+	data []byte,
+) (*gethtypes.BlobTx, error) {
+	if to == nil {
+		return nil, errors.New("blob transactions can not create contracts")
+	}
+
+	if chainID == nil {
+		return nil, errors.New("blob transactions require a chain ID")
+	}
+
+	gasTipCap, gasFeeCap, err := opts.fees(ctx, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	blobFeeCap := opts.BlobFeeCap
+	if blobFeeCap == nil {
+		blobBaseFee, err := backend.BlobBaseFee(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve blob base fee: %w", err)
+		}
+		blobFeeCap = new(big.Int).Mul(blobBaseFee, big.NewInt(DefaultBlobFeeCapMultiplier))
+	}
+
+	blobHashes := opts.Blobs.BlobHashes()
+	gasLimit, err := opts.gasLimit(ctx, backend, &geth.CallMsg{
+		From:          opts.From,
+		To:            to,
+		GasTipCap:     gasTipCap,
+		GasFeeCap:     gasFeeCap,
+		Value:         value,
+		Data:          data,
+		BlobGasFeeCap: blobFeeCap,
+		BlobHashes:    blobHashes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := toUint256(chainID, gasTipCap, gasFeeCap, value, blobFeeCap)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gethtypes.BlobTx{
+		ChainID:    fields[0],
+		Nonce:      nonce,
+		GasTipCap:  fields[1],
+		GasFeeCap:  fields[2],
+		Gas:        gasLimit,
+		To:         *to,
+		Value:      fields[3],
+		Data:       data,
+		BlobFeeCap: fields[4],
+		BlobHashes: blobHashes,
+		Sidecar:    opts.Blobs,
+	}, nil
+}
+
+func (opts *TransactOpts) setCodeTx(
+	ctx context.Context,
+	backend TransactBackend,
+	chainID *big.Int,
+	nonce uint64,
+	to *gethcommon.Address,
+	value *big.Int,
+	data []byte,
+) (*gethtypes.SetCodeTx, error) {
+	if to == nil {
+		return nil, errors.New("set-code transactions can not create contracts")
+	}
+
+	if chainID == nil {
+		return nil, errors.New("set-code transactions require a chain ID")
+	}
+
+	gasTipCap, gasFeeCap, err := opts.fees(ctx, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	gasLimit, err := opts.gasLimit(ctx, backend, &geth.CallMsg{
+		From:              opts.From,
+		To:                to,
+		GasTipCap:         gasTipCap,
+		GasFeeCap:         gasFeeCap,
+		Value:             value,
+		Data:              data,
+		AuthorizationList: opts.AuthorizationList,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := toUint256(chainID, gasTipCap, gasFeeCap, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gethtypes.SetCodeTx{
+		ChainID:   fields[0],
+		Nonce:     nonce,
+		GasTipCap: fields[1],
+		GasFeeCap: fields[2],
+		Gas:       gasLimit,
+		To:        *to,
+		Value:     fields[3],
+		Data:      data,
+		AuthList:  opts.AuthorizationList,
+	}, nil
+}
+
+// Authorize signs an EIP-7702 authorization delegating the code of authority to delegate
+// and appends it to AuthorizationList.
+//
+// The authorization nonce is the pending nonce of authority. If authority also sends the transaction
+// then the nonce is incremented, as the sender nonce is consumed before authorizations are processed.
+func (opts *TransactOpts) Authorize(
+	ctx context.Context,
+	backend TransactBackend,
+	chainID *big.Int,
+	authority, delegate gethcommon.Address,
+	signAuth SignAuthorizationFunc,
+) error {
+	var nonce uint64
+	if authority == opts.From {
+		txNonce, err := opts.nonce(ctx, backend)
+		if err != nil {
+			return err
+		}
+		nonce = txNonce + 1
+	} else {
+		var err error
+		nonce, err = backend.PendingNonceAt(ctx, authority)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve authority nonce: %w", err)
+		}
+	}
+
+	auth, err := NewSetCodeAuthorization(chainID, delegate, nonce)
+	if err != nil {
+		return err
+	}
+
+	signedAuth, err := signAuth(ctx, authority, auth)
+	if err != nil {
+		return fmt.Errorf("failed to sign authorization: %w", err)
+	}
+
+	opts.AuthorizationList = append(opts.AuthorizationList, signedAuth)
+
+	return nil
+}
+
+// NewSetCodeAuthorization returns an unsigned EIP-7702 authorization delegating to delegate.
+// A nil or zero chainID makes the authorization valid on every chain.
+func NewSetCodeAuthorization(chainID *big.Int, delegate gethcommon.Address, nonce uint64) (gethtypes.SetCodeAuthorization, error) {
+	auth := gethtypes.SetCodeAuthorization{
+		Address: delegate,
+		Nonce:   nonce,
+	}
+
+	if chainID != nil {
+		fields, err := toUint256(chainID)
+		if err != nil {
+			return gethtypes.SetCodeAuthorization{}, err
+		}
+		auth.ChainID = *fields[0]
+	}
+
+	return auth, nil
+}
+
+func toUint256(values ...*big.Int) ([]*uint256.Int, error) {
+	res := make([]*uint256.Int, len(values))
+	for i, b := range values {
+		if b == nil {
+			return nil, fmt.Errorf("missing transaction field value at pos %v", i)
+		}
+
+		var overflow bool
+		res[i], overflow = uint256.FromBig(b)
+		if overflow || b.Sign() < 0 {
+			return nil, fmt.Errorf("invalid transaction field value %v", b)
+		}
+	}
+	return res, nil
+}
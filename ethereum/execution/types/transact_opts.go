@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 
 	gethbind "github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -9,8 +10,21 @@ import (
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 )
 
+// FeeEstimator computes EIP-1559 fees for a transaction to be included in a timely manner
+type FeeEstimator interface {
+	EstimateFees(ctx context.Context) (gasTipCap, gasFeeCap *big.Int, err error)
+}
+
 type SignTxFunc func(ctx context.Context, addr gethcommon.Address, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error)
 
+type SignAuthorizationFunc func(ctx context.Context, addr gethcommon.Address, auth gethtypes.SetCodeAuthorization) (gethtypes.SetCodeAuthorization, error)
+
+// DefaultBlobFeeCapMultiplier is the multiplier of the blob base fee used as blob fee cap when TransactOpts.BlobFeeCap is not set
+//
+// The blob base fee increases by at most 1/8 per block so twice the current blob base fee stays above it
+// for at least 5 consecutive full blocks (c.f. gas.BlobFeeCap to project it over a chosen number of blocks).
+const DefaultBlobFeeCapMultiplier = 2
+
 // TransactOpts is a set of option to fine tune the creation of a valid Ethereum transaction.
 type TransactOpts struct {
 	From gethcommon.Address // Ethereum account to send the transaction from
@@ -22,6 +36,13 @@ type TransactOpts struct {
 	GasTipCap *big.Int // Gas priority fee cap to use for the 1559 transaction execution (nil = gas price oracle)
 	GasLimit  uint64   // Gas limit to set for the transaction execution (0 = estimate)
 
+	BlobFeeCap *big.Int                 // Blob gas fee cap to use for the 4844 transaction execution (nil = DefaultBlobFeeCapMultiplier times the blob base fee)
+	Blobs      *gethtypes.BlobTxSidecar // Optional blobs to attach, if set an EIP-4844 transaction is built by Transact
+
+	AuthorizationList []gethtypes.SetCodeAuthorization // Optional signed authorizations, if set an EIP-7702 transaction is built by Transact
+
+	FeeEstimator FeeEstimator // Optional estimator used to set unset EIP-1559 fee caps (nil = gas price oracle)
+
 	NoSign bool // Do all transact steps and stops before signing
 	Send   bool // Do all transact steps and send the transaction (can not be true if NoSign is true)
 }
@@ -46,3 +67,43 @@ func (opts *TransactOpts) ToOpts(ctx context.Context, chainID *big.Int, signTx S
 		NoSend:    !opts.Send || opts.NoSign,
 	}
 }
+
+// ToOptsWithFees is the same as ToOpts except that unset EIP-1559 fee caps are computed using FeeEstimator.
+//
+// Fees are not estimated if no FeeEstimator is set, if a legacy GasPrice is set or if both fee caps are set.
+func (opts *TransactOpts) ToOptsWithFees(ctx context.Context, chainID *big.Int, signTx SignTxFunc) (*gethbind.TransactOpts, error) {
+	bindOpts := opts.ToOpts(ctx, chainID, signTx)
+	if opts.FeeEstimator == nil || opts.GasPrice != nil || (opts.GasFeeCap != nil && opts.GasTipCap != nil) {
+		return bindOpts, nil
+	}
+
+	gasTipCap, gasFeeCap, err := opts.FeeEstimator.EstimateFees(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate fees: %w", err)
+	}
+
+	bindOpts.GasTipCap, bindOpts.GasFeeCap = opts.mergeFees(gasTipCap, gasFeeCap)
+
+	return bindOpts, nil
+}
+
+// mergeFees returns estimated fee caps overridden by the ones explicitly set by the user
+func (opts *TransactOpts) mergeFees(gasTipCap, gasFeeCap *big.Int) (tip, feeCap *big.Int) {
+	switch {
+	case opts.GasFeeCap != nil && opts.GasTipCap != nil:
+		return opts.GasTipCap, opts.GasFeeCap
+	case opts.GasFeeCap != nil:
+		// never exceed a fee cap explicitly set by the user
+		if gasTipCap.Cmp(opts.GasFeeCap) > 0 {
+			return opts.GasFeeCap, opts.GasFeeCap
+		}
+		return gasTipCap, opts.GasFeeCap
+	case opts.GasTipCap != nil:
+		if gasFeeCap.Cmp(opts.GasTipCap) < 0 {
+			return opts.GasTipCap, opts.GasTipCap
+		}
+		return opts.GasTipCap, gasFeeCap
+	default:
+		return gasTipCap, gasFeeCap
+	}
+}
@@ -0,0 +1,89 @@
+//go:build !integration
+
+package types
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactDynamicFeeTx(t *testing.T) {
+	opts := &TransactOpts{NoSign: true, GasTipCap: big.NewInt(2)}
+
+	to := gethcommon.HexToAddress("0xff00000000000000000000000000000000000000")
+	tx, err := opts.Transact(t.Context(), new(testBackend), big.NewInt(1), &to, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(gethtypes.DynamicFeeTxType), tx.Type())
+	assert.Equal(t, int64(2), tx.GasTipCap().Int64())
+	assert.Equal(t, int64(210), tx.GasFeeCap().Int64())
+
+	opts = &TransactOpts{NoSign: true, GasPrice: big.NewInt(3)}
+	tx, err = opts.Transact(t.Context(), new(testBackend), big.NewInt(1), &to, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(gethtypes.LegacyTxType), tx.Type())
+	assert.Equal(t, int64(3), tx.GasPrice().Int64())
+}
+
+func TestTransactSetCodeTx(t *testing.T) {
+	key, err := gethcrypto.GenerateKey()
+	require.NoError(t, err)
+	from := gethcrypto.PubkeyToAddress(key.PublicKey)
+
+	signTx := func(_ context.Context, _ gethcommon.Address, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
+		return gethtypes.SignTx(tx, gethtypes.LatestSignerForChainID(chainID), key)
+	}
+	signAuth := func(_ context.Context, _ gethcommon.Address, auth gethtypes.SetCodeAuthorization) (gethtypes.SetCodeAuthorization, error) {
+		return gethtypes.SignSetCode(key, auth)
+	}
+
+	delegate := gethcommon.HexToAddress("0xff00000000000000000000000000000000000000")
+	opts := &TransactOpts{From: from}
+	require.NoError(t, opts.Authorize(t.Context(), new(testBackend), big.NewInt(1), from, delegate, signAuth))
+	require.Len(t, opts.AuthorizationList, 1)
+
+	// sender authorizes itself so the authorization nonce follows the transaction nonce
+	auth := opts.AuthorizationList[0]
+	assert.Equal(t, uint64(8), auth.Nonce)
+	assert.Equal(t, delegate, auth.Address)
+	assert.Equal(t, uint64(1), auth.ChainID.Uint64())
+	authority, err := auth.Authority()
+	require.NoError(t, err)
+	assert.Equal(t, from, authority)
+
+	tx, err := opts.Transact(t.Context(), new(testBackend), big.NewInt(1), &from, nil, signTx)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(gethtypes.SetCodeTxType), tx.Type())
+	assert.Equal(t, uint64(7), tx.Nonce())
+	assert.Equal(t, opts.AuthorizationList, tx.SetCodeAuthorizations())
+
+	sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(big.NewInt(1)), tx)
+	require.NoError(t, err)
+	assert.Equal(t, from, sender)
+
+	_, err = opts.Transact(t.Context(), new(testBackend), nil, &from, nil, signTx)
+	assert.ErrorContains(t, err, "chain ID")
+
+	opts.Blobs = new(gethtypes.BlobTxSidecar)
+	_, err = opts.Transact(t.Context(), new(testBackend), big.NewInt(1), &from, nil, signTx)
+	require.Error(t, err)
+
+	opts.AuthorizationList = nil
+	_, err = opts.Transact(t.Context(), new(testBackend), nil, &from, nil, signTx)
+	assert.ErrorContains(t, err, "chain ID")
+}
+
+func TestNewSetCodeAuthorization(t *testing.T) {
+	auth, err := NewSetCodeAuthorization(nil, gethcommon.Address{}, 1)
+	require.NoError(t, err)
+	assert.True(t, auth.ChainID.IsZero())
+
+	_, err = NewSetCodeAuthorization(big.NewInt(-1), gethcommon.Address{}, 1)
+	require.Error(t, err)
+}
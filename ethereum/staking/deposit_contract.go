@@ -0,0 +1,395 @@
+package staking
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethcl "github.com/kilnfi/go-utils/ethereum/consensus"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
+	beaconcommon "github.com/protolambda/zrnt/eth2/beacon/common"
+	"github.com/protolambda/ztyp/tree"
+)
+
+// depositContractABI is the ABI of the deposit() function and DepositEvent of the deposit contract
+// (c.f. https://github.com/ethereum/consensus-specs/blob/dev/solidity_deposit_contract/deposit_contract.sol)
+const depositContractABI = `[
+	{
+		"inputs": [
+			{"internalType": "bytes", "name": "pubkey", "type": "bytes"},
+			{"internalType": "bytes", "name": "withdrawal_credentials", "type": "bytes"},
+			{"internalType": "bytes", "name": "signature", "type": "bytes"},
+			{"internalType": "bytes32", "name": "deposit_data_root", "type": "bytes32"}
+		],
+		"name": "deposit",
+		"outputs": [],
+		"stateMutability": "payable",
+		"type": "function"
+	},
+	{
+		"anonymous": false,
+		"inputs": [
+			{"indexed": false, "internalType": "bytes", "name": "pubkey", "type": "bytes"},
+			{"indexed": false, "internalType": "bytes", "name": "withdrawal_credentials", "type": "bytes"},
+			{"indexed": false, "internalType": "bytes", "name": "amount", "type": "bytes"},
+			{"indexed": false, "internalType": "bytes", "name": "signature", "type": "bytes"},
+			{"indexed": false, "internalType": "bytes", "name": "index", "type": "bytes"}
+		],
+		"name": "DepositEvent",
+		"type": "event"
+	}
+]`
+
+// batchDepositABI is the ABI of batch deposit contracts forwarding several deposits to the deposit contract
+//
+// pubkeys, withdrawal_credentials and signatures are the concatenation of the values of each deposit.
+const batchDepositABI = `[
+	{
+		"inputs": [
+			{"internalType": "bytes", "name": "pubkeys", "type": "bytes"},
+			{"internalType": "bytes", "name": "withdrawal_credentials", "type": "bytes"},
+			{"internalType": "bytes", "name": "signatures", "type": "bytes"},
+			{"internalType": "bytes32[]", "name": "deposit_data_roots", "type": "bytes32[]"}
+		],
+		"name": "batchDeposit",
+		"outputs": [],
+		"stateMutability": "payable",
+		"type": "function"
+	}
+]`
+
+var (
+	parsedDepositContractABI = mustParseABI(depositContractABI)
+	parsedBatchDepositABI    = mustParseABI(batchDepositABI)
+)
+
+// DepositEventTopic is the topic of DepositEvent logs of the deposit contract
+var DepositEventTopic = parsedDepositContractABI.Events["DepositEvent"].ID
+
+func mustParseABI(s string) gethabi.ABI {
+	parsed, err := gethabi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+var depositContractAddresses = map[string]gethcommon.Address{
+	ethcl.MainnetForkVersion.String():  gethcommon.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa"),
+	ethcl.PraterForkVersion.String():   gethcommon.HexToAddress("0xff50ed3d0ec03aC01D4C79aAd74928BFF48a7b2b"),
+	ethcl.SepoliaForkVersion.String():  gethcommon.HexToAddress("0x7f02C3E3c98b133055B8B348B2Ac625669Ed295D"),
+	ethcl.HoleskyForkVersion.String():  gethcommon.HexToAddress("0x4242424242424242424242424242424242424242"),
+	ethcl.HoodiForkVersion.String():    gethcommon.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa"),
+	ethcl.KurtosisForkVersion.String(): gethcommon.HexToAddress("0x4242424242424242424242424242424242424242"),
+}
+
+// DepositContractAddress returns the address of the deposit contract of the network of genesis fork version v
+func DepositContractAddress(v beaconcommon.Version) (gethcommon.Address, error) {
+	if addr, ok := depositContractAddresses[v.String()]; ok {
+		return addr, nil
+	}
+	return gethcommon.Address{}, fmt.Errorf("unknown deposit contract for fork version %v", v)
+}
+
+// gweiToWei converts a deposit amount to the value of the transaction depositing it
+func gweiToWei(amount beaconcommon.Gwei) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(uint64(amount)), big.NewInt(1e9))
+}
+
+// DepositCalldata returns the calldata of the deposit contract deposit() call depositing data
+func DepositCalldata(data *DepositData) ([]byte, error) {
+	if data.Amount < MinDepositAmount {
+		return nil, fmt.Errorf("deposit amount %v is below minimum %v", data.Amount, MinDepositAmount)
+	}
+
+	return parsedDepositContractABI.Pack(
+		"deposit",
+		data.Pubkey[:],
+		data.WithdrawalCredentials[:],
+		data.Signature[:],
+		[32]byte(data.HashTreeRoot(tree.GetHashFn())),
+	)
+}
+
+// BatchDepositCalldata returns the calldata of the batch deposit contract batchDeposit() call depositing datas
+// and the value of the transaction
+//
+// As batch deposit contracts split the transaction value evenly between deposits, all deposits must have the same amount.
+func BatchDepositCalldata(datas ...*DepositData) (calldata []byte, value *big.Int, err error) {
+	if len(datas) == 0 {
+		return nil, nil, errors.New("no deposit data")
+	}
+
+	var (
+		pubkeys    = make([]byte, 0, len(datas)*len(beaconcommon.BLSPubkey{}))
+		creds      = make([]byte, 0, len(datas)*len(beaconcommon.Root{}))
+		signatures = make([]byte, 0, len(datas)*len(beaconcommon.BLSSignature{}))
+		roots      = make([][32]byte, 0, len(datas))
+	)
+	for i, data := range datas {
+		if data.Amount < MinDepositAmount {
+			return nil, nil, fmt.Errorf("deposit amount %v at pos %v is below minimum %v", data.Amount, i, MinDepositAmount)
+		}
+		if data.Amount != datas[0].Amount {
+			return nil, nil, fmt.Errorf("deposit amount %v at pos %v differs from amount %v of other deposits", data.Amount, i, datas[0].Amount)
+		}
+
+		pubkeys = append(pubkeys, data.Pubkey[:]...)
+		creds = append(creds, data.WithdrawalCredentials[:]...)
+		signatures = append(signatures, data.Signature[:]...)
+		roots = append(roots, [32]byte(data.HashTreeRoot(tree.GetHashFn())))
+	}
+
+	calldata, err = parsedBatchDepositABI.Pack("batchDeposit", pubkeys, creds, signatures, roots)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return calldata, new(big.Int).Mul(gweiToWei(datas[0].Amount), big.NewInt(int64(len(datas)))), nil
+}
+
+// Deposit sends a transaction depositing data to the deposit contract at contract
+//
+// The transaction value is the deposit amount, other transaction fields are set from opts (c.f. types.TransactOpts.Transact).
+// signTx is typically the SignTx method of a keystore.Store.
+//
+// Deposit data are not validated, they should be validated beforehand (e.g. with ValidateDepositDataAt).
+func Deposit(
+	ctx context.Context,
+	backend types.TransactBackend,
+	chainID *big.Int,
+	contract gethcommon.Address,
+	opts *types.TransactOpts,
+	signTx types.SignTxFunc,
+	data *DepositData,
+) (*gethtypes.Transaction, error) {
+	calldata, err := DepositCalldata(data)
+	if err != nil {
+		return nil, err
+	}
+
+	txOpts := *opts
+	txOpts.Value = gweiToWei(data.Amount)
+
+	return txOpts.Transact(ctx, backend, chainID, &contract, calldata, signTx)
+}
+
+// BatchDeposit sends a transaction depositing datas through the batch deposit contract at contract
+//
+// It is the same as Deposit for several deposits (c.f. BatchDepositCalldata).
+func BatchDeposit(
+	ctx context.Context,
+	backend types.TransactBackend,
+	chainID *big.Int,
+	contract gethcommon.Address,
+	opts *types.TransactOpts,
+	signTx types.SignTxFunc,
+	datas ...*DepositData,
+) (*gethtypes.Transaction, error) {
+	calldata, value, err := BatchDepositCalldata(datas...)
+	if err != nil {
+		return nil, err
+	}
+
+	txOpts := *opts
+	txOpts.Value = value
+
+	return txOpts.Transact(ctx, backend, chainID, &contract, calldata, signTx)
+}
+
+// DepositEvent is a DepositEvent log emitted by the deposit contract
+type DepositEvent struct {
+	Pubkey                beaconcommon.BLSPubkey
+	WithdrawalCredentials beaconcommon.Root
+	Amount                beaconcommon.Gwei
+	Signature             beaconcommon.BLSSignature
+
+	// Index is the index of the deposit in the deposit contract
+	Index uint64
+
+	BlockNumber uint64
+	TxHash      gethcommon.Hash
+	LogIndex    uint
+}
+
+// ParseDepositEvent parses a DepositEvent log of the deposit contract
+func ParseDepositEvent(log *gethtypes.Log) (*DepositEvent, error) {
+	if len(log.Topics) == 0 || log.Topics[0] != DepositEventTopic {
+		return nil, errors.New("not a DepositEvent log")
+	}
+
+	values, err := parsedDepositContractABI.Unpack("DepositEvent", log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DepositEvent log: %w", err)
+	}
+
+	fields := make([][]byte, len(values))
+	for i, size := range []int{48, 32, 8, 96, 8} {
+		b, ok := values[i].([]byte)
+		if !ok || len(b) != size {
+			return nil, fmt.Errorf("invalid DepositEvent log: invalid %v", parsedDepositContractABI.Events["DepositEvent"].Inputs[i].Name)
+		}
+		fields[i] = b
+	}
+
+	event := &DepositEvent{
+		Amount:      beaconcommon.Gwei(binary.LittleEndian.Uint64(fields[2])),
+		Index:       binary.LittleEndian.Uint64(fields[4]),
+		BlockNumber: log.BlockNumber,
+		TxHash:      log.TxHash,
+		LogIndex:    log.Index,
+	}
+	copy(event.Pubkey[:], fields[0])
+	copy(event.WithdrawalCredentials[:], fields[1])
+	copy(event.Signature[:], fields[3])
+
+	return event, nil
+}
+
+// LogFilterer is the node method required to scan DepositEvent logs
+type LogFilterer interface {
+	FilterLogs(ctx context.Context, q geth.FilterQuery) ([]gethtypes.Log, error)
+}
+
+// FilterDepositEvents returns DepositEvent logs emitted by contract from block fromBlock to toBlock (included)
+//
+// As nodes usually limit the range of eth_getLogs, logs are queried by ranges of at most blockRange blocks
+// (in a single query if blockRange is 0).
+func FilterDepositEvents(ctx context.Context, filterer LogFilterer, contract gethcommon.Address, fromBlock, toBlock, blockRange uint64) ([]*DepositEvent, error) {
+	var events []*DepositEvent
+	for start := fromBlock; start <= toBlock; {
+		end := toBlock
+		if blockRange > 0 && end-start >= blockRange {
+			end = start + blockRange - 1
+		}
+
+		logs, err := filterer.FilterLogs(ctx, geth.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []gethcommon.Address{contract},
+			Topics:    [][]gethcommon.Hash{{DepositEventTopic}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter DepositEvent logs from block %v to %v: %w", start, end, err)
+		}
+
+		for i := range logs {
+			if logs[i].Removed {
+				continue
+			}
+
+			event, err := ParseDepositEvent(&logs[i])
+			if err != nil {
+				return nil, fmt.Errorf("log %v of tx %v: %w", logs[i].Index, logs[i].TxHash, err)
+			}
+			events = append(events, event)
+		}
+
+		if end == toBlock {
+			break
+		}
+		start = end + 1
+	}
+
+	return events, nil
+}
+
+var (
+	// ErrDepositNotFound is the error of a pubkey without any deposit with a valid signature
+	ErrDepositNotFound = errors.New("no deposit with a valid signature")
+
+	// ErrDepositFrontRun is wrapped by the error of a pubkey whose first deposit has foreign withdrawal credentials
+	ErrDepositFrontRun = errors.New("deposit front-run with foreign withdrawal credentials")
+)
+
+// DepositStatus is the on-chain status of the deposits of a validator
+type DepositStatus struct {
+	Pubkey beaconcommon.BLSPubkey
+
+	// Deposits are all deposits of Pubkey ordered by index
+	Deposits []*DepositEvent
+
+	// First is the first deposit of Pubkey with a valid signature, which sets the validator withdrawal credentials
+	First *DepositEvent
+
+	// Amount is the total amount deposited from the First deposit
+	Amount beaconcommon.Gwei
+
+	// Err is ErrDepositNotFound or wraps ErrDepositFrontRun if Pubkey was not deposited with the expected credentials
+	Err error
+}
+
+// VerifyDeposits verifies each of pubkeys was deposited with withdrawal credentials expectedCreds
+//
+// The consensus layer ignores deposits of an unknown pubkey until one has a valid signature for the network
+// of fork version: this first deposit sets the validator withdrawal credentials, following ones are top-ups.
+// A first deposit with other credentials than expectedCreds means the deposit was front-run.
+func VerifyDeposits(events []*DepositEvent, version beaconcommon.Version, expectedCreds beaconcommon.Root, pubkeys ...beaconcommon.BLSPubkey) []*DepositStatus {
+	statuses := make([]*DepositStatus, len(pubkeys))
+	byPubkey := make(map[beaconcommon.BLSPubkey]*DepositStatus, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		statuses[i] = &DepositStatus{Pubkey: pubkey}
+		byPubkey[pubkey] = statuses[i]
+	}
+
+	sorted := make([]*DepositEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	for _, event := range sorted {
+		status, ok := byPubkey[event.Pubkey]
+		if !ok {
+			continue
+		}
+		status.Deposits = append(status.Deposits, event)
+
+		if status.First != nil {
+			status.Amount += event.Amount
+			continue
+		}
+
+		data := &DepositData{
+			DepositData: beaconcommon.DepositData{
+				Pubkey:                event.Pubkey,
+				WithdrawalCredentials: event.WithdrawalCredentials,
+				Amount:                event.Amount,
+				Signature:             event.Signature,
+			},
+			Version: version,
+		}
+		if valid, err := data.VerifySignature(); err == nil && valid {
+			status.First = event
+			status.Amount = event.Amount
+		}
+	}
+
+	for _, status := range statuses {
+		switch {
+		case status.First == nil:
+			status.Err = ErrDepositNotFound
+		case status.First.WithdrawalCredentials != expectedCreds:
+			status.Err = fmt.Errorf("%w: deposit %v in tx %v has credentials %v (expected %v)", ErrDepositFrontRun, status.First.Index, status.First.TxHash, status.First.WithdrawalCredentials, expectedCreds)
+		}
+	}
+
+	return statuses
+}
+
+// DepositStatusesError returns an error joining errors of all pubkeys not deposited with the expected credentials
+func DepositStatusesError(statuses []*DepositStatus) error {
+	var errs []error
+	for _, status := range statuses {
+		if status.Err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", status.Pubkey, status.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,211 @@
+//go:build !integration
+
+package staking
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	geth "github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethcl "github.com/kilnfi/go-utils/ethereum/consensus"
+	"github.com/kilnfi/go-utils/ethereum/execution/client/simulated"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
+	beaconcommon "github.com/protolambda/zrnt/eth2/beacon/common"
+	"github.com/protolambda/ztyp/tree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDepositData(t *testing.T, startIndex, count int, addr gethcommon.Address, amount beaconcommon.Gwei) []*DepositData {
+	t.Helper()
+
+	vkeys, err := GenerateValidatorKeysFrom(testMnemonic, "", startIndex, count, false, nil)
+	require.NoError(t, err)
+
+	creds, err := NewWithdrawalCredentials(CompoundingWithdrawalPrefix, addr)
+	require.NoError(t, err)
+
+	datas, err := GenerateDepositData(vkeys, creds, amount, ethcl.HoodiForkVersion)
+	require.NoError(t, err)
+
+	return datas
+}
+
+func TestDepositCalldata(t *testing.T) {
+	datas := newTestDepositData(t, 0, 2, gethcommon.HexToAddress("0x7e654d251da770a068413677967f6d3ea2fea9e4"), beaconcommon.Gwei(32000000000))
+
+	calldata, err := DepositCalldata(datas[0])
+	require.NoError(t, err)
+	assert.Equal(t, "22895118", hex.EncodeToString(calldata[:4]))
+
+	args, err := parsedDepositContractABI.Methods["deposit"].Inputs.Unpack(calldata[4:])
+	require.NoError(t, err)
+	assert.Equal(t, datas[0].Pubkey[:], args[0])
+	assert.Equal(t, datas[0].WithdrawalCredentials[:], args[1])
+	assert.Equal(t, datas[0].Signature[:], args[2])
+	assert.Equal(t, [32]byte(datas[0].HashTreeRoot(tree.GetHashFn())), args[3])
+
+	calldata, value, err := BatchDepositCalldata(datas...)
+	require.NoError(t, err)
+	assert.Equal(t, "64000000000000000000", value.String())
+
+	args, err = parsedBatchDepositABI.Methods["batchDeposit"].Inputs.Unpack(calldata[4:])
+	require.NoError(t, err)
+	assert.Equal(t, append(datas[0].Pubkey[:], datas[1].Pubkey[:]...), args[0])
+	assert.Len(t, args[3], 2)
+
+	topUp := *datas[1]
+	topUp.Amount = MinDepositAmount
+	_, _, err = BatchDepositCalldata(datas[0], &topUp)
+	assert.Error(t, err, "different amounts")
+
+	topUp.Amount = MinDepositAmount - 1
+	_, err = DepositCalldata(&topUp)
+	assert.Error(t, err, "below minimum")
+
+	_, _, err = BatchDepositCalldata()
+	assert.Error(t, err)
+}
+
+func TestDeposit(t *testing.T) {
+	c, err := simulated.New(nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	from, err := c.NewAccount(t.Context(), new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18)))
+	require.NoError(t, err)
+
+	chainID, err := c.ChainID(t.Context())
+	require.NoError(t, err)
+
+	contract, err := DepositContractAddress(ethcl.HoodiForkVersion)
+	require.NoError(t, err)
+	assert.Equal(t, gethcommon.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa"), contract)
+
+	datas := newTestDepositData(t, 0, 2, from, beaconcommon.Gwei(32000000000))
+
+	opts := &types.TransactOpts{From: from, Send: true}
+	tx, err := Deposit(t.Context(), c, chainID, contract, opts, c.SignTx, datas[0])
+	require.NoError(t, err)
+	assert.Nil(t, opts.Value, "opts are not modified")
+	assert.Equal(t, contract, *tx.To())
+	assert.Equal(t, "32000000000000000000", tx.Value().String())
+
+	expected, err := DepositCalldata(datas[0])
+	require.NoError(t, err)
+	assert.Equal(t, expected, tx.Data())
+
+	batchTx, err := BatchDeposit(t.Context(), c, chainID, gethcommon.Address{0x1}, opts, c.SignTx, datas...)
+	require.NoError(t, err)
+	assert.Equal(t, "64000000000000000000", batchTx.Value().String())
+
+	c.Commit()
+	for _, tx := range []*gethtypes.Transaction{tx, batchTx} {
+		receipt, err := c.TransactionReceipt(t.Context(), tx.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, gethtypes.ReceiptStatusSuccessful, receipt.Status)
+	}
+}
+
+type fakeLogFilterer struct {
+	logs    []gethtypes.Log
+	queries []geth.FilterQuery
+}
+
+func (f *fakeLogFilterer) FilterLogs(_ context.Context, q geth.FilterQuery) ([]gethtypes.Log, error) {
+	f.queries = append(f.queries, q)
+
+	var logs []gethtypes.Log
+	for _, log := range f.logs {
+		if log.BlockNumber >= q.FromBlock.Uint64() && log.BlockNumber <= q.ToBlock.Uint64() {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func newDepositEventLog(t *testing.T, contract gethcommon.Address, data *DepositData, index, blockNumber uint64) gethtypes.Log {
+	t.Helper()
+
+	amount, idx := make([]byte, 8), make([]byte, 8)
+	binary.LittleEndian.PutUint64(amount, uint64(data.Amount))
+	binary.LittleEndian.PutUint64(idx, index)
+
+	b, err := parsedDepositContractABI.Events["DepositEvent"].Inputs.Pack(
+		data.Pubkey[:],
+		data.WithdrawalCredentials[:],
+		amount,
+		data.Signature[:],
+		idx,
+	)
+	require.NoError(t, err)
+
+	return gethtypes.Log{
+		Address:     contract,
+		Topics:      []gethcommon.Hash{DepositEventTopic},
+		Data:        b,
+		BlockNumber: blockNumber,
+	}
+}
+
+func TestVerifyDeposits(t *testing.T) {
+	contract := gethcommon.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa")
+	owner := gethcommon.HexToAddress("0x7e654d251da770a068413677967f6d3ea2fea9e4")
+	attacker := gethcommon.HexToAddress("0x000000000000000000000000000000000000dead")
+
+	datas := newTestDepositData(t, 0, 4, owner, beaconcommon.Gwei(32000000000))
+	foreign := newTestDepositData(t, 0, 4, attacker, MinDepositAmount)
+
+	// deposit with foreign credentials and a signature of another key, ignored by the consensus layer
+	invalid := *foreign[2]
+	invalid.Signature = foreign[3].Signature
+
+	filterer := &fakeLogFilterer{
+		logs: []gethtypes.Log{
+			newDepositEventLog(t, contract, datas[0], 0, 10),
+			newDepositEventLog(t, contract, foreign[1], 1, 10),
+			newDepositEventLog(t, contract, datas[1], 2, 25),
+			newDepositEventLog(t, contract, &invalid, 3, 25),
+			newDepositEventLog(t, contract, datas[2], 4, 40),
+			newDepositEventLog(t, contract, foreign[0], 5, 41),
+		},
+	}
+
+	events, err := FilterDepositEvents(t.Context(), filterer, contract, 0, 45, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 6)
+	assert.Len(t, filterer.queries, 5)
+	assert.Equal(t, uint64(9), filterer.queries[0].ToBlock.Uint64())
+	assert.Equal(t, uint64(45), filterer.queries[4].ToBlock.Uint64())
+	assert.Equal(t, datas[0].Pubkey, events[0].Pubkey)
+	assert.Equal(t, beaconcommon.Gwei(32000000000), events[0].Amount)
+	assert.Equal(t, uint64(5), events[5].Index)
+
+	statuses := VerifyDeposits(events, ethcl.HoodiForkVersion, datas[0].WithdrawalCredentials, datas[0].Pubkey, datas[1].Pubkey, datas[2].Pubkey, datas[3].Pubkey)
+	require.Len(t, statuses, 4)
+
+	// deposited then topped up by a third party
+	require.NoError(t, statuses[0].Err)
+	assert.Len(t, statuses[0].Deposits, 2)
+	assert.Equal(t, beaconcommon.Gwei(33000000000), statuses[0].Amount)
+
+	// front-run
+	assert.ErrorIs(t, statuses[1].Err, ErrDepositFrontRun)
+	assert.Equal(t, uint64(1), statuses[1].First.Index)
+
+	// first deposit with foreign credentials has an invalid signature
+	require.NoError(t, statuses[2].Err)
+	assert.Equal(t, uint64(4), statuses[2].First.Index)
+	assert.Equal(t, beaconcommon.Gwei(32000000000), statuses[2].Amount)
+
+	assert.ErrorIs(t, statuses[3].Err, ErrDepositNotFound)
+
+	err = DepositStatusesError(statuses)
+	assert.ErrorIs(t, err, ErrDepositFrontRun)
+	assert.ErrorIs(t, err, ErrDepositNotFound)
+}
@@ -16,6 +16,9 @@ type DepositData struct {
 	beaconcommon.DepositData
 
 	Version beaconcommon.Version
+
+	// DepositCLIVersion is the version of staking-deposit-cli the deposit data is compatible with (optional)
+	DepositCLIVersion string
 }
 
 func (data *DepositData) Network() string {
@@ -26,13 +29,8 @@ func (data *DepositData) Network() string {
 func (data *DepositData) Sign(
 	vkey *ValidatorKey,
 ) (*DepositData, error) {
-	if len(data.Pubkey) == 0 {
-		pubKey := new(beaconcommon.BLSPubkey)
-		err := pubKey.UnmarshalText(vkey.PrivKey.PublicKey().Marshal())
-		if err != nil {
-			return nil, err
-		}
-		data.Pubkey = *pubKey
+	if (data.Pubkey == beaconcommon.BLSPubkey{}) {
+		copy(data.Pubkey[:], vkey.PrivKey.PublicKey().Marshal())
 	} else if "0x"+hex.EncodeToString(vkey.PrivKey.PublicKey().Marshal()) != data.Pubkey.String() {
 		return nil, errors.New("signing keys does not match data public key")
 	}
@@ -67,12 +65,15 @@ func sign(vkey *ValidatorKey, root beaconcommon.Root) (*beaconcommon.BLSSignatur
 }
 
 func (data *DepositData) VerifySignature() (bool, error) {
-	sig, err := e2types.BLSSignatureFromBytes(data.Signature[:])
+	// BLS bytes are copied as cgo forbids passing memory of data (which holds Go pointers)
+	sigBytes, pubkeyBytes := data.Signature, data.Pubkey
+
+	sig, err := e2types.BLSSignatureFromBytes(sigBytes[:])
 	if err != nil {
 		return false, err
 	}
 
-	pubkey, err := e2types.BLSPublicKeyFromBytes(data.Pubkey[:])
+	pubkey, err := e2types.BLSPublicKeyFromBytes(pubkeyBytes[:])
 	if err != nil {
 		return false, err
 	}
@@ -98,18 +99,38 @@ type jsonDepositData struct {
 	Network               string                    `json:"network_name,omitempty"`
 	DepositMessageRoot    beaconcommon.Root         `json:"deposit_message_root"`
 	DepositDataRoot       beaconcommon.Root         `json:"deposit_data_root"`
+	DepositCLIVersion     string                    `json:"deposit_cli_version,omitempty"`
 }
 
+// launchpadDepositData is deposit data in the format of deposit_data-<ts>.json files generated
+// by staking-deposit-cli and expected by the staking launchpad (fields order matters)
+type launchpadDepositData struct {
+	Pubkey                string `json:"pubkey"`
+	WithdrawalCredentials string `json:"withdrawal_credentials"`
+	Amount                uint64 `json:"amount"`
+	Signature             string `json:"signature"`
+	DepositMessageRoot    string `json:"deposit_message_root"`
+	DepositDataRoot       string `json:"deposit_data_root"`
+	Version               string `json:"fork_version"`
+	Network               string `json:"network_name,omitempty"`
+	DepositCLIVersion     string `json:"deposit_cli_version,omitempty"`
+}
+
+// MarshalJSON encodes deposit data in the format of staking-deposit-cli
 func (data *DepositData) MarshalJSON() ([]byte, error) {
-	d := &jsonDepositData{
-		Pubkey:                data.Pubkey,
-		WithdrawalCredentials: data.WithdrawalCredentials,
-		Amount:                data.Amount,
-		Signature:             data.Signature,
-		Version:               data.Version,
+	msgRoot := data.MessageRoot()
+	dataRoot := data.HashTreeRoot(tree.GetHashFn())
+
+	d := &launchpadDepositData{
+		Pubkey:                hex.EncodeToString(data.Pubkey[:]),
+		WithdrawalCredentials: hex.EncodeToString(data.WithdrawalCredentials[:]),
+		Amount:                uint64(data.Amount),
+		Signature:             hex.EncodeToString(data.Signature[:]),
+		DepositMessageRoot:    hex.EncodeToString(msgRoot[:]),
+		DepositDataRoot:       hex.EncodeToString(dataRoot[:]),
+		Version:               hex.EncodeToString(data.Version[:]),
 		Network:               data.Network(),
-		DepositMessageRoot:    data.MessageRoot(),
-		DepositDataRoot:       data.HashTreeRoot(tree.GetHashFn()),
+		DepositCLIVersion:     data.DepositCLIVersion,
 	}
 
 	return json.Marshal(d)
@@ -127,6 +148,7 @@ func (data *DepositData) UnmarshalJSON(b []byte) error {
 	data.Amount = d.Amount
 	data.Signature = d.Signature
 	data.Version = d.Version
+	data.DepositCLIVersion = d.DepositCLIVersion
 
 	// Validates `deposit_message_root` and `deposit_data_root`
 	if (d.DepositMessageRoot != beaconcommon.Root{}) && (d.DepositMessageRoot != data.MessageRoot()) {
@@ -150,6 +172,26 @@ func DepositDomain(version beaconcommon.Version) beaconcommon.BLSDomain {
 }
 
 func ValidateDepositData(expectedCreds beaconcommon.Root, expectedVersion beaconcommon.Version, expectedAmount beaconcommon.Gwei, datas ...*DepositData) error {
+	return validateDepositData(expectedCreds, expectedVersion, func(i int, data *DepositData) error {
+		if data.Amount == beaconcommon.Gwei(0) {
+			data.Amount = expectedAmount
+		} else if data.Amount != expectedAmount {
+			return fmt.Errorf("invalid `amount` %v at pos %v (expected %v)", data.Amount, i, expectedAmount)
+		}
+		return nil
+	}, datas...)
+}
+
+// validateDepositData validates credentials, fork version and signature of deposit data, unset credentials
+// and fork version default to the expected ones.
+//
+// validateAmount validates the amount of the deposit at pos i, it may set a default amount on data (a copy).
+func validateDepositData(
+	expectedCreds beaconcommon.Root,
+	expectedVersion beaconcommon.Version,
+	validateAmount func(i int, data *DepositData) error,
+	datas ...*DepositData,
+) error {
 	for i, data := range datas {
 		tmpData := new(DepositData)
 		*tmpData = *data
@@ -165,10 +207,8 @@ func ValidateDepositData(expectedCreds beaconcommon.Root, expectedVersion beacon
 			return fmt.Errorf("invalid `fork_version` %v at pos %v (expected %v)", data.Version, i, expectedVersion)
 		}
 
-		if tmpData.Amount == beaconcommon.Gwei(0) {
-			tmpData.Amount = expectedAmount
-		} else if tmpData.Amount != expectedAmount {
-			return fmt.Errorf("invalid `amount` %v at pos %v (expected %v)", tmpData.Amount, i, expectedAmount)
+		if err := validateAmount(i, tmpData); err != nil {
+			return err
 		}
 
 		valid, err := tmpData.VerifySignature()
@@ -0,0 +1,157 @@
+package staking
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	beaconcommon "github.com/protolambda/zrnt/eth2/beacon/common"
+)
+
+// DepositCLIVersion is the version of staking-deposit-cli generated deposit data are compatible with
+const DepositCLIVersion = "2.7.0"
+
+// Prefixes of withdrawal credentials
+const (
+	BLSWithdrawalPrefix         byte = 0x00
+	ETH1AddressWithdrawalPrefix byte = 0x01
+	CompoundingWithdrawalPrefix byte = 0x02
+)
+
+// NewWithdrawalCredentials returns withdrawal credentials of an execution layer address
+//
+// prefix is either ETH1AddressWithdrawalPrefix (0x01) or CompoundingWithdrawalPrefix (0x02).
+func NewWithdrawalCredentials(prefix byte, addr gethcommon.Address) (beaconcommon.Root, error) {
+	if prefix != ETH1AddressWithdrawalPrefix && prefix != CompoundingWithdrawalPrefix {
+		return beaconcommon.Root{}, fmt.Errorf("invalid withdrawal credentials prefix %#02x for an execution address (expected 0x01 or 0x02)", prefix)
+	}
+
+	var creds beaconcommon.Root
+	creds[0] = prefix
+	copy(creds[12:], addr[:])
+
+	return creds, nil
+}
+
+// GenerateDepositData returns deposit data of vkeys signed for the network of fork version
+//
+// amount is validated with ValidateDepositAmount (e.g. up to 2048 ETH for compounding credentials).
+func GenerateDepositData(vkeys []*ValidatorKey, withdrawalCreds beaconcommon.Root, amount beaconcommon.Gwei, version beaconcommon.Version) ([]*DepositData, error) {
+	switch withdrawalCreds[0] {
+	case BLSWithdrawalPrefix, ETH1AddressWithdrawalPrefix, CompoundingWithdrawalPrefix:
+	default:
+		return nil, fmt.Errorf("invalid withdrawal credentials prefix %#02x", withdrawalCreds[0])
+	}
+	if err := ValidateDepositAmount(withdrawalCreds, amount); err != nil {
+		return nil, err
+	}
+
+	datas := make([]*DepositData, len(vkeys))
+	for i, vkey := range vkeys {
+		data := &DepositData{
+			DepositData: beaconcommon.DepositData{
+				WithdrawalCredentials: withdrawalCreds,
+				Amount:                amount,
+			},
+			Version:           version,
+			DepositCLIVersion: DepositCLIVersion,
+		}
+
+		var err error
+		if datas[i], err = data.Sign(vkey); err != nil {
+			return nil, fmt.Errorf("failed to sign deposit data of %v: %w", vkey.Pubkey, err)
+		}
+	}
+
+	return datas, nil
+}
+
+// MarshalDepositDataFile encodes deposit data as staking-deposit-cli encodes deposit_data-<ts>.json files
+//
+// staking-deposit-cli writes files with Python json.dump which separates items with ", " and keys with ": ".
+func MarshalDepositDataFile(datas []*DepositData) ([]byte, error) {
+	if datas == nil {
+		datas = []*DepositData{}
+	}
+
+	b, err := json.Marshal(datas)
+	if err != nil {
+		return nil, err
+	}
+
+	return pythonJSONSeparators(b), nil
+}
+
+// WriteDepositDataFile writes deposit data in dir as deposit_data-<ts>.json and returns the path of the file
+//
+// As staking-deposit-cli, the file is only readable by its owner and group.
+func WriteDepositDataFile(dir string, datas []*DepositData) (string, error) {
+	b, err := MarshalDepositDataFile(datas)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	pth := filepath.Join(dir, fmt.Sprintf("deposit_data-%d.json", time.Now().Unix()))
+	f, err := os.OpenFile(pth, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o440)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	return pth, f.Close()
+}
+
+// ReadDepositDataFile reads deposit data of a deposit_data-<ts>.json file
+//
+// Deposit message and deposit data roots are validated.
+func ReadDepositDataFile(pth string) ([]*DepositData, error) {
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	var datas []*DepositData
+	if err := json.Unmarshal(b, &datas); err != nil {
+		return nil, fmt.Errorf("invalid deposit data file: %w", err)
+	}
+
+	return datas, nil
+}
+
+// pythonJSONSeparators adds a space after each separator of compact JSON outside of strings
+func pythonJSONSeparators(b []byte) []byte {
+	var (
+		buf      bytes.Buffer
+		inString bool
+		escaped  bool
+	)
+	buf.Grow(len(b) + len(b)/8)
+
+	for _, c := range b {
+		buf.WriteByte(c)
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString && (c == ',' || c == ':'):
+			buf.WriteByte(' ')
+		}
+	}
+
+	return buf.Bytes()
+}
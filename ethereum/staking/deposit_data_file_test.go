@@ -0,0 +1,121 @@
+//go:build !integration
+
+package staking
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	ethcl "github.com/kilnfi/go-utils/ethereum/consensus"
+	beaconcommon "github.com/protolambda/zrnt/eth2/beacon/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDepositDataFileStakingDepositCLIParity checks files written by MarshalDepositDataFile are byte for byte
+// the ones written by staking-deposit-cli (Python json.dump): fields order, hex values without 0x prefix
+// and separators.
+//
+// testdata/deposit_data.json was generated by staking-deposit-cli then indented, it is compacted back
+// with the separators of json.dump. Its last 2 entries have been truncated by hand so they are ignored.
+func TestDepositDataFileStakingDepositCLIParity(t *testing.T) {
+	b, err := os.ReadFile("testdata/deposit_data.json")
+	require.NoError(t, err)
+
+	var entries []json.RawMessage
+	require.NoError(t, json.Unmarshal(b, &entries))
+	require.Len(t, entries, 7)
+
+	compacted, err := json.Marshal(entries[:5])
+	require.NoError(t, err)
+	expected := pythonJSONSeparators(compacted)
+
+	datas, err := ReadDepositDataFile("testdata/deposit_data.json")
+	require.NoError(t, err)
+	require.Len(t, datas, 7)
+	assert.Equal(t, "2.0.0", datas[0].DepositCLIVersion)
+
+	b, err = MarshalDepositDataFile(datas[:5])
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(b))
+	assert.True(t, strings.HasPrefix(string(b), `[{"pubkey": "9161cc71f1f70a2a251fe7e820ec288fc47e23ed4d364ddd6728f1a4a742556082b32024942d9d5abb5d1b335e51dd44", "withdrawal_credentials": "0008bd79`))
+
+	// separators within strings are left untouched
+	assert.Equal(t, `{"a": "b,c:d\\", "e": [1, 2]}`, string(pythonJSONSeparators([]byte(`{"a":"b,c:d\\","e":[1,2]}`))))
+}
+
+func TestGenerateDepositData(t *testing.T) {
+	mnemonic := "zebra sight furnace type elder speak spy beach parent snack million puppy mobile royal ski walnut awful dry culture orphan tourist throw expire shock"
+
+	// keys 3 and 4 generated by staking-deposit-cli (c.f. TestGenerateValidatorKeys)
+	vkeys, err := GenerateValidatorKeysFrom(mnemonic, "", 3, 2, false, nil)
+	require.NoError(t, err)
+	require.Len(t, vkeys, 2)
+	assert.Equal(t, "m/12381/3600/3/0/0", vkeys[0].Path)
+	assert.Equal(t, "ac6a8140b913070ebab4f814cecf25291d5d09c3dabf08b983fa47aa7611d3a1974b0ae484aff218dbfe4d57b3b8232d", vkeys[0].Pubkey)
+	assert.Equal(t, "922713b9ad7edb0886997ae937e58323b4b4b440e3be77412e25d79827a217722e21aa1ff63e554c1df1dbe959f46e48", vkeys[1].Pubkey)
+
+	_, err = GenerateValidatorKeysFrom(mnemonic, "", -1, 2, false, nil)
+	assert.Error(t, err)
+
+	// key 0 deposit of 32 ETH on mainnet (c.f. TestDepositDataSignAndVerifySignature)
+	vkeys, err = GenerateValidatorKeysFrom(mnemonic, "", 0, 1, false, nil)
+	require.NoError(t, err)
+
+	creds, err := NewWithdrawalCredentials(ETH1AddressWithdrawalPrefix, gethcommon.HexToAddress("0x7e654d251da770a068413677967f6d3ea2fea9e4"))
+	require.NoError(t, err)
+	assert.Equal(t, "0100000000000000000000007e654d251da770a068413677967f6d3ea2fea9e4", hex.EncodeToString(creds[:]))
+
+	datas, err := GenerateDepositData(vkeys, creds, beaconcommon.Gwei(32000000000), ethcl.MainnetForkVersion)
+	require.NoError(t, err)
+	require.Len(t, datas, 1)
+	assert.Equal(t, "0x996d2810d937e70bf546ae3249b05122cb91f784449372a73875225d2023981a927d0d060bc81435d8bb75ff2e2ffd5b043c60fd31c9c658385b25568b2bb3c9b72809d525d11ed7184a099f5251130329f01f24656bcb659f78c29c04d0b63e", datas[0].Signature.String())
+
+	var raw map[string]interface{}
+	b, err := json.Marshal(datas[0])
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &raw))
+	assert.Equal(t, vkeys[0].Pubkey, raw["pubkey"])
+	assert.Equal(t, "00000000", raw["fork_version"])
+	assert.Equal(t, "mainnet", raw["network_name"])
+	assert.Equal(t, DepositCLIVersion, raw["deposit_cli_version"])
+	assert.Equal(t, float64(32000000000), raw["amount"])
+	assert.Contains(t, raw, "deposit_message_root")
+	assert.Contains(t, raw, "deposit_data_root")
+
+	_, err = NewWithdrawalCredentials(BLSWithdrawalPrefix, gethcommon.Address{})
+	assert.Error(t, err)
+	_, err = GenerateDepositData(vkeys, beaconcommon.Root{0x03}, beaconcommon.Gwei(32000000000), ethcl.MainnetForkVersion)
+	assert.Error(t, err)
+}
+
+func TestWriteDepositDataFile(t *testing.T) {
+	vkeys, err := GenerateValidatorKeysFrom(testMnemonic, "", 0, 2, false, nil)
+	require.NoError(t, err)
+
+	creds, err := NewWithdrawalCredentials(CompoundingWithdrawalPrefix, gethcommon.HexToAddress("0x7e654d251da770a068413677967f6d3ea2fea9e4"))
+	require.NoError(t, err)
+
+	datas, err := GenerateDepositData(vkeys, creds, beaconcommon.Gwei(64000000000), ethcl.HoodiForkVersion)
+	require.NoError(t, err)
+
+	pth, err := WriteDepositDataFile(t.TempDir(), datas)
+	require.NoError(t, err)
+	assert.Regexp(t, `deposit_data-\d+\.json$`, pth)
+
+	info, err := os.Stat(pth)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o440), info.Mode().Perm())
+
+	read, err := ReadDepositDataFile(pth)
+	require.NoError(t, err)
+	require.Len(t, read, 2)
+	assert.Equal(t, datas[1].Pubkey, read[1].Pubkey)
+	assert.Equal(t, creds, read[1].WithdrawalCredentials)
+	assert.Equal(t, "hoodi", read[1].Network())
+	require.NoError(t, ValidateDepositData(creds, ethcl.HoodiForkVersion, beaconcommon.Gwei(64000000000), read...))
+}
@@ -0,0 +1,136 @@
+package staking
+
+import (
+	"errors"
+	"fmt"
+
+	ethcl "github.com/kilnfi/go-utils/ethereum/consensus"
+	"github.com/kilnfi/go-utils/ethereum/consensus/types"
+	beaconcommon "github.com/protolambda/zrnt/eth2/beacon/common"
+)
+
+// Deposit amounts bounds (c.f. https://eips.ethereum.org/EIPS/eip-7251)
+const (
+	// MinDepositAmount is the minimum amount of a deposit accepted by the deposit contract (1 ETH)
+	MinDepositAmount beaconcommon.Gwei = 1000000000
+
+	// MaxEffectiveBalance is the maximum effective balance of validators with 0x00 or 0x01 credentials (32 ETH)
+	MaxEffectiveBalance beaconcommon.Gwei = 32000000000
+
+	// MaxEffectiveBalanceElectra is the maximum effective balance of validators with 0x02 credentials (2048 ETH)
+	MaxEffectiveBalanceElectra beaconcommon.Gwei = 2048000000000
+)
+
+// IsCompounding returns true if creds are EIP-7251 compounding withdrawal credentials (0x02)
+func IsCompounding(creds beaconcommon.Root) bool {
+	return creds[0] == CompoundingWithdrawalPrefix
+}
+
+// MaxEffectiveBalanceOf returns the maximum effective balance of a validator with withdrawal credentials creds
+func MaxEffectiveBalanceOf(creds beaconcommon.Root) beaconcommon.Gwei {
+	if IsCompounding(creds) {
+		return MaxEffectiveBalanceElectra
+	}
+	return MaxEffectiveBalance
+}
+
+// ValidateWithdrawalCredentials validates creds can be deposited on the network of fork version at epoch
+//
+// 0x01 and 0x02 credentials must hold an execution address and 0x02 credentials are only valid from Electra.
+func ValidateWithdrawalCredentials(creds beaconcommon.Root, version beaconcommon.Version, epoch ethcl.Epoch) error {
+	switch creds[0] {
+	case BLSWithdrawalPrefix:
+		return nil
+	case ETH1AddressWithdrawalPrefix, CompoundingWithdrawalPrefix:
+		for _, b := range creds[1:12] {
+			if b != 0 {
+				return fmt.Errorf("invalid withdrawal credentials %v (bytes 1 to 11 must be zero)", creds)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid withdrawal credentials prefix %#02x", creds[0])
+	}
+
+	if IsCompounding(creds) && !ethcl.IsElectra(version, epoch) {
+		return fmt.Errorf("compounding withdrawal credentials %v are not supported before Electra (fork version %v, epoch %v)", creds, version, epoch)
+	}
+
+	return nil
+}
+
+// ValidateDepositAmount validates amount of the deposit creating a validator with withdrawal credentials creds
+//
+// It must be between MinDepositAmount and the maximum effective balance of creds
+// (i.e. 1 to 32 ETH for 0x00 and 0x01 credentials, 1 to 2048 ETH for 0x02 credentials).
+func ValidateDepositAmount(creds beaconcommon.Root, amount beaconcommon.Gwei) error {
+	if amount < MinDepositAmount {
+		return fmt.Errorf("deposit amount %v is below minimum %v", amount, MinDepositAmount)
+	}
+
+	if maxAmount := MaxEffectiveBalanceOf(creds); amount > maxAmount {
+		return fmt.Errorf("deposit amount %v exceeds maximum %v for withdrawal credentials prefix %#02x", amount, maxAmount, creds[0])
+	}
+
+	return nil
+}
+
+// ValidateDepositDataAt validates deposit data creating validators on the network of fork version at epoch
+//
+// Unlike ValidateDepositData, amounts may differ between deposits. Credentials are validated
+// with ValidateWithdrawalCredentials and amounts with ValidateDepositAmount.
+func ValidateDepositDataAt(expectedCreds beaconcommon.Root, expectedVersion beaconcommon.Version, epoch ethcl.Epoch, datas ...*DepositData) error {
+	if err := ValidateWithdrawalCredentials(expectedCreds, expectedVersion, epoch); err != nil {
+		return err
+	}
+
+	return validateDepositData(expectedCreds, expectedVersion, func(i int, data *DepositData) error {
+		if err := ValidateDepositAmount(data.WithdrawalCredentials, data.Amount); err != nil {
+			return fmt.Errorf("invalid `amount` at pos %v: %w", i, err)
+		}
+		return nil
+	}, datas...)
+}
+
+// ValidateTopUpDepositData validates deposit data topping up an existing validator
+//
+// Each top-up must be at least MinDepositAmount and the validator balance after all top-ups must not exceed
+// the maximum effective balance of its credentials (excess would be withdrawn). The validator must not be exiting.
+//
+// Consensus layer ignores withdrawal credentials and signature of top-ups. Credentials, if set, must still match
+// the validator's to catch mistakes, signatures are not verified.
+func ValidateTopUpDepositData(validator *types.Validator, expectedVersion beaconcommon.Version, datas ...*DepositData) error {
+	if validator == nil || validator.Validator == nil {
+		return errors.New("missing validator")
+	}
+
+	if validator.Validator.ExitEpoch != beaconcommon.FAR_FUTURE_EPOCH {
+		return fmt.Errorf("validator %v is exiting at epoch %v", validator.Index, validator.Validator.ExitEpoch)
+	}
+
+	creds := validator.Validator.WithdrawalCredentials
+	balance := validator.Balance
+	for i, data := range datas {
+		if data.Pubkey != validator.Validator.Pubkey {
+			return fmt.Errorf("invalid `pubkey` %v at pos %v (expected %v)", data.Pubkey, i, validator.Validator.Pubkey)
+		}
+
+		if (data.WithdrawalCredentials != beaconcommon.Root{}) && data.WithdrawalCredentials != creds {
+			return fmt.Errorf("invalid `withdrawal_credentials` %v at pos %v (expected validator credentials %v)", data.WithdrawalCredentials, i, creds)
+		}
+
+		if (data.Version != beaconcommon.Version{}) && data.Version != expectedVersion {
+			return fmt.Errorf("invalid `fork_version` %v at pos %v (expected %v)", data.Version, i, expectedVersion)
+		}
+
+		if data.Amount < MinDepositAmount {
+			return fmt.Errorf("invalid `amount` %v at pos %v (minimum %v)", data.Amount, i, MinDepositAmount)
+		}
+
+		balance += data.Amount
+		if maxBalance := MaxEffectiveBalanceOf(creds); balance > maxBalance {
+			return fmt.Errorf("invalid `amount` %v at pos %v: validator balance %v would exceed maximum effective balance %v", data.Amount, i, balance, maxBalance)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+//go:build !integration
+
+package staking
+
+import (
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	ethcl "github.com/kilnfi/go-utils/ethereum/consensus"
+	"github.com/kilnfi/go-utils/ethereum/consensus/types"
+	beaconcommon "github.com/protolambda/zrnt/eth2/beacon/common"
+	beaconphase0 "github.com/protolambda/zrnt/eth2/beacon/phase0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateWithdrawalCredentials(t *testing.T) {
+	addr := gethcommon.HexToAddress("0x7e654d251da770a068413677967f6d3ea2fea9e4")
+	eth1Creds, err := NewWithdrawalCredentials(ETH1AddressWithdrawalPrefix, addr)
+	require.NoError(t, err)
+	compoundingCreds, err := NewWithdrawalCredentials(CompoundingWithdrawalPrefix, addr)
+	require.NoError(t, err)
+	invalidCreds := compoundingCreds
+	invalidCreds[5] = 0x01
+
+	tests := []struct {
+		desc    string
+		creds   beaconcommon.Root
+		version beaconcommon.Version
+		epoch   ethcl.Epoch
+		valid   bool
+	}{
+		{desc: "bls", creds: beaconcommon.Root{0x00, 0x01}, version: ethcl.MainnetForkVersion, valid: true},
+		{desc: "eth1 address", creds: eth1Creds, version: ethcl.PraterForkVersion, valid: true},
+		{desc: "compounding after electra", creds: compoundingCreds, version: ethcl.HoodiForkVersion, epoch: 2048, valid: true},
+		{desc: "compounding before electra", creds: compoundingCreds, version: ethcl.HoodiForkVersion, epoch: 2047},
+		{desc: "compounding on network without electra", creds: compoundingCreds, version: ethcl.PraterForkVersion, epoch: 1000000},
+		{desc: "non zero padding", creds: invalidCreds, version: ethcl.HoodiForkVersion, epoch: 2048},
+		{desc: "unknown prefix", creds: beaconcommon.Root{0x03}, version: ethcl.HoodiForkVersion, epoch: 2048},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := ValidateWithdrawalCredentials(tt.creds, tt.version, tt.epoch)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateDepositAmount(t *testing.T) {
+	eth1Creds := beaconcommon.Root{ETH1AddressWithdrawalPrefix}
+	compoundingCreds := beaconcommon.Root{CompoundingWithdrawalPrefix}
+
+	assert.NoError(t, ValidateDepositAmount(eth1Creds, MinDepositAmount))
+	assert.NoError(t, ValidateDepositAmount(eth1Creds, MaxEffectiveBalance))
+	assert.Error(t, ValidateDepositAmount(eth1Creds, MaxEffectiveBalance+1))
+	assert.Error(t, ValidateDepositAmount(eth1Creds, MinDepositAmount-1))
+
+	assert.NoError(t, ValidateDepositAmount(compoundingCreds, MinDepositAmount))
+	assert.NoError(t, ValidateDepositAmount(compoundingCreds, beaconcommon.Gwei(100000000000)))
+	assert.NoError(t, ValidateDepositAmount(compoundingCreds, MaxEffectiveBalanceElectra))
+	assert.Error(t, ValidateDepositAmount(compoundingCreds, MaxEffectiveBalanceElectra+1))
+	assert.Error(t, ValidateDepositAmount(compoundingCreds, 0))
+}
+
+func TestValidateDepositDataAt(t *testing.T) {
+	vkeys, err := GenerateValidatorKeysFrom(testMnemonic, "", 0, 2, false, nil)
+	require.NoError(t, err)
+
+	creds, err := NewWithdrawalCredentials(CompoundingWithdrawalPrefix, gethcommon.HexToAddress("0x7e654d251da770a068413677967f6d3ea2fea9e4"))
+	require.NoError(t, err)
+
+	datas, err := GenerateDepositData(vkeys[:1], creds, MinDepositAmount, ethcl.HoodiForkVersion)
+	require.NoError(t, err)
+	maxDatas, err := GenerateDepositData(vkeys[1:], creds, MaxEffectiveBalanceElectra, ethcl.HoodiForkVersion)
+	require.NoError(t, err)
+	datas = append(datas, maxDatas...)
+
+	require.NoError(t, ValidateDepositDataAt(creds, ethcl.HoodiForkVersion, 2048, datas...))
+	assert.Error(t, ValidateDepositDataAt(creds, ethcl.HoodiForkVersion, 0, datas...), "before Electra")
+	assert.Error(t, ValidateDepositDataAt(creds, ethcl.HoleskyForkVersion, 2048, datas...), "other network")
+
+	_, err = GenerateDepositData(vkeys, creds, MaxEffectiveBalanceElectra+1, ethcl.HoodiForkVersion)
+	assert.Error(t, err)
+
+	eth1Creds := creds
+	eth1Creds[0] = ETH1AddressWithdrawalPrefix
+	_, err = GenerateDepositData(vkeys, eth1Creds, beaconcommon.Gwei(64000000000), ethcl.HoodiForkVersion)
+	assert.Error(t, err)
+
+	tampered := *datas[1]
+	tampered.Amount = beaconcommon.Gwei(64000000000)
+	assert.Error(t, ValidateDepositDataAt(creds, ethcl.HoodiForkVersion, 2048, &tampered), "invalid signature")
+}
+
+func TestValidateTopUpDepositData(t *testing.T) {
+	vkeys, err := GenerateValidatorKeysFrom(testMnemonic, "", 0, 2, false, nil)
+	require.NoError(t, err)
+
+	creds, err := NewWithdrawalCredentials(CompoundingWithdrawalPrefix, gethcommon.HexToAddress("0x7e654d251da770a068413677967f6d3ea2fea9e4"))
+	require.NoError(t, err)
+
+	datas, err := GenerateDepositData(vkeys, creds, beaconcommon.Gwei(1000000000000), ethcl.HoodiForkVersion)
+	require.NoError(t, err)
+
+	validator := &types.Validator{
+		Index:   12,
+		Balance: beaconcommon.Gwei(32000000000),
+		Validator: &beaconphase0.Validator{
+			Pubkey:                datas[0].Pubkey,
+			WithdrawalCredentials: creds,
+			ExitEpoch:             beaconcommon.FAR_FUTURE_EPOCH,
+		},
+	}
+
+	require.NoError(t, ValidateTopUpDepositData(validator, ethcl.HoodiForkVersion, datas[0]))
+
+	// top-ups without credentials nor signature (e.g. sent by a contract) are valid
+	topUp := &DepositData{DepositData: beaconcommon.DepositData{Pubkey: datas[0].Pubkey, Amount: MinDepositAmount}}
+	require.NoError(t, ValidateTopUpDepositData(validator, ethcl.HoodiForkVersion, topUp))
+
+	assert.Error(t, ValidateTopUpDepositData(validator, ethcl.HoodiForkVersion, datas[0], datas[0], datas[0]), "exceeds maximum effective balance")
+	assert.Error(t, ValidateTopUpDepositData(validator, ethcl.HoodiForkVersion, datas[1]), "other validator")
+	assert.Error(t, ValidateTopUpDepositData(validator, ethcl.HoleskyForkVersion, datas[0]), "other network")
+
+	topUp.Amount = MinDepositAmount - 1
+	assert.Error(t, ValidateTopUpDepositData(validator, ethcl.HoodiForkVersion, topUp), "below minimum")
+
+	eth1Validator := *validator
+	eth1Validator.Validator = new(beaconphase0.Validator)
+	*eth1Validator.Validator = *validator.Validator
+	eth1Validator.Validator.WithdrawalCredentials[0] = ETH1AddressWithdrawalPrefix
+	assert.Error(t, ValidateTopUpDepositData(&eth1Validator, ethcl.HoodiForkVersion, datas[0]), "credentials mismatch")
+
+	eth1Validator.Balance = beaconcommon.Gwei(31000000000)
+	topUp.Amount = MinDepositAmount
+	require.NoError(t, ValidateTopUpDepositData(&eth1Validator, ethcl.HoodiForkVersion, topUp))
+	assert.Error(t, ValidateTopUpDepositData(&eth1Validator, ethcl.HoodiForkVersion, topUp, topUp), "exceeds 32 ETH")
+
+	validator.Validator.ExitEpoch = 10000
+	assert.Error(t, ValidateTopUpDepositData(validator, ethcl.HoodiForkVersion, topUp), "exiting")
+}
@@ -24,6 +24,10 @@ func (mngr *KeystoreManager) GenerateValidatorKeys(mnemonic string, count int, s
 	return GenerateValidatorKeys(mnemonic, "", count, storeMnemo, cb)
 }
 
+func (mngr *KeystoreManager) GenerateValidatorKeysFrom(mnemonic string, startIndex, count int, storeMnemo bool, cb func(string) error) (keys []*ValidatorKey, err error) {
+	return GenerateValidatorKeysFrom(mnemonic, "", startIndex, count, storeMnemo, cb)
+}
+
 func (mngr *KeystoreManager) EncryptToScryptKeystore(vKey *ValidatorKey, pwd string) (map[string]interface{}, error) {
 	cryptoKs, err := mngr.scryptEncryptor.Encrypt(vKey.PrivKey.Marshal(), pwd)
 	if err != nil {
@@ -0,0 +1,228 @@
+package staking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// KeystoreFilePattern matches EIP-2335 keystore files generated by staking-deposit-cli
+// (e.g. keystore-m_12381_3600_0_0_0-1700000000.json)
+const KeystoreFilePattern = "keystore-m_12381_3600_*.json"
+
+var (
+	// keystoreFilePathRegexp extracts the EIP-2334 path from the name of a keystore file
+	keystoreFilePathRegexp = regexp.MustCompile(`^keystore-(m_12381_3600_\d+_0_0)(?:-\d+)?\.json$`)
+
+	// signingKeyPathRegexp matches EIP-2334 paths of validator signing keys (m/12381/3600/i/0 is the withdrawal key)
+	signingKeyPathRegexp = regexp.MustCompile(`^m/12381/3600/\d+/0/0$`)
+)
+
+// KeystoreDirConfig configures the loading of a directory of keystore files
+type KeystoreDirConfig struct {
+	// Dir is the directory holding keystore files (e.g. validator_keys generated by staking-deposit-cli)
+	Dir string `json:"dir"`
+
+	// Pattern matches names of the keystore files of Dir
+	Pattern string `json:"pattern"`
+
+	// Password decrypts all keystores (ignored if PasswordFile or PasswordDir is set)
+	Password string `json:"-"`
+
+	// PasswordFile holds the password decrypting all keystores
+	PasswordFile string `json:"passwordFile,omitempty"`
+
+	// PasswordDir holds a password file per keystore named after the keystore file with a .txt extension
+	// (e.g. keystore-m_12381_3600_0_0_0-1700000000.txt), as expected by Teku and Lighthouse
+	PasswordDir string `json:"passwordDir,omitempty"`
+
+	// Workers is the maximum number of keystores decrypted in parallel
+	Workers int `json:"workers"`
+}
+
+func (cfg *KeystoreDirConfig) SetDefault() *KeystoreDirConfig {
+	if cfg.Pattern == "" {
+		cfg.Pattern = KeystoreFilePattern
+	}
+
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+
+	return cfg
+}
+
+// KeystoreFile is a keystore file loaded from a directory
+type KeystoreFile struct {
+	Path string
+	Key  *ValidatorKey
+
+	// Err is the error that occurred loading the file, in which case Key is nil
+	Err error
+}
+
+// LoadKeystoreDir decrypts all keystore files of a directory
+//
+// Keystores are decrypted in parallel by at most cfg.Workers workers and validated by LoadKeystoreFile.
+//
+// Files are returned ordered by name. Errors loading a file are reported in KeystoreFile.Err,
+// an error is returned only if the directory or the password file can not be read.
+func (mngr *KeystoreManager) LoadKeystoreDir(ctx context.Context, cfg *KeystoreDirConfig) ([]*KeystoreFile, error) {
+	if _, err := os.Stat(cfg.Dir); err != nil {
+		return nil, err
+	}
+
+	pattern := cfg.Pattern
+	if pattern == "" {
+		pattern = KeystoreFilePattern
+	}
+
+	paths, err := filepath.Glob(filepath.Join(cfg.Dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	password := func(string) (string, error) { return cfg.Password, nil }
+	switch {
+	case cfg.PasswordDir != "":
+		password = func(pth string) (string, error) {
+			return readPasswordFile(filepath.Join(cfg.PasswordDir, strings.TrimSuffix(filepath.Base(pth), ".json")+".txt"))
+		}
+	case cfg.PasswordFile != "":
+		pwd, err := readPasswordFile(cfg.PasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		password = func(string) (string, error) { return pwd, nil }
+	}
+
+	return mngr.loadKeystoreFiles(ctx, paths, password, cfg.Workers), nil
+}
+
+// loadKeystoreFiles decrypts keystore files in parallel, with the password returned by password for each file
+func (mngr *KeystoreManager) loadKeystoreFiles(ctx context.Context, paths []string, password func(pth string) (string, error), workers int) []*KeystoreFile {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var (
+		wg    sync.WaitGroup
+		files = make([]*KeystoreFile, len(paths))
+		jobs  = make(chan int)
+	)
+	for w := 0; w < workers && w < len(paths); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				files[i] = &KeystoreFile{Path: paths[i]}
+				if err := ctx.Err(); err != nil {
+					files[i].Err = err
+					continue
+				}
+
+				pwd, err := password(paths[i])
+				if err != nil {
+					files[i].Err = err
+					continue
+				}
+
+				files[i].Key, files[i].Err = mngr.LoadKeystoreFile(paths[i], pwd)
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// report keys loaded more than once
+	loaded := make(map[string]string)
+	for _, file := range files {
+		if file.Err != nil {
+			continue
+		}
+		if pth, ok := loaded[file.Key.Pubkey]; ok {
+			file.Key, file.Err = nil, fmt.Errorf("duplicate of keystore %q", pth)
+			continue
+		}
+		loaded[file.Key.Pubkey] = file.Path
+	}
+
+	return files
+}
+
+// LoadKeystoreFile decrypts an EIP-2335 keystore file and validates it
+//
+// Its checksum must match the password and its pubkey must match the decrypted key. Its path, if set
+// (keys not derived from a mnemonic have none), must be an EIP-2334 signing key path matching the file name.
+func (mngr *KeystoreManager) LoadKeystoreFile(pth, pwd string) (*ValidatorKey, error) {
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	var ks map[string]interface{}
+	if err := json.Unmarshal(b, &ks); err != nil {
+		return nil, fmt.Errorf("invalid keystore: %w", err)
+	}
+
+	if version, ok := ks["version"].(float64); !ok || version != 4 {
+		return nil, fmt.Errorf("invalid keystore version %v (version 4 expected)", ks["version"])
+	}
+
+	vkey, err := mngr.DecryptFromKeystore(ks, pwd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore: %w", err)
+	}
+
+	pubkey, _ := ks["pubkey"].(string)
+	if !strings.EqualFold(strings.TrimPrefix(pubkey, "0x"), vkey.Pubkey) {
+		return nil, fmt.Errorf("keystore pubkey %q does not match decrypted key pubkey %q", pubkey, vkey.Pubkey)
+	}
+
+	if vkey.Path == "" {
+		return vkey, nil
+	}
+
+	if !signingKeyPathRegexp.MatchString(vkey.Path) {
+		return nil, fmt.Errorf("invalid keystore path %q (expected EIP-2334 signing key path m/12381/3600/i/0/0)", vkey.Path)
+	}
+
+	if match := keystoreFilePathRegexp.FindStringSubmatch(filepath.Base(pth)); match != nil {
+		if filePath := strings.ReplaceAll(match[1], "_", "/"); filePath != vkey.Path {
+			return nil, fmt.Errorf("keystore path %q does not match path %q of file name", vkey.Path, filePath)
+		}
+	}
+
+	return vkey, nil
+}
+
+// KeystoreFilesError returns an error joining errors of all files that failed to load
+func KeystoreFilesError(files []*KeystoreFile) error {
+	var errs []error
+	for _, file := range files {
+		if file.Err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", filepath.Base(file.Path), file.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func readPasswordFile(pth string) (string, error) {
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %w", err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,150 @@
+//go:build !integration
+
+package staking
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKeystoreFile(t *testing.T, dir, name string, ks map[string]interface{}) string {
+	b, err := json.Marshal(ks)
+	require.NoError(t, err)
+
+	pth := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(pth, b, 0o600))
+
+	return pth
+}
+
+func keystoreFileName(i int) string {
+	return fmt.Sprintf("keystore-m_12381_3600_%d_0_0-1700000000.json", i)
+}
+
+func TestLoadKeystoreDir(t *testing.T) {
+	password := "test2022"
+	mngr := NewKeystoreManager()
+
+	vkeys, err := mngr.GenerateValidatorKeys(testMnemonic, 5, false, nil)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	for i, vkey := range vkeys {
+		ks, err := mngr.EncryptToPbkdf2Keystore(vkey, password)
+		require.NoError(t, err)
+
+		switch i {
+		case 1:
+			ks["pubkey"] = vkeys[0].Pubkey
+		case 2:
+			ks["path"] = "m/12381/3600/0/0/0"
+		case 3:
+			ks["path"] = "m/12381/60/3/0"
+		}
+
+		writeKeystoreFile(t, dir, keystoreFileName(i), ks)
+	}
+	ks, err := mngr.EncryptToPbkdf2Keystore(vkeys[0], password)
+	require.NoError(t, err)
+	writeKeystoreFile(t, dir, "keystore-m_12381_3600_0_0_0-1700000001.json", ks) // duplicate
+	writeKeystoreFile(t, dir, "deposit_data-1700000000.json", map[string]interface{}{})
+
+	pwdFile := filepath.Join(t.TempDir(), "password.txt")
+	require.NoError(t, os.WriteFile(pwdFile, []byte(password+"\n"), 0o600))
+
+	files, err := mngr.LoadKeystoreDir(t.Context(), (&KeystoreDirConfig{Dir: dir, PasswordFile: pwdFile, Workers: 2}).SetDefault())
+	require.NoError(t, err)
+	require.Len(t, files, 6)
+
+	assert.Equal(t, filepath.Join(dir, keystoreFileName(0)), files[0].Path)
+	require.NoError(t, files[0].Err)
+	assert.Equal(t, vkeys[0].Pubkey, files[0].Key.Pubkey)
+	assert.Equal(t, "m/12381/3600/0/0/0", files[0].Key.Path)
+
+	assert.Equal(t, filepath.Join(dir, "keystore-m_12381_3600_0_0_0-1700000001.json"), files[1].Path)
+	assert.ErrorContains(t, files[1].Err, "duplicate")
+
+	assert.Equal(t, filepath.Join(dir, keystoreFileName(1)), files[2].Path)
+	assert.ErrorContains(t, files[2].Err, "pubkey")
+
+	assert.Equal(t, filepath.Join(dir, keystoreFileName(2)), files[3].Path)
+	assert.ErrorContains(t, files[3].Err, "file name")
+
+	assert.Equal(t, filepath.Join(dir, keystoreFileName(3)), files[4].Path)
+	assert.ErrorContains(t, files[4].Err, "EIP-2334")
+
+	assert.Equal(t, filepath.Join(dir, keystoreFileName(4)), files[5].Path)
+	require.NoError(t, files[5].Err)
+	assert.Equal(t, vkeys[4].Pubkey, files[5].Key.Pubkey)
+
+	assert.Error(t, KeystoreFilesError(files))
+	assert.NoError(t, KeystoreFilesError(files[5:]))
+
+	_, err = mngr.LoadKeystoreDir(t.Context(), &KeystoreDirConfig{Dir: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+
+	_, err = mngr.LoadKeystoreDir(t.Context(), &KeystoreDirConfig{Dir: dir, PasswordFile: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
+
+func TestLoadKeystoreDirWithdrawalKey(t *testing.T) {
+	password := "test2022"
+	mngr := NewKeystoreManager()
+
+	vkeys, err := mngr.GenerateValidatorKeys(testMnemonic, 1, false, nil)
+	require.NoError(t, err)
+
+	// EIP-2334 withdrawal key path
+	ks, err := mngr.EncryptToPbkdf2Keystore(vkeys[0], password)
+	require.NoError(t, err)
+	ks["path"] = "m/12381/3600/0/0"
+
+	dir := t.TempDir()
+	writeKeystoreFile(t, dir, "keystore-m_12381_3600_0_0-1700000000.json", ks)
+
+	pwdFile := filepath.Join(t.TempDir(), "password.txt")
+	require.NoError(t, os.WriteFile(pwdFile, []byte(password), 0o600))
+
+	files, err := mngr.LoadKeystoreDir(t.Context(), (&KeystoreDirConfig{Dir: dir, PasswordFile: pwdFile}).SetDefault())
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.ErrorContains(t, files[0].Err, "EIP-2334 signing key path")
+}
+
+func TestLoadKeystoreDirPerKeyPasswords(t *testing.T) {
+	mngr := NewKeystoreManager()
+
+	vkeys, err := mngr.GenerateValidatorKeys(testMnemonic, 3, false, nil)
+	require.NoError(t, err)
+
+	dir, pwdDir := t.TempDir(), t.TempDir()
+	for i, vkey := range vkeys {
+		pwd := fmt.Sprintf("password-%d", i)
+		ks, err := mngr.EncryptToPbkdf2Keystore(vkey, pwd)
+		require.NoError(t, err)
+		writeKeystoreFile(t, dir, keystoreFileName(i), ks)
+
+		switch i {
+		case 1:
+			pwd = "invalid"
+		case 2:
+			continue
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(pwdDir, fmt.Sprintf("keystore-m_12381_3600_%d_0_0-1700000000.txt", i)), []byte(pwd), 0o600))
+	}
+
+	files, err := mngr.LoadKeystoreDir(t.Context(), (&KeystoreDirConfig{Dir: dir, PasswordDir: pwdDir}).SetDefault())
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	require.NoError(t, files[0].Err)
+	assert.Equal(t, vkeys[0].Pubkey, files[0].Key.Pubkey)
+	assert.ErrorContains(t, files[1].Err, "checksum")
+	assert.ErrorContains(t, files[2].Err, "password file")
+}
@@ -0,0 +1,199 @@
+package staking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// lighthouseDefinitionsFile is the file of a Lighthouse validators directory listing its validators
+const lighthouseDefinitionsFile = "validator_definitions.yml"
+
+// lighthouseLocalKeystore is the type of Lighthouse validators signing with a local keystore
+const lighthouseLocalKeystore = "local_keystore"
+
+// lighthouseValidatorDefinition is an entry of Lighthouse validator_definitions.yml
+//
+// Only fields of validators signing with a local keystore are decoded.
+type lighthouseValidatorDefinition struct {
+	Enabled                    bool   `yaml:"enabled"`
+	VotingPublicKey            string `yaml:"voting_public_key"`
+	Description                string `yaml:"description"`
+	Type                       string `yaml:"type"`
+	VotingKeystorePath         string `yaml:"voting_keystore_path"`
+	VotingKeystorePasswordPath string `yaml:"voting_keystore_password_path,omitempty"`
+	VotingKeystorePassword     string `yaml:"voting_keystore_password,omitempty"`
+}
+
+// ImportLighthouseValidators decrypts keys of the enabled validators of a Lighthouse validators directory
+//
+// Validators are read from validator_definitions.yml. Relative keystore and password paths are
+// resolved from validatorsDir. Keystores are decrypted in parallel.
+func (mngr *KeystoreManager) ImportLighthouseValidators(ctx context.Context, validatorsDir string) ([]*ValidatorKey, error) {
+	defs, err := readLighthouseDefinitions(validatorsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		paths     []string
+		pubkeys   []string
+		passwords = make(map[string]lighthouseValidatorDefinition)
+	)
+	for _, def := range defs {
+		if !def.Enabled || def.Type != lighthouseLocalKeystore {
+			continue
+		}
+
+		pth := resolvePath(validatorsDir, def.VotingKeystorePath)
+		paths = append(paths, pth)
+		pubkeys = append(pubkeys, strings.TrimPrefix(strings.ToLower(def.VotingPublicKey), "0x"))
+		passwords[pth] = def
+	}
+
+	files := mngr.loadKeystoreFiles(ctx, paths, func(pth string) (string, error) {
+		def := passwords[pth]
+		if def.VotingKeystorePasswordPath == "" {
+			return def.VotingKeystorePassword, nil
+		}
+		return readPasswordFile(resolvePath(validatorsDir, def.VotingKeystorePasswordPath))
+	}, runtime.NumCPU())
+
+	for i, file := range files {
+		if file.Err == nil && file.Key.Pubkey != pubkeys[i] {
+			file.Key, file.Err = nil, fmt.Errorf("keystore pubkey %q does not match voting_public_key 0x%v", file.Key.Pubkey, pubkeys[i])
+		}
+	}
+
+	return keystoreFilesKeys(files)
+}
+
+// ExportLighthouseValidators writes keys in Lighthouse validators and secrets directories
+//
+// Each key is encrypted with pwd in validatorsDir/<pubkey>/voting-keystore.json and its password
+// written in secretsDir/<pubkey>. Validators are appended to validator_definitions.yml,
+// existing entries are kept untouched (including fields not decoded by lighthouseValidatorDefinition).
+func (mngr *KeystoreManager) ExportLighthouseValidators(validatorsDir, secretsDir string, vkeys []*ValidatorKey, pwd string) error {
+	entries, err := readLighthouseDefinitionsNode(validatorsDir)
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[string]bool)
+	for _, entry := range entries.Content {
+		var def lighthouseValidatorDefinition
+		if err := entry.Decode(&def); err != nil {
+			return fmt.Errorf("invalid %v: %w", lighthouseDefinitionsFile, err)
+		}
+		exists[strings.ToLower(def.VotingPublicKey)] = true
+	}
+
+	// Lighthouse expects absolute paths
+	if validatorsDir, err = filepath.Abs(validatorsDir); err != nil {
+		return err
+	}
+	if secretsDir, err = filepath.Abs(secretsDir); err != nil {
+		return err
+	}
+
+	for _, vkey := range vkeys {
+		pubkey := "0x" + vkey.Pubkey
+		if exists[pubkey] {
+			return fmt.Errorf("validator %v is already defined", pubkey)
+		}
+		exists[pubkey] = true
+
+		ks, err := mngr.encryptToExportKeystore(vkey, pwd)
+		if err != nil {
+			return err
+		}
+
+		def := lighthouseValidatorDefinition{
+			Enabled:                    true,
+			VotingPublicKey:            pubkey,
+			Description:                vkey.Desc,
+			Type:                       lighthouseLocalKeystore,
+			VotingKeystorePath:         filepath.Join(validatorsDir, pubkey, "voting-keystore.json"),
+			VotingKeystorePasswordPath: filepath.Join(secretsDir, pubkey),
+		}
+		if err := writeJSONFile(def.VotingKeystorePath, ks); err != nil {
+			return err
+		}
+		if err := writeSecretFile(def.VotingKeystorePasswordPath, []byte(pwd)); err != nil {
+			return err
+		}
+
+		entry := new(yaml.Node)
+		if err := entry.Encode(def); err != nil {
+			return err
+		}
+		entries.Content = append(entries.Content, entry)
+	}
+
+	b, err := yaml.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return writeSecretFile(filepath.Join(validatorsDir, lighthouseDefinitionsFile), append([]byte("---\n"), b...))
+}
+
+func readLighthouseDefinitions(validatorsDir string) ([]lighthouseValidatorDefinition, error) {
+	b, err := os.ReadFile(filepath.Join(validatorsDir, lighthouseDefinitionsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []lighthouseValidatorDefinition
+	if err := yaml.Unmarshal(b, &defs); err != nil {
+		return nil, fmt.Errorf("invalid %v: %w", lighthouseDefinitionsFile, err)
+	}
+
+	return defs, nil
+}
+
+// readLighthouseDefinitionsNode returns the sequence of entries of validator_definitions.yml as a YAML node,
+// so entries can be written back without losing fields (an empty sequence if the file does not exist)
+func readLighthouseDefinitionsNode(validatorsDir string) (*yaml.Node, error) {
+	entries := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+	b, err := os.ReadFile(filepath.Join(validatorsDir, lighthouseDefinitionsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("invalid %v: %w", lighthouseDefinitionsFile, err)
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return entries, nil
+	}
+
+	if doc.Content[0].Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("invalid %v: expected a list of validators", lighthouseDefinitionsFile)
+	}
+
+	entries = doc.Content[0]
+	// an empty list may be written in flow style ([]), appended entries are written in block style
+	entries.Style &^= yaml.FlowStyle
+
+	return entries, nil
+}
+
+func resolvePath(dir, pth string) string {
+	if filepath.IsAbs(pth) {
+		return pth
+	}
+	return filepath.Join(dir, pth)
+}
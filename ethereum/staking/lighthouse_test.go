@@ -0,0 +1,134 @@
+//go:build !integration
+
+package staking
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLighthouseValidators(t *testing.T) {
+	mngr := NewKeystoreManager()
+
+	vkeys, err := mngr.GenerateValidatorKeys(testMnemonic, 2, false, nil)
+	require.NoError(t, err)
+
+	validatorsDir, secretsDir := t.TempDir(), t.TempDir()
+	require.NoError(t, mngr.ExportLighthouseValidators(validatorsDir, secretsDir, vkeys[:1], "test2022"))
+	require.NoError(t, mngr.ExportLighthouseValidators(validatorsDir, secretsDir, vkeys[1:], "test2022"))
+	assert.Error(t, mngr.ExportLighthouseValidators(validatorsDir, secretsDir, vkeys[1:], "test2022"), "validator already defined")
+
+	imported, err := mngr.ImportLighthouseValidators(t.Context(), validatorsDir)
+	require.NoError(t, err)
+	assertSameKeys(t, vkeys, imported)
+	assert.Equal(t, "m/12381/3600/1/0/0", imported[1].Path)
+
+	require.NoError(t, os.WriteFile(filepath.Join(secretsDir, "0x"+vkeys[1].Pubkey), []byte("invalid"), 0o600))
+	_, err = mngr.ImportLighthouseValidators(t.Context(), validatorsDir)
+	assert.ErrorContains(t, err, "checksum")
+}
+
+func TestImportLighthouseValidatorDefinitions(t *testing.T) {
+	mngr := NewKeystoreManager()
+
+	vkeys, err := mngr.GenerateValidatorKeys(testMnemonic, 2, false, nil)
+	require.NoError(t, err)
+
+	validatorsDir := t.TempDir()
+	for _, vkey := range vkeys {
+		ks, err := mngr.EncryptToPbkdf2Keystore(vkey, "test2022")
+		require.NoError(t, err)
+		require.NoError(t, writeJSONFile(filepath.Join(validatorsDir, "0x"+vkey.Pubkey, "voting-keystore.json"), ks))
+	}
+
+	defs := fmt.Sprintf(`---
+- enabled: true
+  voting_public_key: "0x%[1]v"
+  description: ""
+  type: local_keystore
+  voting_keystore_path: 0x%[1]v/voting-keystore.json
+  voting_keystore_password: test2022
+  suggested_fee_recipient: "0x0000000000000000000000000000000000000001"
+- enabled: false
+  voting_public_key: "0x%[2]v"
+  description: ""
+  type: local_keystore
+  voting_keystore_path: 0x%[2]v/voting-keystore.json
+  voting_keystore_password: test2022
+- enabled: true
+  voting_public_key: "0x%[2]v"
+  description: ""
+  type: web3signer
+  url: "http://signer:9000"
+`, vkeys[0].Pubkey, vkeys[1].Pubkey)
+	require.NoError(t, os.WriteFile(filepath.Join(validatorsDir, lighthouseDefinitionsFile), []byte(defs), 0o600))
+
+	imported, err := mngr.ImportLighthouseValidators(t.Context(), validatorsDir)
+	require.NoError(t, err)
+	assertSameKeys(t, vkeys[:1], imported)
+
+	// voting_public_key must match the keystore
+	defs = fmt.Sprintf(`---
+- enabled: true
+  voting_public_key: "0x%v"
+  type: local_keystore
+  voting_keystore_path: 0x%v/voting-keystore.json
+  voting_keystore_password: test2022
+`, vkeys[1].Pubkey, vkeys[0].Pubkey)
+	require.NoError(t, os.WriteFile(filepath.Join(validatorsDir, lighthouseDefinitionsFile), []byte(defs), 0o600))
+
+	_, err = mngr.ImportLighthouseValidators(t.Context(), validatorsDir)
+	assert.ErrorContains(t, err, "voting_public_key")
+}
+
+func TestExportLighthouseValidatorsKeepsDefinitions(t *testing.T) {
+	mngr := NewKeystoreManager()
+
+	vkeys, err := mngr.GenerateValidatorKeys(testMnemonic, 2, false, nil)
+	require.NoError(t, err)
+
+	validatorsDir, secretsDir := t.TempDir(), t.TempDir()
+	require.NoError(t, mngr.ExportLighthouseValidators(validatorsDir, secretsDir, vkeys[:1], "test2022"))
+
+	b, err := os.ReadFile(filepath.Join(validatorsDir, lighthouseDefinitionsFile))
+	require.NoError(t, err)
+
+	// add a fee recipient to the exported validator and a remote signer validator
+	defs := strings.Replace(string(b), "  type: local_keystore\n", "  type: local_keystore\n  suggested_fee_recipient: \"0x0000000000000000000000000000000000000001\"\n  gas_limit: 36000000\n", 1) +
+		`- enabled: true
+  voting_public_key: "0x93247f2209abcacf57b75a51dafae777f9dd38bc7053d1af526f220a7489a6d3a2753e5f3e8b1cfe39b56f43611df74a"
+  description: remote
+  type: web3signer
+  url: "https://signer:9000"
+  root_certificate_path: /certs/ca.pem
+  request_timeout_ms: 12000
+`
+	require.NoError(t, os.WriteFile(filepath.Join(validatorsDir, lighthouseDefinitionsFile), []byte(defs), 0o600))
+
+	require.NoError(t, mngr.ExportLighthouseValidators(validatorsDir, secretsDir, vkeys[1:], "test2022"))
+
+	b, err = os.ReadFile(filepath.Join(validatorsDir, lighthouseDefinitionsFile))
+	require.NoError(t, err)
+
+	var entries []map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(b, &entries))
+	require.Len(t, entries, 3)
+	assert.Equal(t, "0x0000000000000000000000000000000000000001", entries[0]["suggested_fee_recipient"])
+	assert.Equal(t, 36000000, entries[0]["gas_limit"])
+	assert.Equal(t, "web3signer", entries[1]["type"])
+	assert.Equal(t, "https://signer:9000", entries[1]["url"])
+	assert.Equal(t, "/certs/ca.pem", entries[1]["root_certificate_path"])
+	assert.Equal(t, 12000, entries[1]["request_timeout_ms"])
+	assert.Equal(t, "0x"+vkeys[1].Pubkey, entries[2]["voting_public_key"])
+
+	imported, err := mngr.ImportLighthouseValidators(t.Context(), validatorsDir)
+	require.NoError(t, err)
+	assertSameKeys(t, vkeys, imported)
+}
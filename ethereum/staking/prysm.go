@@ -0,0 +1,113 @@
+package staking
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// prysmKeystorePath is the path, in a Prysm wallet directory, of the keystore holding all imported accounts
+var prysmKeystorePath = filepath.Join("direct", "accounts", "all-accounts.keystore.json")
+
+// DecryptFromPrysmKeystore decrypts the keys of a Prysm keystore written by EncryptToPrysmKeystore
+// or by Prysm in all-accounts.keystore.json
+func (mngr *KeystoreManager) DecryptFromPrysmKeystore(ks map[string]interface{}, pwd string) ([]*ValidatorKey, error) {
+	cryptoKs, ok := ks["crypto"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid keystore missing \"crypto\" field")
+	}
+
+	encodedStore, err := mngr.pbkdf2Encryptor.Decrypt(cryptoKs, pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	accStore := new(accountsStore)
+	if err := json.Unmarshal(encodedStore, accStore); err != nil {
+		return nil, fmt.Errorf("invalid Prysm accounts store: %w", err)
+	}
+	if len(accStore.PrivateKeys) != len(accStore.PublicKeys) {
+		return nil, fmt.Errorf("invalid Prysm accounts store: %v private keys for %v public keys", len(accStore.PrivateKeys), len(accStore.PublicKeys))
+	}
+
+	vkeys := make([]*ValidatorKey, len(accStore.PrivateKeys))
+	for i, privKey := range accStore.PrivateKeys {
+		if vkeys[i], err = ValidatorKeyFromBytes(privKey); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(vkeys[i].PrivKey.PublicKey().Marshal(), accStore.PublicKeys[i]) {
+			return nil, fmt.Errorf("invalid Prysm accounts store: public key #%v does not match its private key", i)
+		}
+	}
+
+	return vkeys, nil
+}
+
+// ImportPrysmWallet decrypts the accounts imported in a Prysm wallet with the wallet password
+func (mngr *KeystoreManager) ImportPrysmWallet(walletDir, pwd string) ([]*ValidatorKey, error) {
+	b, err := os.ReadFile(filepath.Join(walletDir, prysmKeystorePath))
+	if err != nil {
+		return nil, err
+	}
+
+	var ks map[string]interface{}
+	if err := json.Unmarshal(b, &ks); err != nil {
+		return nil, fmt.Errorf("invalid Prysm keystore: %w", err)
+	}
+
+	return mngr.DecryptFromPrysmKeystore(ks, pwd)
+}
+
+// ExportPrysmWallet writes keys in a Prysm wallet encrypted with the wallet password
+//
+// If the wallet already holds accounts, they are decrypted with pwd and kept: keys are added to them,
+// skipping keys already in the wallet.
+// The wallet can then be used with Prysm validator client --wallet-dir and --wallet-password-file flags.
+func (mngr *KeystoreManager) ExportPrysmWallet(walletDir string, vkeys []*ValidatorKey, pwd string) error {
+	existing, err := mngr.ImportPrysmWallet(walletDir, pwd)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read existing Prysm wallet: %w", err)
+	}
+
+	pubkeys := make(map[string]bool, len(existing))
+	for _, vkey := range existing {
+		pubkeys[vkey.Pubkey] = true
+	}
+
+	merged := existing
+	for _, vkey := range vkeys {
+		if !pubkeys[vkey.Pubkey] {
+			pubkeys[vkey.Pubkey] = true
+			merged = append(merged, vkey)
+		}
+	}
+
+	ks, err := mngr.EncryptToPrysmKeystore(merged, pwd)
+	if err != nil {
+		return err
+	}
+
+	return writeJSONFile(filepath.Join(walletDir, prysmKeystorePath), ks)
+}
+
+// writeJSONFile writes v in JSON at pth only readable by the current user, creating parent directories if needed
+func writeJSONFile(pth string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeSecretFile(pth, b)
+}
+
+// writeSecretFile writes b at pth only readable by the current user, creating parent directories if needed
+func writeSecretFile(pth string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(pth), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(pth, b, 0o600)
+}
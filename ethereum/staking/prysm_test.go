@@ -0,0 +1,60 @@
+//go:build !integration
+
+package staking
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "forest engage two brief ketchup gaze corn approve about lady uncle ball rhythm eternal alley box very evil tribe guard shoulder open venture curve"
+
+func assertSameKeys(t *testing.T, expected, actual []*ValidatorKey) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].Pubkey, actual[i].Pubkey)
+		assert.Equal(t, expected[i].PrivKey.Marshal(), actual[i].PrivKey.Marshal())
+	}
+}
+
+func TestPrysmWallet(t *testing.T) {
+	mngr := NewKeystoreManager()
+
+	vkeys, err := mngr.GenerateValidatorKeys(testMnemonic, 2, false, nil)
+	require.NoError(t, err)
+
+	walletDir := t.TempDir()
+	require.NoError(t, mngr.ExportPrysmWallet(walletDir, vkeys, "test2022"))
+
+	imported, err := mngr.ImportPrysmWallet(walletDir, "test2022")
+	require.NoError(t, err)
+	assertSameKeys(t, vkeys, imported)
+
+	_, err = mngr.ImportPrysmWallet(walletDir, "invalid")
+	assert.Error(t, err)
+
+	_, err = mngr.ImportPrysmWallet(t.TempDir(), "test2022")
+	assert.Error(t, err)
+}
+
+func TestExportPrysmWalletKeepsAccounts(t *testing.T) {
+	mngr := NewKeystoreManager()
+
+	vkeys, err := mngr.GenerateValidatorKeys(testMnemonic, 3, false, nil)
+	require.NoError(t, err)
+
+	walletDir := t.TempDir()
+	require.NoError(t, mngr.ExportPrysmWallet(walletDir, vkeys[:2], "test2022"))
+
+	// wallet password must decrypt existing accounts
+	assert.Error(t, mngr.ExportPrysmWallet(walletDir, vkeys[2:], "invalid"))
+
+	// accounts already in the wallet are not duplicated
+	require.NoError(t, mngr.ExportPrysmWallet(walletDir, vkeys[1:], "test2022"))
+
+	imported, err := mngr.ImportPrysmWallet(walletDir, "test2022")
+	require.NoError(t, err)
+	assertSameKeys(t, vkeys, imported)
+}
@@ -0,0 +1,76 @@
+package staking
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// ImportTekuKeys decrypts keys of Teku --validator-keys=<keysDir>:<passwordsDir> layout
+//
+// keysDir holds EIP-2335 keystore files and passwordsDir holds a password file per keystore,
+// named after the keystore file with a .txt extension.
+func (mngr *KeystoreManager) ImportTekuKeys(ctx context.Context, keysDir, passwordsDir string) ([]*ValidatorKey, error) {
+	files, err := mngr.LoadKeystoreDir(ctx, (&KeystoreDirConfig{
+		Dir:         keysDir,
+		Pattern:     "*.json",
+		PasswordDir: passwordsDir,
+	}).SetDefault())
+	if err != nil {
+		return nil, err
+	}
+
+	return keystoreFilesKeys(files)
+}
+
+// ExportTekuKeys writes keys in Teku --validator-keys=<keysDir>:<passwordsDir> layout
+//
+// Each key is encrypted with pwd in an EIP-2335 keystore named after its pubkey.
+func (mngr *KeystoreManager) ExportTekuKeys(keysDir, passwordsDir string, vkeys []*ValidatorKey, pwd string) error {
+	for _, vkey := range vkeys {
+		ks, err := mngr.encryptToExportKeystore(vkey, pwd)
+		if err != nil {
+			return err
+		}
+
+		name := "0x" + vkey.Pubkey
+		if err := writeJSONFile(filepath.Join(keysDir, name+".json"), ks); err != nil {
+			return err
+		}
+		if err := writeSecretFile(filepath.Join(passwordsDir, name+".txt"), []byte(pwd)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encryptToExportKeystore encrypts vkey in an EIP-2335 keystore imported by validator clients
+func (mngr *KeystoreManager) encryptToExportKeystore(vkey *ValidatorKey, pwd string) (map[string]interface{}, error) {
+	ks, err := mngr.EncryptToScryptKeystore(vkey, pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	// keys decrypted from Prysm wallets have no UUID
+	if vkey.UUID == "" {
+		ks["uuid"] = uuid.New().String()
+	}
+
+	return ks, nil
+}
+
+// keystoreFilesKeys returns keys of files or an error if any file failed to load
+func keystoreFilesKeys(files []*KeystoreFile) ([]*ValidatorKey, error) {
+	if err := KeystoreFilesError(files); err != nil {
+		return nil, err
+	}
+
+	vkeys := make([]*ValidatorKey, len(files))
+	for i, file := range files {
+		vkeys[i] = file.Key
+	}
+
+	return vkeys, nil
+}
@@ -0,0 +1,41 @@
+//go:build !integration
+
+package staking
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTekuKeys(t *testing.T) {
+	mngr := NewKeystoreManager()
+
+	vkeys, err := mngr.GenerateValidatorKeys(testMnemonic, 1, false, nil)
+	require.NoError(t, err)
+
+	// keys of a Prysm wallet have neither path nor UUID
+	prysmKeys, err := mngr.DecryptFromPrysmKeystore(mustEncryptToPrysmKeystore(t, mngr, vkeys), "test2022")
+	require.NoError(t, err)
+
+	keysDir, passwordsDir := t.TempDir(), t.TempDir()
+	require.NoError(t, mngr.ExportTekuKeys(keysDir, passwordsDir, prysmKeys, "test2022"))
+
+	imported, err := mngr.ImportTekuKeys(t.Context(), keysDir, passwordsDir)
+	require.NoError(t, err)
+	assertSameKeys(t, vkeys, imported)
+	assert.NotEmpty(t, imported[0].UUID)
+
+	require.NoError(t, os.Remove(filepath.Join(passwordsDir, "0x"+vkeys[0].Pubkey+".txt")))
+	_, err = mngr.ImportTekuKeys(t.Context(), keysDir, passwordsDir)
+	assert.Error(t, err)
+}
+
+func mustEncryptToPrysmKeystore(t *testing.T, mngr *KeystoreManager, vkeys []*ValidatorKey) map[string]interface{} {
+	ks, err := mngr.EncryptToPrysmKeystore(vkeys, "test2022")
+	require.NoError(t, err)
+	return ks
+}
@@ -40,6 +40,16 @@ func GenerateValidatorKey(seed []byte, path, desc string) (*ValidatorKey, error)
 }
 
 func GenerateValidatorKeys(mnemonicPassphrase, mnemonicPassword string, count int, storeMnemo bool, cb func(string) error) (keys []*ValidatorKey, err error) {
+	return GenerateValidatorKeysFrom(mnemonicPassphrase, mnemonicPassword, 0, count, storeMnemo, cb)
+}
+
+// GenerateValidatorKeysFrom generates count validator keys starting at index startIndex
+// (as staking-deposit-cli existing-mnemonic --validator_start_index)
+func GenerateValidatorKeysFrom(mnemonicPassphrase, mnemonicPassword string, startIndex, count int, storeMnemo bool, cb func(string) error) (keys []*ValidatorKey, err error) {
+	if startIndex < 0 || count < 0 {
+		return nil, fmt.Errorf("invalid validator keys range (start index %v, count %v)", startIndex, count)
+	}
+
 	seed, err := Seed(mnemonicPassphrase, mnemonicPassword)
 	if err != nil {
 		return nil, err
@@ -49,7 +59,7 @@ func GenerateValidatorKeys(mnemonicPassphrase, mnemonicPassword string, count in
 	for i := 0; i < count; i++ {
 		keys[i], err = GenerateValidatorKey(
 			seed,
-			fmt.Sprintf("m/12381/3600/%d/0/0", i), // Set path as EIP-2334 format (c.f https://eips.ethereum.org/EIPS/eip-2334)
+			fmt.Sprintf("m/12381/3600/%d/0/0", startIndex+i), // Set path as EIP-2334 format (c.f https://eips.ethereum.org/EIPS/eip-2334)
 			"",
 		)
 
@@ -12,6 +12,14 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ErrEmptySecret is returned by Get when there is no secret at the given path
+var ErrEmptySecret = errors.New("empty secret")
+
+// ErrEmptyList is returned by List when there is no secret under the given path
+//
+// Vault answers LIST on an empty path with a 404 and no data.
+var ErrEmptyList = errors.New("empty response")
+
 type KVv2Client struct {
 	*api.Client
 
@@ -52,22 +60,30 @@ func (c *KVv2Client) SetLogger(logger logrus.FieldLogger) {
 }
 
 func (c *KVv2Client) Init(ctx context.Context) error {
-	err := c.validateAddress()
+	err := c.Authenticate(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = c.initAuth(ctx)
+	err = c.initKVv2(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = c.initKVv2(ctx)
+	return nil
+}
+
+// Authenticate validates Vault address and authenticates on Vault
+//
+// Contrary to Init, it does not check the configured path is a kv-v2 secret engine
+// so the client can be used for other secret engines.
+func (c *KVv2Client) Authenticate(ctx context.Context) error {
+	err := c.validateAddress()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return c.initAuth(ctx)
 }
 
 func (c *KVv2Client) validateAddress() (err error) {
@@ -237,7 +253,7 @@ func (c *KVv2Client) Get(ctx context.Context, pth, version string) (secret *api.
 	}
 
 	if secret == nil || secret.Data["data"] == nil {
-		return secret, nil, nil, fmt.Errorf("empty secret at path %q", pth)
+		return secret, nil, nil, fmt.Errorf("%w at path %q", ErrEmptySecret, pth)
 	}
 
 	var ok bool
@@ -263,12 +279,17 @@ func (c *KVv2Client) List(ctx context.Context, pth string) ([]string, error) {
 		return nil, err
 	}
 
-	return extractListData(secret)
+	keys, err := extractListData(secret)
+	if errors.Is(err, ErrEmptyList) {
+		return nil, fmt.Errorf("%w at path %q", ErrEmptyList, pth)
+	}
+
+	return keys, err
 }
 
 func extractListData(secret *api.Secret) ([]string, error) {
 	if secret == nil || secret.Data == nil {
-		return nil, errors.New("empty response")
+		return nil, ErrEmptyList
 	}
 
 	keys, ok := secret.Data["keys"]
@@ -289,8 +310,13 @@ func extractListData(secret *api.Secret) ([]string, error) {
 	return skeys, nil
 }
 
-func (c *KVv2Client) Delete(context.Context, string) error {
-	return errors.New("not implemented error")
+// Delete permanently deletes all versions and metadata of the secret at path id
+func (c *KVv2Client) Delete(ctx context.Context, id string) error {
+	_, err := c.Client.Logical().DeleteWithContext(
+		ctx,
+		c.metadaDataPath(id),
+	)
+	return err
 }
 
 func (c *KVv2Client) dataPath(id string) string {
@@ -4,6 +4,9 @@ package gethkeystore
 type Config struct {
 	Path     string `json:"path"`
 	Password string `json:"-"`
+
+	// Signer is the address of the default signer (optional)
+	Signer string `json:"signer,omitempty"`
 }
 
 func (cfg *Config) SetDefault() *Config {
@@ -10,12 +10,14 @@ import (
 func Flags(v *viper.Viper, f *pflag.FlagSet) {
 	KeystorePathFlag(v, f)
 	KeystorePasswordFlag(v, f)
+	KeystoreSignerFlag(v, f)
 }
 
 func ConfigFromViper(v *viper.Viper) *Config {
 	return &Config{
 		Path:     GetKeystorePath(v),
 		Password: GetKeystorePassword(v),
+		Signer:   GetKeystoreSigner(v),
 	}
 }
 
@@ -62,3 +64,25 @@ func KeystorePasswordFlag(v *viper.Viper, f *pflag.FlagSet) {
 func GetKeystorePassword(v *viper.Viper) string {
 	return v.GetString(keyStorePasswordViperKey)
 }
+
+const (
+	keyStoreSignerFlag     = "keystore-signer"
+	keyStoreSignerViperKey = "keystore.signer"
+	keyStoreSignerEnv      = "KEYSTORE_SIGNER"
+)
+
+// KeystoreSignerFlag register flag for the address of the default signer
+func KeystoreSignerFlag(v *viper.Viper, f *pflag.FlagSet) {
+	f.String(keyStoreSignerFlag, "", cmdutils.FlagDesc("Address of the default signer (defaults to the first account)", keyStoreSignerEnv))
+
+	if err := v.BindPFlag(keyStoreSignerViperKey, f.Lookup(keyStoreSignerFlag)); err != nil {
+		panic(err)
+	}
+	if err := v.BindEnv(keyStoreSignerViperKey, keyStoreSignerEnv); err != nil {
+		panic(err)
+	}
+}
+
+func GetKeystoreSigner(v *viper.Viper) string {
+	return v.GetString(keyStoreSignerViperKey)
+}
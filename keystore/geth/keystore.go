@@ -6,12 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync"
 
 	gethaccounts "github.com/ethereum/go-ethereum/accounts"
 	gethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/kilnfi/go-utils/keystore"
 )
 
@@ -20,6 +22,9 @@ var _ keystore.Store = &KeyStore{}
 type KeyStore struct {
 	cfg  *Config
 	keys *gethkeystore.KeyStore
+
+	// mu protects cfg.Password and cfg.Signer which can be changed at runtime
+	mu sync.RWMutex
 }
 
 func New(cfg *Config) *KeyStore {
@@ -30,15 +35,25 @@ func New(cfg *Config) *KeyStore {
 }
 
 func (s *KeyStore) CreateAccount(_ context.Context) (*keystore.Account, error) {
-	acc, err := s.keys.NewAccount(s.cfg.Password)
+	acc, err := s.keys.NewAccount(s.password())
 	if err != nil {
 		return nil, err
 	}
 
+	return newAccount(acc), nil
+}
+
+func newAccount(acc gethaccounts.Account) *keystore.Account {
 	return &keystore.Account{
 		Addr: acc.Address,
 		URL:  acc.URL,
-	}, nil
+	}
+}
+
+func (s *KeyStore) password() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Password
 }
 
 // Import a secp256k1 private key in hexadecimal format
@@ -48,37 +63,163 @@ func (s *KeyStore) Import(_ context.Context, hexkey string) (*keystore.Account,
 		return nil, err
 	}
 
-	acc, err := s.keys.ImportECDSA(priv, s.cfg.Password)
+	acc, err := s.keys.ImportECDSA(priv, s.password())
 	if err != nil {
 		return nil, err
 	}
 
-	return &keystore.Account{
-		Addr: acc.Address,
-		URL:  acc.URL,
-	}, nil
+	return newAccount(acc), nil
 }
 
 func (s *KeyStore) SignTx(_ context.Context, addr gethcommon.Address, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
 	if !s.keys.HasAddress(addr) {
 		return nil, fmt.Errorf("no key for address %q", addr.String())
 	}
+	if err := keystore.ValidateBlobTx(tx); err != nil {
+		return nil, err
+	}
 	return s.keys.SignTxWithPassphrase(
 		gethaccounts.Account{Address: addr},
-		s.cfg.Password,
+		s.password(),
 		tx,
 		chainID,
 	)
 }
 
+// SignAuthorization signs an EIP-7702 set-code authorization with the key of addr
+func (s *KeyStore) SignAuthorization(_ context.Context, addr gethcommon.Address, auth gethtypes.SetCodeAuthorization) (gethtypes.SetCodeAuthorization, error) {
+	sighash := auth.SigHash()
+	sig, err := s.signHash(addr, sighash[:])
+	if err != nil {
+		return gethtypes.SetCodeAuthorization{}, err
+	}
+
+	auth.R.SetBytes(sig[:32])
+	auth.S.SetBytes(sig[32:64])
+	auth.V = sig[64]
+
+	return auth, nil
+}
+
+// SignMessage signs msg with the key of addr as personal_sign (EIP-191)
+func (s *KeyStore) SignMessage(_ context.Context, addr gethcommon.Address, msg []byte) ([]byte, error) {
+	sig, err := s.signHash(addr, keystore.MessageHash(msg))
+	if err != nil {
+		return nil, err
+	}
+	return keystore.ToEthSignature(sig), nil
+}
+
+// SignTypedData signs typed data with the key of addr as eth_signTypedData_v4 (EIP-712)
+func (s *KeyStore) SignTypedData(_ context.Context, addr gethcommon.Address, data apitypes.TypedData) ([]byte, error) {
+	hash, err := keystore.TypedDataHash(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := s.signHash(addr, hash)
+	if err != nil {
+		return nil, err
+	}
+	return keystore.ToEthSignature(sig), nil
+}
+
+// signHash signs hash with the key of addr and returns a signature in the [R || S || V] format
+func (s *KeyStore) signHash(addr gethcommon.Address, hash []byte) ([]byte, error) {
+	if !s.keys.HasAddress(addr) {
+		return nil, fmt.Errorf("no key for address %q", addr.String())
+	}
+	return s.keys.SignHashWithPassphrase(gethaccounts.Account{Address: addr}, s.password(), hash)
+}
+
 func (s *KeyStore) HasAccount(_ context.Context, addr gethcommon.Address) (bool, error) {
 	return s.keys.HasAddress(addr), nil
 }
 
+// ListAccounts returns all accounts of the keystore ordered by key file
+func (s *KeyStore) ListAccounts(_ context.Context) ([]*keystore.Account, error) {
+	accs := s.keys.Accounts()
+	res := make([]*keystore.Account, len(accs))
+	for i, acc := range accs {
+		res[i] = newAccount(acc)
+	}
+	return res, nil
+}
+
+// DeleteAccount deletes the key file of addr
+func (s *KeyStore) DeleteAccount(_ context.Context, addr gethcommon.Address) error {
+	if !s.keys.HasAddress(addr) {
+		return fmt.Errorf("no key for address %q", addr.String())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.keys.Delete(gethaccounts.Account{Address: addr}, s.cfg.Password); err != nil {
+		return err
+	}
+
+	if gethcommon.IsHexAddress(s.cfg.Signer) && gethcommon.HexToAddress(s.cfg.Signer) == addr {
+		s.cfg.Signer = ""
+	}
+
+	return nil
+}
+
+// ExportAccount returns the key of addr encrypted with password
+func (s *KeyStore) ExportAccount(_ context.Context, addr gethcommon.Address, password string) ([]byte, error) {
+	if !s.keys.HasAddress(addr) {
+		return nil, fmt.Errorf("no key for address %q", addr.String())
+	}
+	return s.keys.Export(gethaccounts.Account{Address: addr}, s.password(), password)
+}
+
+// ChangePassword re-encrypts all key files with newPassword
+//
+// If a key fails to be re-encrypted, keys already re-encrypted are restored to the current password and
+// the returned error names the keys that could not be restored (i.e. left on newPassword).
+func (s *KeyStore) ChangePassword(_ context.Context, newPassword string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accs := s.keys.Accounts()
+	for i, acc := range accs {
+		if err := s.keys.Update(acc, s.cfg.Password, newPassword); err != nil {
+			errs := []error{fmt.Errorf("failed to change password of %q: %w", acc.Address.String(), err)}
+			for _, updated := range accs[:i] {
+				if err := s.keys.Update(updated, newPassword, s.cfg.Password); err != nil {
+					errs = append(errs, fmt.Errorf("failed to restore password of %q, key file is left encrypted with the new password: %w", updated.Address.String(), err))
+				}
+			}
+			return errors.Join(errs...)
+		}
+	}
+
+	s.cfg.Password = newPassword
+
+	return nil
+}
+
+// SignerAddress returns the configured signer or the first account (ordered by key file)
 func (s *KeyStore) SignerAddress(_ context.Context) (gethcommon.Address, error) {
 	if s.keys == nil {
 		return gethcommon.Address{}, errors.New("no cached keys")
 	}
+
+	s.mu.RLock()
+	signer, err := keystore.ParseSignerAddress(s.cfg.Signer)
+	s.mu.RUnlock()
+	if err != nil {
+		return gethcommon.Address{}, err
+	}
+
+	if signer != (gethcommon.Address{}) {
+		if !s.keys.HasAddress(signer) {
+			return gethcommon.Address{}, fmt.Errorf("no key for signer %q", signer.String())
+		}
+		return signer, nil
+	}
+
 	accs := s.keys.Accounts()
 	if len(accs) < 1 {
 		return gethcommon.Address{}, errors.New("keystore has no accounts")
@@ -86,3 +227,16 @@ func (s *KeyStore) SignerAddress(_ context.Context) (gethcommon.Address, error)
 	// select first (primary account) address
 	return accs[0].Address, nil
 }
+
+// SetSignerAddress selects the default signer
+func (s *KeyStore) SetSignerAddress(_ context.Context, addr gethcommon.Address) error {
+	if !s.keys.HasAddress(addr) {
+		return fmt.Errorf("no key for address %q", addr.String())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg.Signer = addr.Hex()
+
+	return nil
+}
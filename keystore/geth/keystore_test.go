@@ -7,8 +7,14 @@ import (
 	"math/big"
 	"testing"
 
+	gethaccounts "github.com/ethereum/go-ethereum/accounts"
+	gethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethmath "github.com/ethereum/go-ethereum/common/math"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/holiman/uint256"
+	"github.com/kilnfi/go-utils/ethereum/execution/types"
 	"github.com/kilnfi/go-utils/keystore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -69,3 +75,161 @@ func TestSignTxMissingAddress(t *testing.T) {
 	require.Error(t, err)
 	assert.Equal(t, "no key for address \"0x027f72Bc0CA063E40577D30D336D52Fd7bCC7375\"", err.Error())
 }
+
+func TestSignBlobTx(t *testing.T) {
+	keys := New(&Config{
+		Path:     t.TempDir(),
+		Password: "test-pwd",
+	})
+
+	acc, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+
+	sidecar, err := types.NewBlobTxSidecar([]byte("blob data"), gethtypes.BlobSidecarVersion0)
+	require.NoError(t, err)
+
+	tx := gethtypes.NewTx(&gethtypes.BlobTx{
+		ChainID:    uint256.NewInt(1),
+		BlobFeeCap: uint256.NewInt(1),
+		BlobHashes: sidecar.BlobHashes(),
+		Sidecar:    sidecar,
+	})
+
+	signedTx, err := keys.SignTx(t.Context(), acc.Addr, tx, big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, uint8(gethtypes.BlobTxType), signedTx.Type())
+	assert.Equal(t, sidecar, signedTx.BlobTxSidecar())
+
+	sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(big.NewInt(1)), signedTx)
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, sender)
+
+	tx = gethtypes.NewTx(&gethtypes.BlobTx{
+		ChainID:    uint256.NewInt(1),
+		BlobHashes: []gethcommon.Hash{{0x01}},
+		Sidecar:    sidecar,
+	})
+	_, err = keys.SignTx(t.Context(), acc.Addr, tx, big.NewInt(1))
+	require.Error(t, err)
+}
+
+func TestSignAuthorization(t *testing.T) {
+	keys := New(&Config{
+		Path:     t.TempDir(),
+		Password: "test-pwd",
+	})
+
+	acc, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+
+	auth := gethtypes.SetCodeAuthorization{
+		ChainID: *uint256.NewInt(1),
+		Address: gethcommon.HexToAddress("0x027f72bc0ca063e40577d30d336d52fd7bcc7375"),
+		Nonce:   3,
+	}
+
+	signedAuth, err := keys.SignAuthorization(t.Context(), acc.Addr, auth)
+	require.NoError(t, err)
+	assert.Equal(t, auth.Address, signedAuth.Address)
+	assert.Equal(t, auth.Nonce, signedAuth.Nonce)
+
+	authority, err := signedAuth.Authority()
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, authority)
+
+	_, err = keys.SignAuthorization(t.Context(), auth.Address, auth)
+	require.Error(t, err)
+}
+
+func TestAccountLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{
+		Path:     dir,
+		Password: "test-pwd",
+	}
+	keys := &KeyStore{
+		cfg:  cfg,
+		keys: gethkeystore.NewKeyStore(dir, gethkeystore.LightScryptN, gethkeystore.LightScryptP),
+	}
+
+	acc1, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+	acc2, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+
+	accs, err := keys.ListAccounts(t.Context())
+	require.NoError(t, err)
+	require.Len(t, accs, 2)
+
+	signer, err := keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, accs[0].Addr, signer)
+
+	require.NoError(t, keys.SetSignerAddress(t.Context(), acc2.Addr))
+	signer, err = keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, acc2.Addr, signer)
+	assert.Error(t, keys.SetSignerAddress(t.Context(), gethcommon.Address{0x1}))
+
+	keyJSON, err := keys.ExportAccount(t.Context(), acc1.Addr, "export-pwd")
+	require.NoError(t, err)
+	key, err := gethkeystore.DecryptKey(keyJSON, "export-pwd")
+	require.NoError(t, err)
+	assert.Equal(t, acc1.Addr, key.Address)
+
+	require.NoError(t, keys.ChangePassword(t.Context(), "new-pwd"))
+	_, err = keys.SignTx(t.Context(), acc1.Addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), big.NewInt(1))
+	require.NoError(t, err)
+	_, err = keys.keys.Export(gethaccounts.Account{Address: acc1.Addr}, "test-pwd", "test-pwd")
+	assert.ErrorIs(t, err, gethkeystore.ErrDecrypt)
+
+	require.NoError(t, keys.DeleteAccount(t.Context(), acc2.Addr))
+	accs, err = keys.ListAccounts(t.Context())
+	require.NoError(t, err)
+	require.Len(t, accs, 1)
+	assert.Equal(t, acc1.Addr, accs[0].Addr)
+
+	// deleted signer falls back to the first account
+	signer, err = keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, acc1.Addr, signer)
+}
+
+func TestSignMessageAndTypedData(t *testing.T) {
+	keys := New(&Config{
+		Path:     t.TempDir(),
+		Password: "test-pwd",
+	})
+
+	acc, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+
+	msg := []byte("order #1")
+	sig, err := keys.SignMessage(t.Context(), acc.Addr, msg)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, sig[64], byte(27))
+
+	ok, err := keystore.VerifyMessage(acc.Addr, msg, sig)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	data := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {{Name: "name", Type: "string"}, {Name: "chainId", Type: "uint256"}},
+			"Order":        {{Name: "maker", Type: "address"}, {Name: "amount", Type: "uint256"}},
+		},
+		PrimaryType: "Order",
+		Domain:      apitypes.TypedDataDomain{Name: "Exchange", ChainId: gethmath.NewHexOrDecimal256(1)},
+		Message:     apitypes.TypedDataMessage{"maker": acc.Addr.Hex(), "amount": "1000"},
+	}
+
+	sig, err = keys.SignTypedData(t.Context(), acc.Addr, data)
+	require.NoError(t, err)
+
+	ok, err = keystore.VerifyTypedData(acc.Addr, data, sig)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = keys.SignMessage(t.Context(), gethcommon.Address{0x1}, msg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+//revive:disable-next-line:package-directory-mismatch
+package hdkeystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	gethaccounts "github.com/ethereum/go-ethereum/accounts"
+	gethmath "github.com/ethereum/go-ethereum/common/math"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// hardenedKeyStart is the index of the first hardened child key (BIP-32)
+const hardenedKeyStart = 0x80000000
+
+var (
+	masterKeySecret = []byte("Bitcoin seed")
+
+	errInvalidKey = errors.New("derived key is invalid")
+)
+
+// extendedKey is a BIP-32 extended private key
+type extendedKey struct {
+	key       []byte
+	chainCode []byte
+}
+
+// newMasterKey derives the master extended key of seed
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	sum := hmacSHA512(masterKeySecret, seed)
+
+	if !isValidKey(new(big.Int).SetBytes(sum[:32])) {
+		return nil, errInvalidKey
+	}
+
+	return &extendedKey{
+		key:       sum[:32],
+		chainCode: sum[32:],
+	}, nil
+}
+
+// child derives the child extended key at index i
+//
+// It returns errInvalidKey in the (highly unlikely) case the child key is invalid,
+// in which case BIP-32 specifies to proceed with the next index.
+func (k *extendedKey) child(i uint32) (*extendedKey, error) {
+	data := make([]byte, 0, 37)
+	if i >= hardenedKeyStart {
+		data = append(data, 0x00)
+		data = append(data, k.key...)
+	} else {
+		priv, err := gethcrypto.ToECDSA(k.key)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, gethcrypto.CompressPubkey(&priv.PublicKey)...)
+	}
+	data = binary.BigEndian.AppendUint32(data, i)
+
+	sum := hmacSHA512(k.chainCode, data)
+
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(gethcrypto.S256().Params().N) >= 0 {
+		return nil, errInvalidKey
+	}
+
+	key := il.Add(il, new(big.Int).SetBytes(k.key))
+	key.Mod(key, gethcrypto.S256().Params().N)
+	if !isValidKey(key) {
+		return nil, errInvalidKey
+	}
+
+	return &extendedKey{
+		key:       gethmath.PaddedBigBytes(key, 32),
+		chainCode: sum[32:],
+	}, nil
+}
+
+// deriveKey derives the private key at path from seed
+func deriveKey(seed []byte, path gethaccounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	key, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range path {
+		if key, err = key.child(i); err != nil {
+			return nil, fmt.Errorf("failed to derive key at path %q: %w", path.String(), err)
+		}
+	}
+
+	return gethcrypto.ToECDSA(key.key)
+}
+
+func isValidKey(k *big.Int) bool {
+	return k.Sign() > 0 && k.Cmp(gethcrypto.S256().Params().N) < 0
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
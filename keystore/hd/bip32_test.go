@@ -0,0 +1,47 @@
+//go:build !integration
+
+//revive:disable-next-line:package-directory-mismatch
+package hdkeystore
+
+import (
+	"testing"
+
+	gethaccounts "github.com/ethereum/go-ethereum/accounts"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethhexutil "github.com/ethereum/go-ethereum/common/hexutil"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vector 1 of BIP-32
+func TestDeriveKey(t *testing.T) {
+	seed := gethcommon.FromHex("0x000102030405060708090a0b0c0d0e0f")
+
+	master, err := newMasterKey(seed)
+	require.NoError(t, err)
+	assert.Equal(t, "0xe8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", gethhexutil.Encode(master.key))
+	assert.Equal(t, "0x873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508", gethhexutil.Encode(master.chainCode))
+
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{path: "m/0'", key: "0xedb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{path: "m/0'/1", key: "0x3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{path: "m/0'/1/2'", key: "0xcbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{path: "m/0'/1/2'/2", key: "0x0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{path: "m/0'/1/2'/2/1000000000", key: "0x471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			path, err := gethaccounts.ParseDerivationPath(test.path)
+			require.NoError(t, err)
+
+			priv, err := deriveKey(seed, path)
+			require.NoError(t, err)
+			assert.Equal(t, test.key, gethhexutil.Encode(gethcrypto.FromECDSA(priv)))
+		})
+	}
+}
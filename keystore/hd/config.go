@@ -0,0 +1,23 @@
+//nolint:revive // package name intentionally reflects domain, not directory name
+package hdkeystore
+
+type Config struct {
+	// Path is the file storing the encrypted seed of the wallet
+	Path     string `json:"path"`
+	Password string `json:"-"`
+
+	// Mnemonic and MnemonicPassphrase restore the wallet if no wallet is stored at Path (optional)
+	Mnemonic           string `json:"-"`
+	MnemonicPassphrase string `json:"-"`
+
+	// Signer is the address of the default signer (optional)
+	Signer string `json:"signer,omitempty"`
+}
+
+func (cfg *Config) SetDefault() *Config {
+	if cfg.Path == "" {
+		cfg.Path = "hdwallet.json"
+	}
+
+	return cfg
+}
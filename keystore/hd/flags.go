@@ -0,0 +1,136 @@
+//nolint:revive // package name intentionally reflects domain, not directory name
+package hdkeystore
+
+import (
+	cmdutils "github.com/kilnfi/go-utils/cmd/utils"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func Flags(v *viper.Viper, f *pflag.FlagSet) {
+	WalletPathFlag(v, f)
+	WalletPasswordFlag(v, f)
+	WalletMnemonicFlag(v, f)
+	WalletMnemonicPassphraseFlag(v, f)
+	WalletSignerFlag(v, f)
+}
+
+func ConfigFromViper(v *viper.Viper) *Config {
+	return &Config{
+		Path:               GetWalletPath(v),
+		Password:           GetWalletPassword(v),
+		Mnemonic:           GetWalletMnemonic(v),
+		MnemonicPassphrase: GetWalletMnemonicPassphrase(v),
+		Signer:             GetWalletSigner(v),
+	}
+}
+
+const (
+	walletPathFlag     = "hd-wallet-path"
+	walletPathViperKey = "hd-wallet.path"
+	walletPathEnv      = "HD_WALLET_PATH"
+)
+
+// WalletPathFlag register flag for the path to the file storing the encrypted seed of the HD wallet
+func WalletPathFlag(v *viper.Viper, f *pflag.FlagSet) {
+	f.String(walletPathFlag, "", cmdutils.FlagDesc("File storing the encrypted seed of the HD wallet", walletPathEnv))
+
+	if err := v.BindPFlag(walletPathViperKey, f.Lookup(walletPathFlag)); err != nil {
+		panic(err)
+	}
+	if err := v.BindEnv(walletPathViperKey, walletPathEnv); err != nil {
+		panic(err)
+	}
+}
+
+func GetWalletPath(v *viper.Viper) string {
+	return v.GetString(walletPathViperKey)
+}
+
+const (
+	walletPasswordFlag     = "hd-wallet-password"
+	walletPasswordViperKey = "hd-wallet.password"
+	walletPasswordEnv      = "HD_WALLET_PASSWORD"
+)
+
+// WalletPasswordFlag register flag for the password used to encrypt the seed of the HD wallet
+func WalletPasswordFlag(v *viper.Viper, f *pflag.FlagSet) {
+	f.String(walletPasswordFlag, "", cmdutils.FlagDesc("Password used to encrypt the seed of the HD wallet", walletPasswordEnv))
+
+	if err := v.BindPFlag(walletPasswordViperKey, f.Lookup(walletPasswordFlag)); err != nil {
+		panic(err)
+	}
+	if err := v.BindEnv(walletPasswordViperKey, walletPasswordEnv); err != nil {
+		panic(err)
+	}
+}
+
+func GetWalletPassword(v *viper.Viper) string {
+	return v.GetString(walletPasswordViperKey)
+}
+
+const (
+	walletMnemonicFlag     = "hd-wallet-mnemonic"
+	walletMnemonicViperKey = "hd-wallet.mnemonic"
+	walletMnemonicEnv      = "HD_WALLET_MNEMONIC"
+)
+
+// WalletMnemonicFlag register flag for the BIP-39 mnemonic restoring the HD wallet
+func WalletMnemonicFlag(v *viper.Viper, f *pflag.FlagSet) {
+	f.String(walletMnemonicFlag, "", cmdutils.FlagDesc("BIP-39 mnemonic restoring the HD wallet if none is stored", walletMnemonicEnv))
+
+	if err := v.BindPFlag(walletMnemonicViperKey, f.Lookup(walletMnemonicFlag)); err != nil {
+		panic(err)
+	}
+	if err := v.BindEnv(walletMnemonicViperKey, walletMnemonicEnv); err != nil {
+		panic(err)
+	}
+}
+
+func GetWalletMnemonic(v *viper.Viper) string {
+	return v.GetString(walletMnemonicViperKey)
+}
+
+const (
+	walletMnemonicPassphraseFlag     = "hd-wallet-mnemonic-passphrase"
+	walletMnemonicPassphraseViperKey = "hd-wallet.mnemonic-passphrase"
+	walletMnemonicPassphraseEnv      = "HD_WALLET_MNEMONIC_PASSPHRASE"
+)
+
+// WalletMnemonicPassphraseFlag register flag for the BIP-39 passphrase of the mnemonic
+func WalletMnemonicPassphraseFlag(v *viper.Viper, f *pflag.FlagSet) {
+	f.String(walletMnemonicPassphraseFlag, "", cmdutils.FlagDesc("BIP-39 passphrase of the mnemonic (optional)", walletMnemonicPassphraseEnv))
+
+	if err := v.BindPFlag(walletMnemonicPassphraseViperKey, f.Lookup(walletMnemonicPassphraseFlag)); err != nil {
+		panic(err)
+	}
+	if err := v.BindEnv(walletMnemonicPassphraseViperKey, walletMnemonicPassphraseEnv); err != nil {
+		panic(err)
+	}
+}
+
+func GetWalletMnemonicPassphrase(v *viper.Viper) string {
+	return v.GetString(walletMnemonicPassphraseViperKey)
+}
+
+const (
+	walletSignerFlag     = "hd-wallet-signer"
+	walletSignerViperKey = "hd-wallet.signer"
+	walletSignerEnv      = "HD_WALLET_SIGNER"
+)
+
+// WalletSignerFlag register flag for the address of the default signer of the HD wallet
+func WalletSignerFlag(v *viper.Viper, f *pflag.FlagSet) {
+	f.String(walletSignerFlag, "", cmdutils.FlagDesc("Address of the default signer of the HD wallet (defaults to the account with the lowest index)", walletSignerEnv))
+
+	if err := v.BindPFlag(walletSignerViperKey, f.Lookup(walletSignerFlag)); err != nil {
+		panic(err)
+	}
+	if err := v.BindEnv(walletSignerViperKey, walletSignerEnv); err != nil {
+		panic(err)
+	}
+}
+
+func GetWalletSigner(v *viper.Viper) string {
+	return v.GetString(walletSignerViperKey)
+}
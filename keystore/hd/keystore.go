@@ -0,0 +1,502 @@
+//revive:disable-next-line:package-directory-mismatch
+package hdkeystore
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	gethaccounts "github.com/ethereum/go-ethereum/accounts"
+	gethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/google/uuid"
+	"github.com/kilnfi/go-utils/ethereum/staking"
+	"github.com/kilnfi/go-utils/keystore"
+)
+
+var _ keystore.Store = &KeyStore{}
+
+// ErrImportNotSupported is returned when importing a key in an HD wallet
+//
+// Keys of an HD wallet are all derived from its seed.
+var ErrImportNotSupported = fmt.Errorf("importing keys in an HD wallet: %w", keystore.ErrNotSupported)
+
+// walletVersion is the version of the wallet file format
+const walletVersion = 1
+
+// walletJSON is the content of the wallet file
+//
+// The seed is encrypted in the Web3 Secret Storage format. Keys are never stored:
+// only indexes of the accounts in use are, so accounts can be derived again from the seed.
+type walletJSON struct {
+	Version int                     `json:"version"`
+	Crypto  gethkeystore.CryptoJSON `json:"crypto"`
+
+	// Indexes of the accounts in use
+	Indexes []uint32 `json:"indexes"`
+
+	// Next is the index of the next account created
+	Next uint32 `json:"next"`
+}
+
+// KeyStore derives secp256k1 keys from a BIP-39 mnemonic along the BIP-44 path m/44'/60'/0'/0/i
+//
+// Only the seed of the mnemonic is stored, encrypted with the keystore password in the file at Config.Path.
+// The same accounts can be derived again on any machine from the mnemonic.
+type KeyStore struct {
+	cfg *Config
+
+	scryptN, scryptP int
+
+	// mu protects all fields below as well as cfg.Password and cfg.Signer which can be changed at runtime
+	mu     sync.RWMutex
+	seed   []byte
+	crypto gethkeystore.CryptoJSON
+	next   uint32
+
+	// keys and addrs index the accounts in use by address and by index
+	keys  map[gethcommon.Address]*derivedKey
+	addrs map[uint32]gethcommon.Address
+}
+
+type derivedKey struct {
+	index uint32
+	priv  *ecdsa.PrivateKey
+}
+
+// New opens the HD wallet stored at cfg.Path
+//
+// If no wallet is stored, it is restored from cfg.Mnemonic. If both are set, the stored wallet
+// must have been created from cfg.Mnemonic.
+func New(cfg *Config) (*KeyStore, error) {
+	return open(cfg, gethkeystore.StandardScryptN, gethkeystore.StandardScryptP)
+}
+
+// Generate creates an HD wallet at cfg.Path from a new random mnemonic
+//
+// The mnemonic is returned and must be backed up as it is never stored.
+func Generate(cfg *Config) (keys *KeyStore, mnemonic string, err error) {
+	mnemonic, err = staking.GenerateRandomMnemonics()
+	if err != nil {
+		return nil, "", err
+	}
+
+	keys, err = create(cfg, mnemonic, cfg.MnemonicPassphrase, gethkeystore.StandardScryptN, gethkeystore.StandardScryptP)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return keys, mnemonic, nil
+}
+
+func newKeyStore(cfg *Config, scryptN, scryptP int) *KeyStore {
+	return &KeyStore{
+		cfg:     cfg,
+		scryptN: scryptN,
+		scryptP: scryptP,
+		keys:    make(map[gethcommon.Address]*derivedKey),
+		addrs:   make(map[uint32]gethcommon.Address),
+	}
+}
+
+func open(cfg *Config, scryptN, scryptP int) (*KeyStore, error) {
+	b, err := os.ReadFile(cfg.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		if cfg.Mnemonic == "" {
+			return nil, fmt.Errorf("no HD wallet at %q and no mnemonic to restore it", cfg.Path)
+		}
+		return create(cfg, cfg.Mnemonic, cfg.MnemonicPassphrase, scryptN, scryptP)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var wallet walletJSON
+	if err := json.Unmarshal(b, &wallet); err != nil {
+		return nil, fmt.Errorf("invalid HD wallet at %q: %w", cfg.Path, err)
+	}
+	if wallet.Version != walletVersion {
+		return nil, fmt.Errorf("unsupported HD wallet version %v (expected %v)", wallet.Version, walletVersion)
+	}
+
+	seed, err := gethkeystore.DecryptDataV3(wallet.Crypto, cfg.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt HD wallet at %q: %w", cfg.Path, err)
+	}
+
+	if cfg.Mnemonic != "" {
+		mnemonicSeed, err := staking.Seed(cfg.Mnemonic, cfg.MnemonicPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(seed, mnemonicSeed) {
+			return nil, fmt.Errorf("HD wallet at %q was not created from the configured mnemonic", cfg.Path)
+		}
+	}
+
+	s := newKeyStore(cfg, scryptN, scryptP)
+	s.seed = seed
+	s.crypto = wallet.Crypto
+	s.next = wallet.Next
+	for _, index := range wallet.Indexes {
+		if err := s.addKey(index); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// create stores a new HD wallet at cfg.Path holding the seed of mnemonic
+func create(cfg *Config, mnemonic, mnemonicPassphrase string, scryptN, scryptP int) (*KeyStore, error) {
+	if _, err := os.Stat(cfg.Path); err == nil {
+		return nil, fmt.Errorf("an HD wallet already exists at %q", cfg.Path)
+	}
+
+	seed, err := staking.Seed(mnemonic, mnemonicPassphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newKeyStore(cfg, scryptN, scryptP)
+	s.seed = seed
+	if s.crypto, err = gethkeystore.EncryptDataV3(seed, []byte(cfg.Password), scryptN, scryptP); err != nil {
+		return nil, err
+	}
+
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// CreateAccount derives the account at the index following the last created one
+func (s *KeyStore) CreateAccount(ctx context.Context) (*keystore.Account, error) {
+	s.mu.RLock()
+	next := s.next
+	s.mu.RUnlock()
+
+	return s.DeriveAccount(ctx, next)
+}
+
+// DeriveAccount derives the account at path m/44'/60'/0'/0/index and adds it to the accounts in use
+//
+// Deriving an account already in use returns it unchanged, so accounts can be discovered by index.
+func (s *KeyStore) DeriveAccount(_ context.Context, index uint32) (*keystore.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if addr, ok := s.addrs[index]; ok {
+		return account(addr, index), nil
+	}
+
+	if err := s.addKey(index); err != nil {
+		return nil, err
+	}
+
+	next := s.next
+	if index >= s.next {
+		s.next = index + 1
+	}
+
+	if err := s.save(); err != nil {
+		s.removeKey(s.addrs[index])
+		s.next = next
+		return nil, err
+	}
+
+	return account(s.addrs[index], index), nil
+}
+
+// addKey derives the key at index and adds it to the accounts in use
+func (s *KeyStore) addKey(index uint32) error {
+	if index >= hardenedKeyStart {
+		return fmt.Errorf("invalid account index %v (expected less than %v)", index, uint32(hardenedKeyStart))
+	}
+
+	priv, err := deriveKey(s.seed, DerivationPath(index))
+	if err != nil {
+		return err
+	}
+
+	addr := gethcrypto.PubkeyToAddress(priv.PublicKey)
+	s.keys[addr] = &derivedKey{index: index, priv: priv}
+	s.addrs[index] = addr
+
+	return nil
+}
+
+func (s *KeyStore) removeKey(addr gethcommon.Address) {
+	if key, ok := s.keys[addr]; ok {
+		delete(s.addrs, key.index)
+		delete(s.keys, addr)
+	}
+}
+
+// indexes returns indexes of the accounts in use in increasing order
+func (s *KeyStore) indexes() []uint32 {
+	indexes := make([]uint32, 0, len(s.addrs))
+	for index := range s.addrs {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	return indexes
+}
+
+// save writes the wallet file
+//
+// The file is first written next to the wallet then renamed, so the wallet is never left half-written.
+func (s *KeyStore) save() error {
+	b, err := json.Marshal(&walletJSON{
+		Version: walletVersion,
+		Crypto:  s.crypto,
+		Indexes: s.indexes(),
+		Next:    s.next,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.cfg.Path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.cfg.Path), "."+filepath.Base(s.cfg.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.cfg.Path)
+}
+
+// Import is not supported as keys of an HD wallet are derived from its seed
+func (s *KeyStore) Import(_ context.Context, _ string) (*keystore.Account, error) {
+	return nil, ErrImportNotSupported
+}
+
+func (s *KeyStore) SignTx(_ context.Context, addr gethcommon.Address, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
+	priv, err := s.key(addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := keystore.ValidateBlobTx(tx); err != nil {
+		return nil, err
+	}
+	return gethtypes.SignTx(tx, gethtypes.LatestSignerForChainID(chainID), priv)
+}
+
+// SignAuthorization signs an EIP-7702 set-code authorization with the key of addr
+func (s *KeyStore) SignAuthorization(_ context.Context, addr gethcommon.Address, auth gethtypes.SetCodeAuthorization) (gethtypes.SetCodeAuthorization, error) {
+	priv, err := s.key(addr)
+	if err != nil {
+		return gethtypes.SetCodeAuthorization{}, err
+	}
+	return gethtypes.SignSetCode(priv, auth)
+}
+
+// SignMessage signs msg with the key of addr as personal_sign (EIP-191)
+func (s *KeyStore) SignMessage(_ context.Context, addr gethcommon.Address, msg []byte) ([]byte, error) {
+	return s.signHash(addr, keystore.MessageHash(msg))
+}
+
+// SignTypedData signs typed data with the key of addr as eth_signTypedData_v4 (EIP-712)
+func (s *KeyStore) SignTypedData(_ context.Context, addr gethcommon.Address, data apitypes.TypedData) ([]byte, error) {
+	hash, err := keystore.TypedDataHash(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.signHash(addr, hash)
+}
+
+// signHash signs hash with the key of addr and returns a signature with V being 27 or 28
+func (s *KeyStore) signHash(addr gethcommon.Address, hash []byte) ([]byte, error) {
+	priv, err := s.key(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := gethcrypto.Sign(hash, priv)
+	if err != nil {
+		return nil, err
+	}
+
+	return keystore.ToEthSignature(sig), nil
+}
+
+func (s *KeyStore) HasAccount(_ context.Context, addr gethcommon.Address) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.keys[addr]
+	return ok, nil
+}
+
+// ListAccounts returns all accounts in use ordered by index
+func (s *KeyStore) ListAccounts(_ context.Context) ([]*keystore.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	indexes := s.indexes()
+	accs := make([]*keystore.Account, len(indexes))
+	for i, index := range indexes {
+		accs[i] = account(s.addrs[index], index)
+	}
+
+	return accs, nil
+}
+
+// DeleteAccount removes addr from the accounts in use
+//
+// As its key is derived from the seed, the account can be derived again with DeriveAccount.
+func (s *KeyStore) DeleteAccount(_ context.Context, addr gethcommon.Address) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[addr]
+	if !ok {
+		return fmt.Errorf("no key for address %q", addr.String())
+	}
+
+	s.removeKey(addr)
+	if err := s.save(); err != nil {
+		s.keys[addr] = key
+		s.addrs[key.index] = addr
+		return err
+	}
+
+	if gethcommon.IsHexAddress(s.cfg.Signer) && gethcommon.HexToAddress(s.cfg.Signer) == addr {
+		s.cfg.Signer = ""
+	}
+
+	return nil
+}
+
+// ExportAccount returns the key of addr encrypted with password
+func (s *KeyStore) ExportAccount(_ context.Context, addr gethcommon.Address, password string) ([]byte, error) {
+	priv, err := s.key(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return gethkeystore.EncryptKey(
+		&gethkeystore.Key{
+			Id:         uuid.New(),
+			Address:    addr,
+			PrivateKey: priv,
+		},
+		password,
+		gethkeystore.StandardScryptN,
+		gethkeystore.StandardScryptP,
+	)
+}
+
+// ChangePassword re-encrypts the seed with newPassword
+func (s *KeyStore) ChangePassword(_ context.Context, newPassword string) error {
+	crypto, err := gethkeystore.EncryptDataV3(s.seed, []byte(newPassword), s.scryptN, s.scryptP)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.crypto
+	s.crypto = crypto
+	if err := s.save(); err != nil {
+		s.crypto = current
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	s.cfg.Password = newPassword
+
+	return nil
+}
+
+// SignerAddress returns the configured signer or the account with the lowest index
+func (s *KeyStore) SignerAddress(_ context.Context) (gethcommon.Address, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	signer, err := keystore.ParseSignerAddress(s.cfg.Signer)
+	if err != nil {
+		return gethcommon.Address{}, err
+	}
+
+	if signer != (gethcommon.Address{}) {
+		if _, ok := s.keys[signer]; !ok {
+			return gethcommon.Address{}, fmt.Errorf("no key for signer %q", signer.String())
+		}
+		return signer, nil
+	}
+
+	indexes := s.indexes()
+	if len(indexes) == 0 {
+		return gethcommon.Address{}, errors.New("keystore has no accounts")
+	}
+
+	return s.addrs[indexes[0]], nil
+}
+
+// SetSignerAddress selects the default signer
+func (s *KeyStore) SetSignerAddress(_ context.Context, addr gethcommon.Address) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[addr]; !ok {
+		return fmt.Errorf("no key for address %q", addr.String())
+	}
+	s.cfg.Signer = addr.Hex()
+
+	return nil
+}
+
+// key returns the private key of addr
+func (s *KeyStore) key(addr gethcommon.Address) (*ecdsa.PrivateKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[addr]
+	if !ok {
+		return nil, fmt.Errorf("no key for address %q", addr.String())
+	}
+
+	return key.priv, nil
+}
+
+func account(addr gethcommon.Address, index uint32) *keystore.Account {
+	return &keystore.Account{
+		Addr: addr,
+		URL: gethaccounts.URL{
+			Scheme: "hd",
+			Path:   DerivationPath(index).String(),
+		},
+	}
+}
+
+// DerivationPath returns the BIP-44 path of the account at index: m/44'/60'/0'/0/index
+func DerivationPath(index uint32) gethaccounts.DerivationPath {
+	path := make(gethaccounts.DerivationPath, 0, len(gethaccounts.DefaultRootDerivationPath)+1)
+	path = append(path, gethaccounts.DefaultRootDerivationPath...)
+	return append(path, index)
+}
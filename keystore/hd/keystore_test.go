@@ -0,0 +1,201 @@
+//go:build !integration
+
+//revive:disable-next-line:package-directory-mismatch
+package hdkeystore
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+	"github.com/kilnfi/go-utils/keystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMnemonic is the mnemonic of the default accounts of Hardhat and Anvil
+const testMnemonic = "test test test test test test test test test test test junk"
+
+var testAddresses = []gethcommon.Address{
+	gethcommon.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"),
+	gethcommon.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"),
+	gethcommon.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"),
+}
+
+func newTestKeyStore(t *testing.T, cfg *Config) *KeyStore {
+	keys, err := open(cfg, gethkeystore.LightScryptN, gethkeystore.LightScryptP)
+	require.NoError(t, err)
+	return keys
+}
+
+func TestInterface(t *testing.T) {
+	assert.Implements(t, (*keystore.Store)(nil), new(KeyStore))
+}
+
+func TestDeriveAccounts(t *testing.T) {
+	cfg := &Config{
+		Path:     filepath.Join(t.TempDir(), "hdwallet.json"),
+		Password: "test-pwd",
+		Mnemonic: testMnemonic,
+	}
+	keys := newTestKeyStore(t, cfg)
+
+	for i, addr := range testAddresses {
+		acc, err := keys.CreateAccount(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, addr, acc.Addr)
+		assert.Equal(t, "hd", acc.URL.Scheme)
+		assert.Equal(t, DerivationPath(uint32(i)).String(), acc.URL.Path)
+	}
+	assert.Equal(t, "m/44'/60'/0'/0/2", DerivationPath(2).String())
+
+	// deriving an account in use returns it
+	acc, err := keys.DeriveAccount(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, testAddresses[1], acc.Addr)
+
+	_, err = keys.DeriveAccount(t.Context(), hardenedKeyStart)
+	assert.Error(t, err)
+
+	// only the encrypted seed is stored
+	b, err := os.ReadFile(cfg.Path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "test test")
+
+	// accounts are derived again by a keystore opening the wallet
+	keys = newTestKeyStore(t, &Config{Path: cfg.Path, Password: cfg.Password})
+	accs, err := keys.ListAccounts(t.Context())
+	require.NoError(t, err)
+	require.Len(t, accs, len(testAddresses))
+	for i, addr := range testAddresses {
+		assert.Equal(t, addr, accs[i].Addr)
+	}
+
+	// the same accounts are derived from the mnemonic on another machine
+	keys = newTestKeyStore(t, &Config{
+		Path:     filepath.Join(t.TempDir(), "hdwallet.json"),
+		Mnemonic: testMnemonic,
+	})
+	acc, err = keys.DeriveAccount(t.Context(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, testAddresses[2], acc.Addr)
+
+	acc, err = keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, DerivationPath(3).String(), acc.URL.Path)
+}
+
+func TestOpen(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "hdwallet.json")
+
+	_, err := open(&Config{Path: pth}, gethkeystore.LightScryptN, gethkeystore.LightScryptP)
+	assert.Error(t, err, "no wallet and no mnemonic")
+
+	_, err = open(&Config{Path: pth, Mnemonic: "invalid mnemonic"}, gethkeystore.LightScryptN, gethkeystore.LightScryptP)
+	assert.Error(t, err)
+
+	newTestKeyStore(t, &Config{Path: pth, Password: "test-pwd", Mnemonic: testMnemonic})
+
+	_, err = open(&Config{Path: pth, Password: "invalid-pwd"}, gethkeystore.LightScryptN, gethkeystore.LightScryptP)
+	assert.Error(t, err)
+
+	_, err = open(&Config{Path: pth, Password: "test-pwd", Mnemonic: testMnemonic, MnemonicPassphrase: "other"}, gethkeystore.LightScryptN, gethkeystore.LightScryptP)
+	assert.Error(t, err, "wallet was created from another seed")
+
+	_, err = create(&Config{Path: pth}, testMnemonic, "", gethkeystore.LightScryptN, gethkeystore.LightScryptP)
+	assert.Error(t, err, "wallet already exists")
+}
+
+func TestSignTx(t *testing.T) {
+	keys := newTestKeyStore(t, &Config{
+		Path:     filepath.Join(t.TempDir(), "hdwallet.json"),
+		Mnemonic: testMnemonic,
+	})
+
+	acc, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+
+	chainID := big.NewInt(17000)
+	tx, err := keys.SignTx(t.Context(), acc.Addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), chainID)
+	require.NoError(t, err)
+	sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(chainID), tx)
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, sender)
+
+	auth, err := keys.SignAuthorization(t.Context(), acc.Addr, gethtypes.SetCodeAuthorization{ChainID: *uint256.NewInt(1)})
+	require.NoError(t, err)
+	authority, err := auth.Authority()
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, authority)
+
+	sig, err := keys.SignMessage(t.Context(), acc.Addr, []byte("order #1"))
+	require.NoError(t, err)
+	ok, err := keystore.VerifyMessage(acc.Addr, []byte("order #1"), sig)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = keys.SignTx(t.Context(), testAddresses[1], gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), chainID)
+	assert.Error(t, err)
+
+	_, err = keys.Import(t.Context(), "")
+	assert.ErrorIs(t, err, keystore.ErrNotSupported)
+}
+
+func TestAccountLifecycle(t *testing.T) {
+	cfg := &Config{
+		Path:     filepath.Join(t.TempDir(), "hdwallet.json"),
+		Password: "test-pwd",
+		Mnemonic: testMnemonic,
+	}
+	keys := newTestKeyStore(t, cfg)
+
+	_, err := keys.SignerAddress(t.Context())
+	assert.Error(t, err)
+
+	acc1, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+	acc2, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+
+	require.NoError(t, keys.SetSignerAddress(t.Context(), acc2.Addr))
+	signer, err := keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, acc2.Addr, signer)
+
+	keyJSON, err := keys.ExportAccount(t.Context(), acc1.Addr, "export-pwd")
+	require.NoError(t, err)
+	key, err := gethkeystore.DecryptKey(keyJSON, "export-pwd")
+	require.NoError(t, err)
+	assert.Equal(t, acc1.Addr, key.Address)
+
+	require.NoError(t, keys.DeleteAccount(t.Context(), acc2.Addr))
+	accs, err := keys.ListAccounts(t.Context())
+	require.NoError(t, err)
+	require.Len(t, accs, 1)
+	assert.Equal(t, acc1.Addr, accs[0].Addr)
+
+	signer, err = keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, acc1.Addr, signer)
+
+	// deleted accounts are not created again
+	acc3, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, testAddresses[2], acc3.Addr)
+
+	require.NoError(t, keys.ChangePassword(t.Context(), "new-pwd"))
+	_, err = open(&Config{Path: cfg.Path, Password: "test-pwd"}, gethkeystore.LightScryptN, gethkeystore.LightScryptP)
+	assert.Error(t, err)
+
+	keys = newTestKeyStore(t, &Config{Path: cfg.Path, Password: "new-pwd"})
+	accs, err = keys.ListAccounts(t.Context())
+	require.NoError(t, err)
+	require.Len(t, accs, 2)
+	assert.Equal(t, testAddresses[0], accs[0].Addr)
+	assert.Equal(t, testAddresses[2], accs[1].Addr)
+}
@@ -2,11 +2,14 @@ package keystore
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
 
 	gethaccounts "github.com/ethereum/go-ethereum/accounts"
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 type Account struct {
@@ -14,10 +17,51 @@ type Account struct {
 	URL  gethaccounts.URL   `json:"url"`
 }
 
+// ErrNotSupported is returned by stores for operations their backend does not support
+var ErrNotSupported = errors.New("operation not supported by keystore")
+
 type Store interface {
 	CreateAccount(context.Context) (*Account, error)
 	HasAccount(ctx context.Context, addr gethcommon.Address) (bool, error)
 	SignTx(ctx context.Context, addr gethcommon.Address, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error)
+	SignAuthorization(ctx context.Context, addr gethcommon.Address, auth gethtypes.SetCodeAuthorization) (gethtypes.SetCodeAuthorization, error)
 	Import(ctx context.Context, hexkey string) (*Account, error)
+
+	// SignMessage signs msg as personal_sign (EIP-191) and returns a signature with V being 27 or 28
+	SignMessage(ctx context.Context, addr gethcommon.Address, msg []byte) ([]byte, error)
+
+	// SignTypedData signs typed data as eth_signTypedData_v4 (EIP-712) and returns a signature with V being 27 or 28
+	SignTypedData(ctx context.Context, addr gethcommon.Address, data apitypes.TypedData) ([]byte, error)
+
+	// ListAccounts returns all accounts of the store
+	ListAccounts(context.Context) ([]*Account, error)
+
+	// DeleteAccount permanently deletes the key of addr
+	DeleteAccount(ctx context.Context, addr gethcommon.Address) error
+
+	// ExportAccount returns the key of addr as JSON encrypted with password (Web3 Secret Storage format)
+	ExportAccount(ctx context.Context, addr gethcommon.Address, password string) ([]byte, error)
+
+	// ChangePassword re-encrypts all keys of the store with newPassword
+	ChangePassword(ctx context.Context, newPassword string) error
+
+	// SignerAddress returns the default signer: the one selected with SetSignerAddress
+	// or the first account of the store if none has been selected
 	SignerAddress(context.Context) (gethcommon.Address, error)
+
+	// SetSignerAddress selects the default signer
+	SetSignerAddress(ctx context.Context, addr gethcommon.Address) error
+}
+
+// ParseSignerAddress parses the address of a default signer from configuration
+//
+// It returns the zero address if s is empty.
+func ParseSignerAddress(s string) (gethcommon.Address, error) {
+	if s == "" {
+		return gethcommon.Address{}, nil
+	}
+	if !gethcommon.IsHexAddress(s) {
+		return gethcommon.Address{}, fmt.Errorf("invalid signer address %q", s)
+	}
+	return gethcommon.HexToAddress(s), nil
 }
@@ -0,0 +1,96 @@
+package keystore
+
+import (
+	"fmt"
+
+	gethaccounts "github.com/ethereum/go-ethereum/accounts"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// MessageHash returns the EIP-191 hash of msg signed by personal_sign
+//
+// keccak256("\x19Ethereum Signed Message:\n" + len(msg) + msg)
+func MessageHash(msg []byte) []byte {
+	return gethaccounts.TextHash(msg)
+}
+
+// TypedDataHash returns the EIP-712 hash of typed data signed by eth_signTypedData_v4
+//
+// keccak256("\x19\x01" + domainSeparator + hashStruct(message))
+func TypedDataHash(data apitypes.TypedData) ([]byte, error) {
+	hash, _, err := apitypes.TypedDataAndHash(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid typed data: %w", err)
+	}
+	return hash, nil
+}
+
+// ToEthSignature converts a signature in the [R || S || V] format with V being 0 or 1
+// into the format returned by personal_sign and eth_signTypedData (V being 27 or 28)
+func ToEthSignature(sig []byte) []byte {
+	ethSig := make([]byte, len(sig))
+	copy(ethSig, sig)
+	if len(ethSig) == 65 && ethSig[64] < 27 {
+		ethSig[64] += 27
+	}
+	return ethSig
+}
+
+// RecoverHashSigner returns the address that signed hash
+//
+// sig is expected in the [R || S || V] format, V being either 0/1 or 27/28.
+func RecoverHashSigner(hash, sig []byte) (gethcommon.Address, error) {
+	if len(sig) != 65 {
+		return gethcommon.Address{}, fmt.Errorf("invalid signature length %v (expected 65)", len(sig))
+	}
+
+	rawSig := make([]byte, 65)
+	copy(rawSig, sig)
+	if rawSig[64] >= 27 {
+		rawSig[64] -= 27
+	}
+	if rawSig[64] > 1 {
+		return gethcommon.Address{}, fmt.Errorf("invalid signature recovery id %v", sig[64])
+	}
+
+	pub, err := gethcrypto.SigToPub(hash, rawSig)
+	if err != nil {
+		return gethcommon.Address{}, err
+	}
+
+	return gethcrypto.PubkeyToAddress(*pub), nil
+}
+
+// RecoverMessageSigner returns the address that signed msg with personal_sign
+func RecoverMessageSigner(msg, sig []byte) (gethcommon.Address, error) {
+	return RecoverHashSigner(MessageHash(msg), sig)
+}
+
+// VerifyMessage indicates whether sig is a personal_sign signature of msg by addr
+func VerifyMessage(addr gethcommon.Address, msg, sig []byte) (bool, error) {
+	signer, err := RecoverMessageSigner(msg, sig)
+	if err != nil {
+		return false, err
+	}
+	return signer == addr, nil
+}
+
+// RecoverTypedDataSigner returns the address that signed typed data with eth_signTypedData_v4
+func RecoverTypedDataSigner(data apitypes.TypedData, sig []byte) (gethcommon.Address, error) {
+	hash, err := TypedDataHash(data)
+	if err != nil {
+		return gethcommon.Address{}, err
+	}
+	return RecoverHashSigner(hash, sig)
+}
+
+// VerifyTypedData indicates whether sig is an eth_signTypedData_v4 signature of typed data by addr
+func VerifyTypedData(addr gethcommon.Address, data apitypes.TypedData, sig []byte) (bool, error) {
+	signer, err := RecoverTypedDataSigner(data, sig)
+	if err != nil {
+		return false, err
+	}
+	return signer == addr, nil
+}
@@ -0,0 +1,95 @@
+//go:build !integration
+
+package keystore
+
+import (
+	"encoding/json"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mailTypedData is the example of EIP-712
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedData(t *testing.T) {
+	var data apitypes.TypedData
+	require.NoError(t, json.Unmarshal([]byte(mailTypedData), &data))
+
+	hash, err := TypedDataHash(data)
+	require.NoError(t, err)
+	assert.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", gethcommon.BytesToHash(hash).Hex())
+
+	sig := gethcommon.FromHex("0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b915621c")
+	cow := gethcommon.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826")
+
+	ok, err := VerifyTypedData(cow, data, sig)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	data.Message["contents"] = "Hello, Alice!"
+	ok, err = VerifyTypedData(cow, data, sig)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMessage(t *testing.T) {
+	key, err := gethcrypto.GenerateKey()
+	require.NoError(t, err)
+	addr := gethcrypto.PubkeyToAddress(key.PublicKey)
+
+	msg := []byte("hello")
+	sig, err := gethcrypto.Sign(MessageHash(msg), key)
+	require.NoError(t, err)
+
+	ethSig := ToEthSignature(sig)
+	assert.GreaterOrEqual(t, ethSig[64], byte(27))
+	assert.Equal(t, sig[64], ethSig[64]-27)
+
+	for _, s := range [][]byte{sig, ethSig} {
+		ok, err := VerifyMessage(addr, msg, s)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	ok, err := VerifyMessage(addr, []byte("world"), ethSig)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = RecoverMessageSigner(msg, ethSig[:64])
+	assert.Error(t, err)
+}
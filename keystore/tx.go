@@ -0,0 +1,34 @@
+package keystore
+
+import (
+	"errors"
+	"fmt"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// ValidateBlobTx checks that an EIP-4844 transaction is consistent before it gets signed.
+//
+// The signature only commits to the versioned hashes, so Store implementations call it
+// to make sure the sidecar carried by the transaction (if any) matches those hashes.
+// It is a no-op for other transaction types.
+func ValidateBlobTx(tx *gethtypes.Transaction) error {
+	if tx.Type() != gethtypes.BlobTxType {
+		return nil
+	}
+
+	if len(tx.BlobHashes()) == 0 {
+		return errors.New("blob transaction has no blob hashes")
+	}
+
+	sidecar := tx.BlobTxSidecar()
+	if sidecar == nil {
+		return nil
+	}
+
+	if err := sidecar.ValidateBlobCommitmentHashes(tx.BlobHashes()); err != nil {
+		return fmt.Errorf("invalid blob transaction sidecar: %w", err)
+	}
+
+	return nil
+}
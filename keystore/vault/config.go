@@ -0,0 +1,54 @@
+//nolint:revive // package name intentionally reflects domain, not directory name
+package vaultkeystore
+
+import (
+	"github.com/kilnfi/go-utils/hashicorp"
+)
+
+type Config struct {
+	Vault    *hashicorp.ClientConfig `json:"vault"`
+	Password string                  `json:"-"`
+
+	// Signer is the address of the default signer (optional)
+	Signer string `json:"signer,omitempty"`
+}
+
+func (cfg *Config) SetDefault() *Config {
+	if cfg.Vault == nil {
+		cfg.Vault = &hashicorp.ClientConfig{}
+	}
+	cfg.Vault.SetDefault()
+
+	return cfg
+}
+
+type TransitConfig struct {
+	Vault *hashicorp.ClientConfig `json:"vault"`
+	Mount string                  `json:"mount"`
+
+	// KeyType is the type of the keys created in the engine (default ecdsa-p256k1)
+	//
+	// Stock Vault Transit (OSS and Enterprise) does not offer secp256k1 keys, Mount must
+	// point to a Transit compatible plugin providing ecdsa-p256k1 keys.
+	KeyType string `json:"keyType"`
+
+	// Signer is the address of the default signer (optional)
+	Signer string `json:"signer,omitempty"`
+}
+
+func (cfg *TransitConfig) SetDefault() *TransitConfig {
+	if cfg.Vault == nil {
+		cfg.Vault = &hashicorp.ClientConfig{}
+	}
+	cfg.Vault.SetDefault()
+
+	if cfg.Mount == "" {
+		cfg.Mount = "transit"
+	}
+
+	if cfg.KeyType == "" {
+		cfg.KeyType = "ecdsa-p256k1"
+	}
+
+	return cfg
+}
@@ -0,0 +1,105 @@
+//nolint:revive // package name intentionally reflects domain, not directory name
+package vaultkeystore
+
+import (
+	cmdutils "github.com/kilnfi/go-utils/cmd/utils"
+	"github.com/kilnfi/go-utils/hashicorp"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func Flags(v *viper.Viper, f *pflag.FlagSet) {
+	hashicorp.Flags(v, f)
+	KeystorePasswordFlag(v, f)
+	KeystoreSignerFlag(v, f)
+}
+
+func ConfigFromViper(v *viper.Viper) *Config {
+	return &Config{
+		Vault:    hashicorp.ClientConfigFromViper(v),
+		Password: GetKeystorePassword(v),
+		Signer:   GetKeystoreSigner(v),
+	}
+}
+
+func TransitFlags(v *viper.Viper, f *pflag.FlagSet) {
+	hashicorp.Flags(v, f)
+	TransitMountFlag(v, f)
+	KeystoreSignerFlag(v, f)
+}
+
+func TransitConfigFromViper(v *viper.Viper) *TransitConfig {
+	return &TransitConfig{
+		Vault:  hashicorp.ClientConfigFromViper(v),
+		Mount:  GetTransitMount(v),
+		Signer: GetKeystoreSigner(v),
+	}
+}
+
+const (
+	keyStorePasswordFlag     = "vault-keystore-password"
+	keyStorePasswordViperKey = "vault.keystore.password"
+	keyStorePasswordEnv      = "VAULT_KEYSTORE_PASSWORD"
+)
+
+// KeystorePasswordFlag register flag for the password used to encrypt keys stored in Vault
+func KeystorePasswordFlag(v *viper.Viper, f *pflag.FlagSet) {
+	f.String(keyStorePasswordFlag, "", cmdutils.FlagDesc("Password used to encrypt keys stored in Vault", keyStorePasswordEnv))
+
+	if err := v.BindPFlag(keyStorePasswordViperKey, f.Lookup(keyStorePasswordFlag)); err != nil {
+		panic(err)
+	}
+	if err := v.BindEnv(keyStorePasswordViperKey, keyStorePasswordEnv); err != nil {
+		panic(err)
+	}
+}
+
+func GetKeystorePassword(v *viper.Viper) string {
+	return v.GetString(keyStorePasswordViperKey)
+}
+
+const (
+	transitMountFlag     = "vault-transit-mount"
+	transitMountViperKey = "vault.transit.mount"
+	transitMountDefault  = "transit"
+	transitMountEnv      = "VAULT_TRANSIT_MOUNT"
+)
+
+// TransitMountFlag register flag for the mount path of the Vault Transit secret engine
+func TransitMountFlag(v *viper.Viper, f *pflag.FlagSet) {
+	f.String(transitMountFlag, transitMountDefault, cmdutils.FlagDesc("Vault Transit secret engine mount path", transitMountEnv))
+
+	if err := v.BindPFlag(transitMountViperKey, f.Lookup(transitMountFlag)); err != nil {
+		panic(err)
+	}
+	if err := v.BindEnv(transitMountViperKey, transitMountEnv); err != nil {
+		panic(err)
+	}
+	v.SetDefault(transitMountViperKey, transitMountDefault)
+}
+
+func GetTransitMount(v *viper.Viper) string {
+	return v.GetString(transitMountViperKey)
+}
+
+const (
+	keyStoreSignerFlag     = "vault-keystore-signer"
+	keyStoreSignerViperKey = "vault.keystore.signer"
+	keyStoreSignerEnv      = "VAULT_KEYSTORE_SIGNER"
+)
+
+// KeystoreSignerFlag register flag for the address of the default signer
+func KeystoreSignerFlag(v *viper.Viper, f *pflag.FlagSet) {
+	f.String(keyStoreSignerFlag, "", cmdutils.FlagDesc("Address of the default signer of keys stored in Vault (defaults to the first account)", keyStoreSignerEnv))
+
+	if err := v.BindPFlag(keyStoreSignerViperKey, f.Lookup(keyStoreSignerFlag)); err != nil {
+		panic(err)
+	}
+	if err := v.BindEnv(keyStoreSignerViperKey, keyStoreSignerEnv); err != nil {
+		panic(err)
+	}
+}
+
+func GetKeystoreSigner(v *viper.Viper) string {
+	return v.GetString(keyStoreSignerViperKey)
+}
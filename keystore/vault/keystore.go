@@ -0,0 +1,421 @@
+//revive:disable-next-line:package-directory-mismatch
+package vaultkeystore
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"path"
+	"strings"
+	"sync"
+
+	gethaccounts "github.com/ethereum/go-ethereum/accounts"
+	gethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/google/uuid"
+	"github.com/hashicorp/vault/api"
+	"github.com/kilnfi/go-utils/hashicorp"
+	"github.com/kilnfi/go-utils/keystore"
+)
+
+var _ keystore.Store = &KeyStore{}
+
+// Fields of the Vault secrets holding keys
+const (
+	addressField  = "address"
+	keystoreField = "keystore"
+)
+
+// KVv2 is the subset of hashicorp.KVv2Client used to store keys
+type KVv2 interface {
+	Put(ctx context.Context, id string, data map[string]interface{}) (*api.Secret, error)
+	Get(ctx context.Context, pth, version string) (secret *api.Secret, data, metadata map[string]interface{}, err error)
+	List(ctx context.Context, pth string) ([]string, error)
+	Delete(ctx context.Context, id string) error
+}
+
+var _ KVv2 = (*hashicorp.KVv2Client)(nil)
+
+// KeyStore stores secp256k1 keys in a Vault KV v2 secret engine
+//
+// Each key is stored under its lower case hex address, encrypted with the keystore password
+// in the Web3 Secret Storage format (the format of geth key files).
+// Keys are cached once decrypted.
+type KeyStore struct {
+	cfg    *Config
+	client KVv2
+
+	scryptN, scryptP int
+
+	// mu protects keys as well as cfg.Password and cfg.Signer which can be changed at runtime
+	mu   sync.RWMutex
+	keys map[gethcommon.Address]*gethkeystore.Key
+}
+
+// New creates a keystore storing keys with client, which is expected to be initialized
+func New(cfg *Config, client KVv2) *KeyStore {
+	return &KeyStore{
+		cfg:     cfg,
+		client:  client,
+		scryptN: gethkeystore.StandardScryptN,
+		scryptP: gethkeystore.StandardScryptP,
+		keys:    make(map[gethcommon.Address]*gethkeystore.Key),
+	}
+}
+
+func (s *KeyStore) CreateAccount(ctx context.Context) (*keystore.Account, error) {
+	priv, err := gethcrypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storeKey(ctx, priv)
+}
+
+// Import a secp256k1 private key in hexadecimal format
+func (s *KeyStore) Import(ctx context.Context, hexkey string) (*keystore.Account, error) {
+	priv, err := gethcrypto.HexToECDSA(hexkey)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := gethcrypto.PubkeyToAddress(priv.PublicKey)
+	ok, err := s.HasAccount(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return nil, gethkeystore.ErrAccountAlreadyExists
+	}
+
+	return s.storeKey(ctx, priv)
+}
+
+func (s *KeyStore) storeKey(ctx context.Context, priv *ecdsa.PrivateKey) (*keystore.Account, error) {
+	key := &gethkeystore.Key{
+		Id:         uuid.New(),
+		Address:    gethcrypto.PubkeyToAddress(priv.PublicKey),
+		PrivateKey: priv,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.putKey(ctx, key, s.cfg.Password); err != nil {
+		return nil, err
+	}
+
+	s.keys[key.Address] = key
+
+	return s.account(key.Address), nil
+}
+
+// putKey encrypts key with password and writes it in Vault
+func (s *KeyStore) putKey(ctx context.Context, key *gethkeystore.Key, password string) error {
+	keyJSON, err := gethkeystore.EncryptKey(key, password, s.scryptN, s.scryptP)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Put(ctx, secretID(key.Address), map[string]interface{}{
+		addressField:  key.Address.Hex(),
+		keystoreField: string(keyJSON),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store key in Vault: %w", err)
+	}
+
+	return nil
+}
+
+func (s *KeyStore) SignTx(ctx context.Context, addr gethcommon.Address, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
+	key, err := s.key(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := keystore.ValidateBlobTx(tx); err != nil {
+		return nil, err
+	}
+	return gethtypes.SignTx(tx, gethtypes.LatestSignerForChainID(chainID), key.PrivateKey)
+}
+
+// SignAuthorization signs an EIP-7702 set-code authorization with the key of addr
+func (s *KeyStore) SignAuthorization(ctx context.Context, addr gethcommon.Address, auth gethtypes.SetCodeAuthorization) (gethtypes.SetCodeAuthorization, error) {
+	key, err := s.key(ctx, addr)
+	if err != nil {
+		return gethtypes.SetCodeAuthorization{}, err
+	}
+	return gethtypes.SignSetCode(key.PrivateKey, auth)
+}
+
+// SignMessage signs msg with the key of addr as personal_sign (EIP-191)
+func (s *KeyStore) SignMessage(ctx context.Context, addr gethcommon.Address, msg []byte) ([]byte, error) {
+	return s.signHash(ctx, addr, keystore.MessageHash(msg))
+}
+
+// SignTypedData signs typed data with the key of addr as eth_signTypedData_v4 (EIP-712)
+func (s *KeyStore) SignTypedData(ctx context.Context, addr gethcommon.Address, data apitypes.TypedData) ([]byte, error) {
+	hash, err := keystore.TypedDataHash(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.signHash(ctx, addr, hash)
+}
+
+// signHash signs hash with the key of addr and returns a signature with V being 27 or 28
+func (s *KeyStore) signHash(ctx context.Context, addr gethcommon.Address, hash []byte) ([]byte, error) {
+	key, err := s.key(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := gethcrypto.Sign(hash, key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return keystore.ToEthSignature(sig), nil
+}
+
+func (s *KeyStore) HasAccount(ctx context.Context, addr gethcommon.Address) (bool, error) {
+	s.mu.RLock()
+	_, ok := s.keys[addr]
+	s.mu.RUnlock()
+	if ok {
+		return true, nil
+	}
+
+	_, _, _, err := s.client.Get(ctx, secretID(addr), "")
+	switch {
+	case errors.Is(err, hashicorp.ErrEmptySecret):
+		return false, nil
+	case err != nil:
+		return false, err
+	default:
+		return true, nil
+	}
+}
+
+// ListAccounts returns all accounts of the keystore ordered by address
+func (s *KeyStore) ListAccounts(ctx context.Context) ([]*keystore.Account, error) {
+	addrs, err := s.listAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accs := make([]*keystore.Account, len(addrs))
+	for i, addr := range addrs {
+		accs[i] = s.account(addr)
+	}
+
+	return accs, nil
+}
+
+// DeleteAccount permanently deletes all versions of the secret holding the key of addr
+func (s *KeyStore) DeleteAccount(ctx context.Context, addr gethcommon.Address) error {
+	ok, err := s.HasAccount(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no key for address %q", addr.String())
+	}
+
+	if err := s.client.Delete(ctx, secretID(addr)); err != nil {
+		return fmt.Errorf("failed to delete key in Vault: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, addr)
+	if gethcommon.IsHexAddress(s.cfg.Signer) && gethcommon.HexToAddress(s.cfg.Signer) == addr {
+		s.cfg.Signer = ""
+	}
+
+	return nil
+}
+
+// ExportAccount returns the key of addr encrypted with password
+func (s *KeyStore) ExportAccount(ctx context.Context, addr gethcommon.Address, password string) ([]byte, error) {
+	key, err := s.key(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	return gethkeystore.EncryptKey(key, password, gethkeystore.StandardScryptN, gethkeystore.StandardScryptP)
+}
+
+// ChangePassword re-encrypts all keys with newPassword
+//
+// All keys are decrypted before any is written so a key that can not be decrypted with the current
+// password leaves the keystore unchanged. If a write fails, keys already written are restored and
+// the returned error names the keys that could not be restored (i.e. left on newPassword).
+func (s *KeyStore) ChangePassword(ctx context.Context, newPassword string) error {
+	addrs, err := s.listAddresses(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*gethkeystore.Key, len(addrs))
+	for i, addr := range addrs {
+		if keys[i], err = s.key(ctx, addr); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, key := range keys {
+		if err := s.putKey(ctx, key, newPassword); err != nil {
+			errs := []error{fmt.Errorf("failed to change password of %q: %w", key.Address.String(), err)}
+			for _, updated := range keys[:i] {
+				if err := s.putKey(ctx, updated, s.cfg.Password); err != nil {
+					errs = append(errs, fmt.Errorf("failed to restore password of %q, key is left encrypted with the new password: %w", updated.Address.String(), err))
+				}
+			}
+			return errors.Join(errs...)
+		}
+	}
+
+	s.cfg.Password = newPassword
+
+	return nil
+}
+
+// SignerAddress returns the configured signer or the account with the lowest address
+func (s *KeyStore) SignerAddress(ctx context.Context) (gethcommon.Address, error) {
+	s.mu.RLock()
+	signer, err := keystore.ParseSignerAddress(s.cfg.Signer)
+	s.mu.RUnlock()
+	if err != nil {
+		return gethcommon.Address{}, err
+	}
+
+	if signer != (gethcommon.Address{}) {
+		ok, err := s.HasAccount(ctx, signer)
+		if err != nil {
+			return gethcommon.Address{}, err
+		}
+		if !ok {
+			return gethcommon.Address{}, fmt.Errorf("no key for signer %q", signer.String())
+		}
+		return signer, nil
+	}
+
+	addrs, err := s.listAddresses(ctx)
+	if err != nil {
+		return gethcommon.Address{}, err
+	}
+	if len(addrs) == 0 {
+		return gethcommon.Address{}, errors.New("keystore has no accounts")
+	}
+
+	return addrs[0], nil
+}
+
+// SetSignerAddress selects the default signer
+func (s *KeyStore) SetSignerAddress(ctx context.Context, addr gethcommon.Address) error {
+	ok, err := s.HasAccount(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no key for address %q", addr.String())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg.Signer = addr.Hex()
+
+	return nil
+}
+
+// listAddresses returns addresses of all keys stored in Vault
+func (s *KeyStore) listAddresses(ctx context.Context) ([]gethcommon.Address, error) {
+	ids, err := s.client.List(ctx, "")
+	// Vault returns no secret when there are no keys
+	if errors.Is(err, hashicorp.ErrEmptyList) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys in Vault: %w", err)
+	}
+
+	// Vault lists secrets in lexicographic order
+	var addrs []gethcommon.Address
+	for _, id := range ids {
+		if gethcommon.IsHexAddress(id) {
+			addrs = append(addrs, gethcommon.HexToAddress(id))
+		}
+	}
+
+	return addrs, nil
+}
+
+// key returns the decrypted key of addr
+func (s *KeyStore) key(ctx context.Context, addr gethcommon.Address) (*gethkeystore.Key, error) {
+	s.mu.RLock()
+	key, ok := s.keys[addr]
+	password := s.cfg.Password
+	s.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	_, data, _, err := s.client.Get(ctx, secretID(addr), "")
+	if errors.Is(err, hashicorp.ErrEmptySecret) {
+		return nil, fmt.Errorf("no key for address %q", addr.String())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key in Vault: %w", err)
+	}
+
+	keyJSON, ok := data[keystoreField].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid secret for address %q: missing %q field", addr.String(), keystoreField)
+	}
+
+	key, err = gethkeystore.DecryptKey([]byte(keyJSON), password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key of address %q: %w", addr.String(), err)
+	}
+
+	if key.Address != addr {
+		return nil, fmt.Errorf("secret for address %q holds key of address %q", addr.String(), key.Address.String())
+	}
+
+	s.mu.Lock()
+	s.keys[addr] = key
+	s.mu.Unlock()
+
+	return key, nil
+}
+
+func (s *KeyStore) account(addr gethcommon.Address) *keystore.Account {
+	return &keystore.Account{
+		Addr: addr,
+		URL:  s.url(addr),
+	}
+}
+
+func (s *KeyStore) url(addr gethcommon.Address) gethaccounts.URL {
+	pth := secretID(addr)
+	if s.cfg.Vault != nil {
+		pth = path.Join(s.cfg.Vault.Path, pth)
+	}
+
+	return gethaccounts.URL{
+		Scheme: "vault",
+		Path:   pth,
+	}
+}
+
+func secretID(addr gethcommon.Address) string {
+	return strings.ToLower(addr.Hex())
+}
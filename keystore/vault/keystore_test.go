@@ -0,0 +1,247 @@
+//go:build !integration
+
+//revive:disable-next-line:package-directory-mismatch
+package vaultkeystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+
+	gethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/hashicorp/vault/api"
+	"github.com/holiman/uint256"
+	"github.com/kilnfi/go-utils/hashicorp"
+	"github.com/kilnfi/go-utils/keystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memKVv2 is an in-memory KVv2
+type memKVv2 struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]interface{}
+}
+
+func newMemKVv2() *memKVv2 {
+	return &memKVv2{secrets: make(map[string]map[string]interface{})}
+}
+
+func (kv *memKVv2) Put(_ context.Context, id string, data map[string]interface{}) (*api.Secret, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.secrets[id] = data
+	return &api.Secret{}, nil
+}
+
+func (kv *memKVv2) Get(_ context.Context, pth, _ string) (secret *api.Secret, data, metadata map[string]interface{}, err error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	data, ok := kv.secrets[pth]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w at path %q", hashicorp.ErrEmptySecret, pth)
+	}
+	return &api.Secret{}, data, map[string]interface{}{}, nil
+}
+
+func (kv *memKVv2) Delete(_ context.Context, id string) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	delete(kv.secrets, id)
+	return nil
+}
+
+func (kv *memKVv2) List(_ context.Context, pth string) ([]string, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	// Vault returns no data when listing an empty path
+	if len(kv.secrets) == 0 {
+		return nil, fmt.Errorf("%w at path %q", hashicorp.ErrEmptyList, pth)
+	}
+	ids := make([]string, 0, len(kv.secrets))
+	for id := range kv.secrets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// failingKVv2 is a memKVv2 failing all writes after the first puts ones
+type failingKVv2 struct {
+	*memKVv2
+	puts int
+}
+
+func (kv *failingKVv2) Put(ctx context.Context, id string, data map[string]interface{}) (*api.Secret, error) {
+	if kv.puts == 0 {
+		return nil, errors.New("vault unavailable")
+	}
+	kv.puts--
+	return kv.memKVv2.Put(ctx, id, data)
+}
+
+func newTestKeyStore(kv KVv2, password string) *KeyStore {
+	s := New((&Config{Password: password}).SetDefault(), kv)
+	s.scryptN, s.scryptP = gethkeystore.LightScryptN, gethkeystore.LightScryptP
+	return s
+}
+
+func TestInterface(t *testing.T) {
+	assert.Implements(t, (*keystore.Store)(nil), new(KeyStore))
+}
+
+func TestSignTx(t *testing.T) {
+	kv := newMemKVv2()
+	keys := newTestKeyStore(kv, "test-pwd")
+
+	acc, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "vault", acc.URL.Scheme)
+
+	// keys are read back from Vault by a fresh keystore
+	keys = newTestKeyStore(kv, "test-pwd")
+
+	ok, err := keys.HasAccount(t.Context(), acc.Addr)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	tx, err := keys.SignTx(t.Context(), acc.Addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), tx.ChainId())
+
+	sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(big.NewInt(1)), tx)
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, sender)
+
+	auth, err := keys.SignAuthorization(t.Context(), acc.Addr, gethtypes.SetCodeAuthorization{ChainID: *uint256.NewInt(1)})
+	require.NoError(t, err)
+	authority, err := auth.Authority()
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, authority)
+
+	sig, err := keys.SignMessage(t.Context(), acc.Addr, []byte("order #1"))
+	require.NoError(t, err)
+	ok, err = keystore.VerifyMessage(acc.Addr, []byte("order #1"), sig)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestSignTxWrongPassword(t *testing.T) {
+	kv := newMemKVv2()
+
+	acc, err := newTestKeyStore(kv, "test-pwd").CreateAccount(t.Context())
+	require.NoError(t, err)
+
+	_, err = newTestKeyStore(kv, "wrong-pwd").SignTx(t.Context(), acc.Addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), big.NewInt(1))
+	assert.ErrorIs(t, err, gethkeystore.ErrDecrypt)
+}
+
+func TestSignTxMissingAddress(t *testing.T) {
+	keys := newTestKeyStore(newMemKVv2(), "test-pwd")
+
+	addr := gethcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	ok, err := keys.HasAccount(t.Context(), addr)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = keys.SignTx(t.Context(), addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), big.NewInt(1))
+	assert.Error(t, err)
+}
+
+func TestImport(t *testing.T) {
+	keys := newTestKeyStore(newMemKVv2(), "test-pwd")
+
+	accs, err := keys.ListAccounts(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, accs)
+	require.NoError(t, keys.ChangePassword(t.Context(), "test-pwd"))
+
+	_, err = keys.SignerAddress(t.Context())
+	assert.ErrorContains(t, err, "keystore has no accounts")
+
+	priv, err := gethcrypto.GenerateKey()
+	require.NoError(t, err)
+	hexkey := fmt.Sprintf("%x", gethcrypto.FromECDSA(priv))
+
+	acc, err := keys.Import(t.Context(), hexkey)
+	require.NoError(t, err)
+	assert.Equal(t, gethcrypto.PubkeyToAddress(priv.PublicKey), acc.Addr)
+
+	_, err = keys.Import(t.Context(), hexkey)
+	assert.ErrorIs(t, err, gethkeystore.ErrAccountAlreadyExists)
+
+	signer, err := keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, signer)
+}
+
+func TestAccountLifecycle(t *testing.T) {
+	kv := newMemKVv2()
+	keys := newTestKeyStore(kv, "test-pwd")
+
+	acc1, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+	acc2, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+
+	accs, err := keys.ListAccounts(t.Context())
+	require.NoError(t, err)
+	require.Len(t, accs, 2)
+	assert.Negative(t, accs[0].Addr.Cmp(accs[1].Addr))
+
+	signer, err := keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, accs[0].Addr, signer)
+
+	require.NoError(t, keys.SetSignerAddress(t.Context(), accs[1].Addr))
+	signer, err = keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, accs[1].Addr, signer)
+
+	keyJSON, err := keys.ExportAccount(t.Context(), acc1.Addr, "export-pwd")
+	require.NoError(t, err)
+	key, err := gethkeystore.DecryptKey(keyJSON, "export-pwd")
+	require.NoError(t, err)
+	assert.Equal(t, acc1.Addr, key.Address)
+
+	require.NoError(t, keys.ChangePassword(t.Context(), "new-pwd"))
+	_, err = newTestKeyStore(kv, "new-pwd").SignTx(t.Context(), acc2.Addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), big.NewInt(1))
+	require.NoError(t, err)
+	_, err = newTestKeyStore(kv, "test-pwd").SignTx(t.Context(), acc2.Addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), big.NewInt(1))
+	assert.ErrorIs(t, err, gethkeystore.ErrDecrypt)
+
+	require.NoError(t, keys.DeleteAccount(t.Context(), acc2.Addr))
+	ok, err := keys.HasAccount(t.Context(), acc2.Addr)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Error(t, keys.DeleteAccount(t.Context(), acc2.Addr))
+}
+
+func TestChangePasswordRollbackFailure(t *testing.T) {
+	kv := newMemKVv2()
+	keys := newTestKeyStore(kv, "test-pwd")
+	for range 2 {
+		_, err := keys.CreateAccount(t.Context())
+		require.NoError(t, err)
+	}
+	accs, err := keys.ListAccounts(t.Context())
+	require.NoError(t, err)
+
+	// first key is re-encrypted then Vault fails for the second key and the rollback
+	err = newTestKeyStore(&failingKVv2{memKVv2: kv, puts: 1}, "test-pwd").ChangePassword(t.Context(), "new-pwd")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, fmt.Sprintf("failed to change password of %q", accs[1].Addr.String()))
+	assert.ErrorContains(t, err, fmt.Sprintf("failed to restore password of %q", accs[0].Addr.String()))
+
+	_, err = newTestKeyStore(kv, "new-pwd").SignTx(t.Context(), accs[0].Addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), big.NewInt(1))
+	require.NoError(t, err)
+	_, err = newTestKeyStore(kv, "test-pwd").SignTx(t.Context(), accs[1].Addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), big.NewInt(1))
+	require.NoError(t, err)
+}
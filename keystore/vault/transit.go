@@ -0,0 +1,524 @@
+//revive:disable-next-line:package-directory-mismatch
+package vaultkeystore
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	gethaccounts "github.com/ethereum/go-ethereum/accounts"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/google/uuid"
+	"github.com/hashicorp/vault/api"
+	"github.com/kilnfi/go-utils/keystore"
+)
+
+var _ keystore.Store = &TransitKeyStore{}
+
+// ErrImportNotSupported is returned when importing a key in the Transit engine
+//
+// Keys of the Transit engine are generated by Vault and never leave it.
+var ErrImportNotSupported = fmt.Errorf("importing Transit keys: %w", keystore.ErrNotSupported)
+
+// ErrKeyTypeNotSupported is returned when the Transit engine does not support the configured key type
+//
+// Stock Vault Transit (OSS and Enterprise) has no secp256k1 keys: a Transit compatible plugin
+// providing ecdsa-p256k1 keys must be mounted (c.f. TransitConfig.KeyType).
+var ErrKeyTypeNotSupported = fmt.Errorf("creating Transit key: %w", keystore.ErrNotSupported)
+
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSecp256k1      = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+	secp256k1N     = gethcrypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// Logical is the subset of api.Logical used to call the Transit engine
+type Logical interface {
+	ReadWithContext(ctx context.Context, pth string) (*api.Secret, error)
+	WriteWithContext(ctx context.Context, pth string, data map[string]interface{}) (*api.Secret, error)
+	ListWithContext(ctx context.Context, pth string) (*api.Secret, error)
+	DeleteWithContext(ctx context.Context, pth string) (*api.Secret, error)
+}
+
+var _ Logical = (*api.Logical)(nil)
+
+// transitKey is a version of a Transit key
+type transitKey struct {
+	name    string
+	version int
+}
+
+// TransitKeyStore signs with secp256k1 keys of a Vault Transit secret engine
+//
+// Keys are generated by Vault and never leave it: the address of an account is derived
+// from the public key exported by Vault and hashes are signed with transit/sign.
+// The engine mounted at TransitConfig.Mount must support TransitConfig.KeyType keys, which
+// stock Vault Transit does not (c.f. ErrKeyTypeNotSupported).
+type TransitKeyStore struct {
+	cfg     *TransitConfig
+	logical Logical
+
+	// mu protects accounts and cfg.Signer which can be changed at runtime
+	mu       sync.RWMutex
+	accounts map[gethcommon.Address]transitKey
+}
+
+// NewTransit creates a keystore using the Transit engine through logical, which is expected to be authenticated
+func NewTransit(cfg *TransitConfig, logical Logical) *TransitKeyStore {
+	return &TransitKeyStore{
+		cfg:      cfg,
+		logical:  logical,
+		accounts: make(map[gethcommon.Address]transitKey),
+	}
+}
+
+// CreateAccount creates a new key in the Transit engine
+func (s *TransitKeyStore) CreateAccount(ctx context.Context) (*keystore.Account, error) {
+	name := uuid.New().String()
+
+	_, err := s.logical.WriteWithContext(ctx, s.keyPath(name), map[string]interface{}{
+		"type":       s.cfg.KeyType,
+		"exportable": false,
+	})
+	if err != nil {
+		// Vault rejects key types it does not know with a 400 "unknown key type" error
+		if strings.Contains(err.Error(), "unknown key type") {
+			return nil, fmt.Errorf("%w: key type %q at mount %q: %v", ErrKeyTypeNotSupported, s.cfg.KeyType, s.cfg.Mount, err)
+		}
+		return nil, fmt.Errorf("failed to create Transit key: %w", err)
+	}
+
+	addr, key, err := s.readKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.accounts[addr] = key
+	s.mu.Unlock()
+
+	return s.newAccount(addr, key), nil
+}
+
+func (s *TransitKeyStore) newAccount(addr gethcommon.Address, key transitKey) *keystore.Account {
+	return &keystore.Account{
+		Addr: addr,
+		URL:  s.url(key),
+	}
+}
+
+// Import always fails as key material of Transit keys never leaves Vault
+func (s *TransitKeyStore) Import(context.Context, string) (*keystore.Account, error) {
+	return nil, ErrImportNotSupported
+}
+
+func (s *TransitKeyStore) SignTx(ctx context.Context, addr gethcommon.Address, tx *gethtypes.Transaction, chainID *big.Int) (*gethtypes.Transaction, error) {
+	if err := keystore.ValidateBlobTx(tx); err != nil {
+		return nil, err
+	}
+
+	signer := gethtypes.LatestSignerForChainID(chainID)
+	sig, err := s.signHash(ctx, addr, signer.Hash(tx))
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.WithSignature(signer, sig)
+}
+
+// SignAuthorization signs an EIP-7702 set-code authorization with the key of addr
+func (s *TransitKeyStore) SignAuthorization(ctx context.Context, addr gethcommon.Address, auth gethtypes.SetCodeAuthorization) (gethtypes.SetCodeAuthorization, error) {
+	sig, err := s.signHash(ctx, addr, auth.SigHash())
+	if err != nil {
+		return gethtypes.SetCodeAuthorization{}, err
+	}
+
+	auth.R.SetBytes(sig[:32])
+	auth.S.SetBytes(sig[32:64])
+	auth.V = sig[64]
+
+	return auth, nil
+}
+
+// SignMessage signs msg with the Transit key of addr as personal_sign (EIP-191)
+func (s *TransitKeyStore) SignMessage(ctx context.Context, addr gethcommon.Address, msg []byte) ([]byte, error) {
+	sig, err := s.signHash(ctx, addr, gethcommon.BytesToHash(keystore.MessageHash(msg)))
+	if err != nil {
+		return nil, err
+	}
+	return keystore.ToEthSignature(sig), nil
+}
+
+// SignTypedData signs typed data with the Transit key of addr as eth_signTypedData_v4 (EIP-712)
+func (s *TransitKeyStore) SignTypedData(ctx context.Context, addr gethcommon.Address, data apitypes.TypedData) ([]byte, error) {
+	hash, err := keystore.TypedDataHash(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := s.signHash(ctx, addr, gethcommon.BytesToHash(hash))
+	if err != nil {
+		return nil, err
+	}
+	return keystore.ToEthSignature(sig), nil
+}
+
+func (s *TransitKeyStore) HasAccount(ctx context.Context, addr gethcommon.Address) (bool, error) {
+	_, ok, err := s.account(ctx, addr)
+	return ok, err
+}
+
+// ListAccounts returns the accounts of all keys of the Transit engine ordered by key name
+func (s *TransitKeyStore) ListAccounts(ctx context.Context) ([]*keystore.Account, error) {
+	names, err := s.listKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var accs []*keystore.Account
+	for _, name := range names {
+		addr, key, err := s.readKey(ctx, name)
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		s.accounts[addr] = key
+		s.mu.Unlock()
+
+		accs = append(accs, s.newAccount(addr, key))
+	}
+
+	return accs, nil
+}
+
+// DeleteAccount allows deletion of the Transit key of addr and deletes it
+func (s *TransitKeyStore) DeleteAccount(ctx context.Context, addr gethcommon.Address) error {
+	key, ok, err := s.account(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no key for address %q", addr.String())
+	}
+
+	_, err = s.logical.WriteWithContext(ctx, path.Join(s.keyPath(key.name), "config"), map[string]interface{}{
+		"deletion_allowed": true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to allow deletion of Transit key %q: %w", key.name, err)
+	}
+
+	if _, err := s.logical.DeleteWithContext(ctx, s.keyPath(key.name)); err != nil {
+		return fmt.Errorf("failed to delete Transit key %q: %w", key.name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.accounts, addr)
+	if gethcommon.IsHexAddress(s.cfg.Signer) && gethcommon.HexToAddress(s.cfg.Signer) == addr {
+		s.cfg.Signer = ""
+	}
+
+	return nil
+}
+
+// ExportAccount always fails as key material of Transit keys never leaves Vault
+func (s *TransitKeyStore) ExportAccount(context.Context, gethcommon.Address, string) ([]byte, error) {
+	return nil, fmt.Errorf("exporting Transit keys: %w", keystore.ErrNotSupported)
+}
+
+// ChangePassword always fails as Transit keys are not encrypted with a password
+func (s *TransitKeyStore) ChangePassword(context.Context, string) error {
+	return fmt.Errorf("changing password of Transit keys: %w", keystore.ErrNotSupported)
+}
+
+// SignerAddress returns the configured signer or the account of the first key of the Transit engine
+func (s *TransitKeyStore) SignerAddress(ctx context.Context) (gethcommon.Address, error) {
+	s.mu.RLock()
+	signer, err := keystore.ParseSignerAddress(s.cfg.Signer)
+	s.mu.RUnlock()
+	if err != nil {
+		return gethcommon.Address{}, err
+	}
+
+	if signer != (gethcommon.Address{}) {
+		_, ok, err := s.account(ctx, signer)
+		if err != nil {
+			return gethcommon.Address{}, err
+		}
+		if !ok {
+			return gethcommon.Address{}, fmt.Errorf("no key for signer %q", signer.String())
+		}
+		return signer, nil
+	}
+
+	names, err := s.listKeys(ctx)
+	if err != nil {
+		return gethcommon.Address{}, err
+	}
+
+	for _, name := range names {
+		addr, _, err := s.readKey(ctx, name)
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		return addr, err
+	}
+
+	return gethcommon.Address{}, errors.New("keystore has no accounts")
+}
+
+// SetSignerAddress selects the default signer
+func (s *TransitKeyStore) SetSignerAddress(ctx context.Context, addr gethcommon.Address) error {
+	_, ok, err := s.account(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no key for address %q", addr.String())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg.Signer = addr.Hex()
+
+	return nil
+}
+
+// signHash signs hash with the key of addr and returns a signature in the [R || S || V] format
+func (s *TransitKeyStore) signHash(ctx context.Context, addr gethcommon.Address, hash gethcommon.Hash) ([]byte, error) {
+	key, ok, err := s.account(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no key for address %q", addr.String())
+	}
+
+	secret, err := s.logical.WriteWithContext(ctx, path.Join(s.cfg.Mount, "sign", key.name), map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(hash[:]),
+		"prehashed":            true,
+		"key_version":          key.version,
+		"marshaling_algorithm": "asn1",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with Transit key %q: %w", key.name, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("empty signature for Transit key %q", key.name)
+	}
+
+	vaultSig, _ := secret.Data["signature"].(string)
+	r, sValue, err := parseTransitSignature(vaultSig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ethereum only accepts signatures with a low s (EIP-2)
+	if sValue.Cmp(secp256k1HalfN) > 0 {
+		sValue.Sub(secp256k1N, sValue)
+	}
+
+	sig := make([]byte, 65)
+	r.FillBytes(sig[:32])
+	sValue.FillBytes(sig[32:64])
+
+	// Transit does not return the recovery id so it is found by recovering the signer
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		pub, err := gethcrypto.SigToPub(hash[:], sig)
+		if err == nil && gethcrypto.PubkeyToAddress(*pub) == addr {
+			return sig, nil
+		}
+	}
+
+	return nil, fmt.Errorf("signature of Transit key %q does not recover address %q", key.name, addr.String())
+}
+
+// account returns the Transit key of addr, reloading keys from Vault if it is unknown
+func (s *TransitKeyStore) account(ctx context.Context, addr gethcommon.Address) (transitKey, bool, error) {
+	s.mu.RLock()
+	key, ok := s.accounts[addr]
+	s.mu.RUnlock()
+	if ok {
+		return key, true, nil
+	}
+
+	if err := s.loadAccounts(ctx); err != nil {
+		return transitKey{}, false, err
+	}
+
+	s.mu.RLock()
+	key, ok = s.accounts[addr]
+	s.mu.RUnlock()
+
+	return key, ok, nil
+}
+
+// loadAccounts reads the public keys of all keys of the Transit engine
+func (s *TransitKeyStore) loadAccounts(ctx context.Context) error {
+	names, err := s.listKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	accounts := make(map[gethcommon.Address]transitKey, len(names))
+	for _, name := range names {
+		addr, key, err := s.readKey(ctx, name)
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		accounts[addr] = key
+	}
+
+	s.mu.Lock()
+	s.accounts = accounts
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *TransitKeyStore) listKeys(ctx context.Context) ([]string, error) {
+	secret, err := s.logical.ListWithContext(ctx, path.Join(s.cfg.Mount, "keys"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Transit keys: %w", err)
+	}
+
+	// Vault returns no secret when there are no keys
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	keys, _ := secret.Data["keys"].([]interface{})
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = fmt.Sprintf("%v", key)
+	}
+
+	return names, nil
+}
+
+var errUnsupportedKey = errors.New("unsupported Transit key")
+
+// readKey reads the latest version of a Transit key and derives its address
+func (s *TransitKeyStore) readKey(ctx context.Context, name string) (gethcommon.Address, transitKey, error) {
+	secret, err := s.logical.ReadWithContext(ctx, s.keyPath(name))
+	if err != nil {
+		return gethcommon.Address{}, transitKey{}, fmt.Errorf("failed to read Transit key %q: %w", name, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return gethcommon.Address{}, transitKey{}, fmt.Errorf("transit key %q not found", name)
+	}
+
+	if typ, _ := secret.Data["type"].(string); typ != s.cfg.KeyType {
+		return gethcommon.Address{}, transitKey{}, fmt.Errorf("%w: key %q has type %q", errUnsupportedKey, name, typ)
+	}
+
+	version, err := strconv.Atoi(fmt.Sprintf("%v", secret.Data["latest_version"]))
+	if err != nil {
+		return gethcommon.Address{}, transitKey{}, fmt.Errorf("invalid latest version of Transit key %q: %w", name, err)
+	}
+
+	versions, _ := secret.Data["keys"].(map[string]interface{})
+	keyVersion, _ := versions[strconv.Itoa(version)].(map[string]interface{})
+	pemKey, _ := keyVersion["public_key"].(string)
+
+	pub, err := parsePublicKey(pemKey)
+	if err != nil {
+		return gethcommon.Address{}, transitKey{}, fmt.Errorf("invalid public key of Transit key %q: %w", name, err)
+	}
+
+	return gethcrypto.PubkeyToAddress(*pub), transitKey{name: name, version: version}, nil
+}
+
+func (s *TransitKeyStore) keyPath(name string) string {
+	return path.Join(s.cfg.Mount, "keys", name)
+}
+
+func (s *TransitKeyStore) url(key transitKey) gethaccounts.URL {
+	return gethaccounts.URL{
+		Scheme: "vault",
+		Path:   fmt.Sprintf("%v:v%v", s.keyPath(key.name), key.version),
+	}
+}
+
+// parsePublicKey parses a PEM encoded secp256k1 public key
+//
+// crypto/x509 does not support secp256k1 so the public key info is decoded manually.
+func parsePublicKey(pemKey string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		return nil, err
+	}
+
+	if !info.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, fmt.Errorf("unexpected public key algorithm %v", info.Algorithm.Algorithm)
+	}
+
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &curve); err != nil {
+		return nil, err
+	}
+	if !curve.Equal(oidSecp256k1) {
+		return nil, fmt.Errorf("unexpected curve %v", curve)
+	}
+
+	return gethcrypto.UnmarshalPubkey(info.PublicKey.Bytes)
+}
+
+// parseTransitSignature parses a signature in the vault:v<version>:<base64 ASN.1> format
+func parseTransitSignature(vaultSig string) (r, s *big.Int, err error) {
+	parts := strings.Split(vaultSig, ":")
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, nil, fmt.Errorf("invalid Transit signature %q", vaultSig)
+	}
+
+	der, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid Transit signature encoding: %w", err)
+	}
+
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, nil, fmt.Errorf("invalid Transit signature: %w", err)
+	}
+
+	if sig.R.Sign() <= 0 || sig.S.Sign() <= 0 || sig.R.Cmp(secp256k1N) >= 0 || sig.S.Cmp(secp256k1N) >= 0 {
+		return nil, nil, errors.New("transit signature out of range")
+	}
+
+	return sig.R, sig.S, nil
+}
@@ -0,0 +1,92 @@
+//go:build integration
+// +build integration
+
+package vaultkeystore_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/hashicorp/vault/api"
+	kilndocker "github.com/kilnfi/go-utils/docker"
+	"github.com/kilnfi/go-utils/hashicorp"
+	vaultkeystore "github.com/kilnfi/go-utils/keystore/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prepareComposeVault starts a Vault server in dev mode with a Transit engine mounted
+func prepareComposeVault(t *testing.T) *hashicorp.KVv2Client {
+	cfg := (&kilndocker.ComposeConfig{
+		Namespace: "test.vault",
+	}).SetDefault()
+	compose, err := kilndocker.NewCompose(cfg)
+	require.NoError(t, err)
+
+	opts := new(kilndocker.VaultServiceOpts).SetDefault()
+	svcCfg, err := kilndocker.NewVaultServiceConfig(opts)
+	require.NoError(t, err)
+	svcCfg.Host.AutoRemove = true
+
+	svcName := "vault"
+	compose.RegisterService(svcName, svcCfg)
+
+	err = compose.Up(context.TODO())
+	require.NoError(t, err, "Up must not error")
+
+	t.Cleanup(func() {
+		err = compose.Down(context.TODO())
+		require.NoError(t, err, "Down must not error")
+	})
+
+	err = compose.WaitContainer(context.TODO(), svcName, 10*time.Second)
+	require.NoError(t, err, "WaitContainer must not error")
+
+	container, err := compose.GetContainer(context.TODO(), svcName)
+	require.NoError(t, err, "GetContainer must not error")
+
+	addr, err := opts.Addr(container)
+	require.NoError(t, err)
+
+	client, err := hashicorp.NewKVv2Client((&hashicorp.ClientConfig{
+		Address: addr,
+		Auth:    &hashicorp.AuthConfig{Token: opts.RootToken},
+	}).SetDefault())
+	require.NoError(t, err)
+
+	err = client.Authenticate(context.TODO())
+	require.NoError(t, err)
+
+	err = client.Sys().MountWithContext(context.TODO(), "transit", &api.MountInput{Type: "transit"})
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestTransitKeyStore(t *testing.T) {
+	client := prepareComposeVault(t)
+	keys := vaultkeystore.NewTransit((&vaultkeystore.TransitConfig{}).SetDefault(), client.Logical())
+
+	acc, err := keys.CreateAccount(context.TODO())
+	if errors.Is(err, vaultkeystore.ErrKeyTypeNotSupported) {
+		// stock Vault Transit (OSS and Enterprise) has no secp256k1 keys
+		t.Skipf("Vault Transit engine does not support ecdsa-p256k1 keys, mount a Transit plugin providing them: %v", err)
+	}
+	require.NoError(t, err)
+
+	signer, err := keys.SignerAddress(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, signer)
+
+	chainID := big.NewInt(1)
+	tx, err := keys.SignTx(context.TODO(), acc.Addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), chainID)
+	require.NoError(t, err)
+
+	sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(chainID), tx)
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, sender)
+}
@@ -0,0 +1,296 @@
+//go:build !integration
+
+//revive:disable-next-line:package-directory-mismatch
+package vaultkeystore
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/hashicorp/vault/api"
+	"github.com/holiman/uint256"
+	"github.com/kilnfi/go-utils/keystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memTransit is an in-memory Transit engine mounted at "transit"
+//
+// It returns signatures with a high s every other time to check they are normalized.
+type memTransit struct {
+	mu        sync.Mutex
+	keys      map[string]*ecdsa.PrivateKey
+	deletable map[string]bool
+	signs     int
+}
+
+func newMemTransit() *memTransit {
+	return &memTransit{
+		keys:      make(map[string]*ecdsa.PrivateKey),
+		deletable: make(map[string]bool),
+	}
+}
+
+func (tr *memTransit) ReadWithContext(_ context.Context, pth string) (*api.Secret, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	key, ok := tr.keys[strings.TrimPrefix(pth, "transit/keys/")]
+	if !ok {
+		return nil, nil
+	}
+
+	pemKey, err := marshalPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Secret{Data: map[string]interface{}{
+		"type":           "ecdsa-p256k1",
+		"latest_version": json.Number("1"),
+		"keys": map[string]interface{}{
+			"1": map[string]interface{}{"public_key": pemKey},
+		},
+	}}, nil
+}
+
+func (tr *memTransit) WriteWithContext(_ context.Context, pth string, data map[string]interface{}) (*api.Secret, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if name, ok := strings.CutSuffix(strings.TrimPrefix(pth, "transit/keys/"), "/config"); ok {
+		tr.deletable[name] = data["deletion_allowed"] == true
+		return nil, nil
+	}
+
+	switch dir, name := path.Split(pth); dir {
+	case "transit/keys/":
+		if data["type"] != "ecdsa-p256k1" {
+			return nil, fmt.Errorf("Error making API request.\n\nCode: 400. Errors:\n\n* unknown key type %v", data["type"])
+		}
+		key, err := gethcrypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		tr.keys[name] = key
+		return nil, nil
+	case "transit/sign/":
+		key, ok := tr.keys[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %v", name)
+		}
+		hash, err := base64.StdEncoding.DecodeString(data["input"].(string))
+		if err != nil {
+			return nil, err
+		}
+		sig, err := gethcrypto.Sign(hash, key)
+		if err != nil {
+			return nil, err
+		}
+
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+		tr.signs++
+		if tr.signs%2 == 0 {
+			s.Sub(secp256k1N, s)
+		}
+
+		der, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+		if err != nil {
+			return nil, err
+		}
+		return &api.Secret{Data: map[string]interface{}{
+			"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(der),
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unexpected path %v", pth)
+	}
+}
+
+func (tr *memTransit) DeleteWithContext(_ context.Context, pth string) (*api.Secret, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	name := strings.TrimPrefix(pth, "transit/keys/")
+	if !tr.deletable[name] {
+		return nil, fmt.Errorf("deletion is not allowed for key %v", name)
+	}
+	delete(tr.keys, name)
+
+	return nil, nil
+}
+
+func (tr *memTransit) ListWithContext(context.Context, string) (*api.Secret, error) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if len(tr.keys) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(tr.keys))
+	for name := range tr.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	keys := make([]interface{}, len(names))
+	for i, name := range names {
+		keys[i] = name
+	}
+
+	return &api.Secret{Data: map[string]interface{}{"keys": keys}}, nil
+}
+
+func marshalPublicKey(pub *ecdsa.PublicKey) (string, error) {
+	curve, err := asn1.Marshal(oidSecp256k1)
+	if err != nil {
+		return "", err
+	}
+
+	der, err := asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: curve},
+		},
+		PublicKey: asn1.BitString{Bytes: gethcrypto.FromECDSAPub(pub), BitLength: 8 * 65},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+func TestTransitInterface(t *testing.T) {
+	assert.Implements(t, (*keystore.Store)(nil), new(TransitKeyStore))
+}
+
+func TestTransitSignTx(t *testing.T) {
+	transit := newMemTransit()
+	keys := NewTransit((&TransitConfig{}).SetDefault(), transit)
+
+	_, err := keys.SignerAddress(t.Context())
+	assert.Error(t, err)
+
+	acc, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+
+	// accounts are loaded from Vault by a fresh keystore
+	keys = NewTransit((&TransitConfig{}).SetDefault(), transit)
+
+	ok, err := keys.HasAccount(t.Context(), acc.Addr)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	signer, err := keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, signer)
+
+	chainID := big.NewInt(17000)
+	for i := 0; i < 4; i++ {
+		tx, err := keys.SignTx(t.Context(), acc.Addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{Nonce: uint64(i)}), chainID)
+		require.NoError(t, err)
+
+		_, _, s := tx.RawSignatureValues()
+		assert.LessOrEqual(t, s.Cmp(secp256k1HalfN), 0)
+
+		sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(chainID), tx)
+		require.NoError(t, err)
+		assert.Equal(t, acc.Addr, sender)
+	}
+
+	auth, err := keys.SignAuthorization(t.Context(), acc.Addr, gethtypes.SetCodeAuthorization{ChainID: *uint256.NewInt(1)})
+	require.NoError(t, err)
+	authority, err := auth.Authority()
+	require.NoError(t, err)
+	assert.Equal(t, acc.Addr, authority)
+
+	sig, err := keys.SignMessage(t.Context(), acc.Addr, []byte("order #1"))
+	require.NoError(t, err)
+	ok, err = keystore.VerifyMessage(acc.Addr, []byte("order #1"), sig)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestTransitSignTxMissingAddress(t *testing.T) {
+	keys := NewTransit((&TransitConfig{}).SetDefault(), newMemTransit())
+
+	addr := gethcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	ok, err := keys.HasAccount(t.Context(), addr)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = keys.SignTx(t.Context(), addr, gethtypes.NewTx(&gethtypes.DynamicFeeTx{}), big.NewInt(1))
+	assert.Error(t, err)
+
+	_, err = keys.Import(t.Context(), "")
+	assert.ErrorIs(t, err, ErrImportNotSupported)
+}
+
+func TestTransitCreateAccountKeyTypeNotSupported(t *testing.T) {
+	keys := NewTransit((&TransitConfig{KeyType: "ecdsa-p256"}).SetDefault(), newMemTransit())
+
+	_, err := keys.CreateAccount(t.Context())
+	assert.ErrorIs(t, err, ErrKeyTypeNotSupported)
+	assert.ErrorIs(t, err, keystore.ErrNotSupported)
+}
+
+func TestParseTransitSignature(t *testing.T) {
+	_, _, err := parseTransitSignature("invalid")
+	assert.Error(t, err)
+
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{big.NewInt(1), secp256k1N})
+	require.NoError(t, err)
+	_, _, err = parseTransitSignature("vault:v1:" + base64.StdEncoding.EncodeToString(der))
+	assert.Error(t, err)
+}
+
+func TestTransitAccountLifecycle(t *testing.T) {
+	keys := NewTransit((&TransitConfig{}).SetDefault(), newMemTransit())
+
+	acc1, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+	acc2, err := keys.CreateAccount(t.Context())
+	require.NoError(t, err)
+
+	accs, err := keys.ListAccounts(t.Context())
+	require.NoError(t, err)
+	require.Len(t, accs, 2)
+
+	require.NoError(t, keys.SetSignerAddress(t.Context(), acc2.Addr))
+	signer, err := keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, acc2.Addr, signer)
+
+	_, err = keys.ExportAccount(t.Context(), acc1.Addr, "pwd")
+	assert.ErrorIs(t, err, keystore.ErrNotSupported)
+	assert.ErrorIs(t, keys.ChangePassword(t.Context(), "pwd"), keystore.ErrNotSupported)
+
+	require.NoError(t, keys.DeleteAccount(t.Context(), acc2.Addr))
+	accs, err = keys.ListAccounts(t.Context())
+	require.NoError(t, err)
+	require.Len(t, accs, 1)
+	assert.Equal(t, acc1.Addr, accs[0].Addr)
+
+	signer, err = keys.SignerAddress(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, acc1.Addr, signer)
+}
@@ -6,7 +6,7 @@ import "context"
 
 type Client interface {
 	// Call performs a JSON-RPC call with the given request and store result in res
-
+	//
 	// res MUST be a pointer so JSON-RPC result can be unmarshalled into res. You
 	// can also pass nil, in which case the result is ignored.
 	Call(ctx context.Context, req *Request, res interface{}) error
@@ -17,3 +17,26 @@ type ClientFunc func(ctx context.Context, req *Request, res interface{}) error
 func (f ClientFunc) Call(ctx context.Context, req *Request, res interface{}) error {
 	return f(ctx, req, res)
 }
+
+// BatchElem is a request sent as part of a batch
+type BatchElem struct {
+	Req *Request
+
+	// Result MUST be a pointer so JSON-RPC result can be unmarshalled into it. You
+	// can also pass nil, in which case the result is ignored.
+	Result interface{}
+
+	// Error is set if the request failed
+	Error error
+}
+
+// BatchClient is a JSON-RPC client able to send batch requests
+type BatchClient interface {
+	Client
+
+	// CallBatch sends all requests in a single JSON-RPC batch
+	//
+	// It returns an error only if the batch as a whole failed, errors of
+	// individual requests are set on each BatchElem.
+	CallBatch(ctx context.Context, elems []*BatchElem) error
+}
@@ -3,17 +3,60 @@ package jsonrpc
 import (
 	"context"
 	"sync/atomic"
+	"time"
 )
 
+// ClientDecorator decorates a client
+//
+// Decorators of this package preserve batch support: if the decorated client is a BatchClient,
+// so is the returned client and batches are decorated as well.
 type ClientDecorator func(Client) Client
 
+// BatchCallFunc sends a batch of requests through next
+type BatchCallFunc func(ctx context.Context, elems []*BatchElem, next BatchClient) error
+
+// batchClientFunc is a BatchClient sending single calls with call and batches with callBatch
+type batchClientFunc struct {
+	call      ClientFunc
+	callBatch BatchCallFunc
+	next      BatchClient
+}
+
+func (c *batchClientFunc) Call(ctx context.Context, req *Request, res interface{}) error {
+	return c.call(ctx, req, res)
+}
+
+func (c *batchClientFunc) CallBatch(ctx context.Context, elems []*BatchElem) error {
+	return c.callBatch(ctx, elems, c.next)
+}
+
+// Decorate returns a client sending calls with call
+//
+// If c is a BatchClient, the returned client is a BatchClient sending batches with callBatch
+// (which is given c to send the batch to), otherwise batches are not supported.
+func Decorate(c Client, call ClientFunc, callBatch BatchCallFunc) Client {
+	if next, ok := c.(BatchClient); ok {
+		return &batchClientFunc{call: call, callBatch: callBatch, next: next}
+	}
+	return call
+}
+
 // WithVersion automatically set JSON-RPC request version
 func WithVersion(v string) ClientDecorator {
 	return func(c Client) Client {
-		return ClientFunc(func(ctx context.Context, req *Request, res interface{}) error {
-			req.Version = v
-			return c.Call(ctx, req, res)
-		})
+		return Decorate(
+			c,
+			func(ctx context.Context, req *Request, res interface{}) error {
+				req.Version = v
+				return c.Call(ctx, req, res)
+			},
+			func(ctx context.Context, elems []*BatchElem, next BatchClient) error {
+				for _, elem := range elems {
+					elem.Req.Version = v
+				}
+				return next.CallBatch(ctx, elems)
+			},
+		)
 	}
 }
 
@@ -21,9 +64,48 @@ func WithVersion(v string) ClientDecorator {
 func WithIncrementalID() ClientDecorator {
 	var idCounter uint32
 	return func(c Client) Client {
-		return ClientFunc(func(ctx context.Context, req *Request, res interface{}) error {
-			req.ID = atomic.AddUint32(&idCounter, 1) - 1
-			return c.Call(ctx, req, res)
-		})
+		return Decorate(
+			c,
+			func(ctx context.Context, req *Request, res interface{}) error {
+				req.ID = atomic.AddUint32(&idCounter, 1) - 1
+				return c.Call(ctx, req, res)
+			},
+			func(ctx context.Context, elems []*BatchElem, next BatchClient) error {
+				for _, elem := range elems {
+					elem.Req.ID = atomic.AddUint32(&idCounter, 1) - 1
+				}
+				return next.CallBatch(ctx, elems)
+			},
+		)
+	}
+}
+
+// WithTimeout bounds each call (or batch) to timeout
+//
+// The deadline is carried by the context down to the HTTP request, it is left untouched
+// if the context already has an earlier deadline.
+func WithTimeout(timeout time.Duration) ClientDecorator {
+	return func(c Client) Client {
+		return Decorate(
+			c,
+			func(ctx context.Context, req *Request, res interface{}) error {
+				ctx, cancel := context.WithTimeout(ctx, timeout)
+				defer cancel()
+				return c.Call(ctx, req, res)
+			},
+			func(ctx context.Context, elems []*BatchElem, next BatchClient) error {
+				ctx, cancel := context.WithTimeout(ctx, timeout)
+				defer cancel()
+				return next.CallBatch(ctx, elems)
+			},
+		)
+	}
+}
+
+// Chain applies decorators to c, the first decorator being the outermost
+func Chain(c Client, decorators ...ClientDecorator) Client {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		c = decorators[i](c)
 	}
+	return c
 }
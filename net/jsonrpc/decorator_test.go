@@ -3,11 +3,23 @@
 package jsonrpc_test
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/golang/mock/gomock"
+	kilntypes "github.com/kilnfi/go-utils/common/types"
 	"github.com/kilnfi/go-utils/net/jsonrpc"
 	jsonrpctestutils "github.com/kilnfi/go-utils/net/jsonrpc/testutils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,3 +59,223 @@ func TestWithIncrementalID(t *testing.T) {
 	err = c.Call(t.Context(), &jsonrpc.Request{}, nil)
 	require.NoError(t, err)
 }
+
+func testRetryConfig() *jsonrpc.RetryConfig {
+	return (&jsonrpc.RetryConfig{
+		MaxRetries:   2,
+		MinRetryWait: &kilntypes.Duration{Duration: time.Millisecond},
+		MaxRetryWait: &kilntypes.Duration{Duration: time.Millisecond},
+	}).SetDefault()
+}
+
+func TestWithRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCli := jsonrpctestutils.NewMockClient(ctrl)
+	c := jsonrpc.WithRetry(testRetryConfig())(mockCli)
+
+	t.Run("retryable code", func(t *testing.T) {
+		gomock.InOrder(
+			mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(&jsonrpc.ErrorMsg{Code: -32005, Message: "limit exceeded"}),
+			mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+		)
+		require.NoError(t, c.Call(t.Context(), &jsonrpc.Request{Method: "eth_blockNumber"}, nil))
+	})
+
+	t.Run("retryable HTTP status", func(t *testing.T) {
+		httpErr := autorest.NewErrorWithError(errors.New("unavailable"), "test", "Call", &http.Response{StatusCode: http.StatusServiceUnavailable}, "Response")
+		mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(httpErr).Times(3)
+
+		err := c.Call(t.Context(), &jsonrpc.Request{Method: "eth_blockNumber"}, nil)
+		assert.Equal(t, httpErr, err)
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		gomock.InOrder(
+			mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(&url.Error{Op: "Post", URL: "http://localhost", Err: errors.New("connection refused")}),
+			mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+		)
+		require.NoError(t, c.Call(t.Context(), &jsonrpc.Request{Method: "eth_blockNumber"}, nil))
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		revertErr := &jsonrpc.ErrorMsg{Code: 3, Message: "execution reverted"}
+		mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(revertErr)
+
+		err := c.Call(t.Context(), &jsonrpc.Request{Method: "eth_call"}, nil)
+		assert.Equal(t, revertErr, err)
+	})
+}
+
+func TestWithRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCli := jsonrpctestutils.NewMockClient(ctrl)
+	c := jsonrpc.WithRateLimit((&jsonrpc.RateLimitConfig{
+		Methods: map[string]*jsonrpc.RateLimit{
+			"eth_getLogs": {Rate: 0.001, Burst: 1},
+		},
+	}).SetDefault())(mockCli)
+
+	mockCli.EXPECT().Call(gomock.Any(), jsonrpctestutils.HasMethod("eth_getLogs"), gomock.Any()).Return(nil)
+	mockCli.EXPECT().Call(gomock.Any(), jsonrpctestutils.HasMethod("eth_blockNumber"), gomock.Any()).Return(nil).Times(3)
+
+	require.NoError(t, c.Call(t.Context(), &jsonrpc.Request{Method: "eth_getLogs"}, nil))
+
+	// bucket of eth_getLogs is empty
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	require.Error(t, c.Call(ctx, &jsonrpc.Request{Method: "eth_getLogs"}, nil))
+
+	// other methods are not limited
+	for i := 0; i < 3; i++ {
+		require.NoError(t, c.Call(t.Context(), &jsonrpc.Request{Method: "eth_blockNumber"}, nil))
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCli := jsonrpctestutils.NewMockClient(ctrl)
+	metrics := jsonrpc.NewMetrics()
+	reg := prometheus.NewRegistry()
+	require.NoError(t, metrics.RegisterMetrics(reg))
+	// metrics can be shared by several clients
+	require.NoError(t, jsonrpc.NewMetrics().RegisterMetrics(reg))
+
+	c := jsonrpc.WithMetrics(metrics)(mockCli)
+
+	mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(&jsonrpc.ErrorMsg{Code: -32000, Message: "header not found"})
+
+	require.NoError(t, c.Call(t.Context(), &jsonrpc.Request{Method: "eth_call"}, nil))
+	require.Error(t, c.Call(t.Context(), &jsonrpc.Request{Method: "eth_call"}, nil))
+
+	assert.Equal(t, 2, testutil.CollectAndCount(reg, "jsonrpc_request_duration_seconds"))
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "jsonrpc_request_errors_total"))
+}
+
+func TestWithLogging(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger, hook := logrustest.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+
+	mockCli := jsonrpctestutils.NewMockClient(ctrl)
+	c := jsonrpc.WithLogging(logger, &jsonrpc.LogConfig{MaxSize: 8})(mockCli)
+
+	mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *jsonrpc.Request, res interface{}) error {
+		*(res.(*string)) = "0x0123456789"
+		return nil
+	})
+
+	var res string
+	require.NoError(t, c.Call(t.Context(), &jsonrpc.Request{Method: "eth_call", Params: []string{"0xabcdef"}}, &res))
+
+	require.Len(t, hook.Entries, 1)
+	assert.Equal(t, logrus.DebugLevel, hook.LastEntry().Level)
+	assert.Equal(t, `["0xabcd...(truncated)`, hook.LastEntry().Data["req.params"])
+	assert.Equal(t, `"0x01234...(truncated)`, hook.LastEntry().Data["res"])
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCli := jsonrpctestutils.NewMockClient(ctrl)
+	c := jsonrpc.WithTimeout(time.Second)(mockCli)
+
+	mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ *jsonrpc.Request, _ interface{}) error {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+		return nil
+	})
+
+	require.NoError(t, c.Call(t.Context(), &jsonrpc.Request{Method: "eth_call"}, nil))
+}
+
+func TestRetryTimedOutAttempt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCli := jsonrpctestutils.NewMockClient(ctrl)
+	c := jsonrpc.Chain(mockCli, jsonrpc.WithRetry(testRetryConfig()), jsonrpc.WithTimeout(20*time.Millisecond))
+
+	gomock.InOrder(
+		mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ *jsonrpc.Request, _ interface{}) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+		mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+	)
+	require.NoError(t, c.Call(t.Context(), &jsonrpc.Request{Method: "eth_call"}, nil))
+
+	// calls are not retried once the caller context is done
+	ctx, cancel := context.WithCancel(t.Context())
+	mockCli.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *jsonrpc.Request, interface{}) error {
+		cancel()
+		return context.Canceled
+	})
+	assert.ErrorIs(t, c.Call(ctx, &jsonrpc.Request{Method: "eth_call"}, nil), context.Canceled)
+}
+
+func TestChainBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	metrics := jsonrpc.NewMetrics()
+	reg := prometheus.NewRegistry()
+	require.NoError(t, metrics.RegisterMetrics(reg))
+
+	mockCli := jsonrpctestutils.NewMockBatchClient(ctrl)
+	c := jsonrpc.Chain(
+		mockCli,
+		jsonrpc.WithIncrementalID(),
+		jsonrpc.WithVersion("2.0"),
+		jsonrpc.WithRetry(testRetryConfig()),
+		jsonrpc.WithRateLimit((&jsonrpc.RateLimitConfig{
+			Methods: map[string]*jsonrpc.RateLimit{
+				"eth_getLogs": {Rate: 0.001, Burst: 2},
+			},
+		}).SetDefault()),
+		jsonrpc.WithMetrics(metrics),
+		jsonrpc.WithTimeout(time.Second),
+	)
+
+	batchCli, ok := c.(jsonrpc.BatchClient)
+	require.True(t, ok, "decorators preserve batch support")
+
+	gomock.InOrder(
+		mockCli.EXPECT().CallBatch(gomock.Any(), gomock.Any()).Return(&jsonrpc.ErrorMsg{Code: -32005, Message: "limit exceeded"}),
+		mockCli.EXPECT().CallBatch(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, elems []*jsonrpc.BatchElem) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok, "batch is bounded by timeout")
+			for i, elem := range elems {
+				assert.Equal(t, "2.0", elem.Req.Version)
+				assert.Equal(t, uint32(i), elem.Req.ID)
+			}
+			elems[1].Error = &jsonrpc.ErrorMsg{Code: -32000, Message: "execution reverted"}
+			return nil
+		}),
+	)
+
+	elems := []*jsonrpc.BatchElem{
+		{Req: &jsonrpc.Request{Method: "eth_getLogs"}},
+		{Req: &jsonrpc.Request{Method: "eth_call"}},
+	}
+	require.NoError(t, batchCli.CallBatch(t.Context(), elems))
+
+	// each request of each attempt is measured
+	assert.Equal(t, 3, testutil.CollectAndCount(reg, "jsonrpc_request_duration_seconds"))
+	assert.Equal(t, 3, testutil.CollectAndCount(reg, "jsonrpc_request_errors_total"))
+
+	// bucket of eth_getLogs has been emptied by both attempts so the batch is not sent
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	require.Error(t, batchCli.CallBatch(ctx, []*jsonrpc.BatchElem{{Req: &jsonrpc.Request{Method: "eth_getLogs"}}}))
+}
@@ -4,6 +4,7 @@ package jsonrpchttp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -83,13 +84,90 @@ func (c *Client) call(ctx context.Context, r *jsonrpc.Request, res interface{})
 	}
 	if err != nil {
 		msg, _ := json.Marshal(r)
-		return autorest.NewErrorWithError(err, "jsonrpchttp.Client", fmt.Sprintf("Call(%v)", string(msg)), resp, "Do")
+		return autorest.NewErrorWithError(withContextErr(ctx, err), "jsonrpchttp.Client", fmt.Sprintf("Call(%v)", string(msg)), resp, "Do")
 	}
 
 	err = inspectCallResponse(resp, res)
 	if err != nil {
 		msg, _ := json.Marshal(r)
-		return autorest.NewErrorWithError(err, "jsonrpchttp.Client", fmt.Sprintf("Call(%v)", string(msg)), resp, "Response")
+		return autorest.NewErrorWithError(withContextErr(ctx, err), "jsonrpchttp.Client", fmt.Sprintf("Call(%v)", string(msg)), resp, "Response")
+	}
+
+	return nil
+}
+
+// CallBatch performs a JSON-RPC batch call
+//
+// Requests with no ID are given their index in the batch as ID so responses can be matched
+func (c *Client) CallBatch(ctx context.Context, elems []*jsonrpc.BatchElem) error {
+	err := c.callBatch(ctx, elems)
+	if err != nil {
+		c.logger.
+			WithField("batch.size", len(elems)).
+			WithError(err).Errorf("jsonrpc batch call failed")
+	}
+
+	return err
+}
+
+func (c *Client) callBatch(ctx context.Context, elems []*jsonrpc.BatchElem) error {
+	reqs := make([]*jsonrpc.Request, len(elems))
+	ids := make(map[string]*jsonrpc.BatchElem, len(elems))
+	for i, elem := range elems {
+		if elem.Req.ID == nil {
+			elem.Req.ID = i
+		}
+
+		id, err := json.Marshal(elem.Req.ID)
+		if err != nil {
+			return autorest.NewErrorWithError(err, "jsonrpchttp.Client", "CallBatch", nil, "Request")
+		}
+		if _, ok := ids[string(id)]; ok {
+			return autorest.NewErrorWithError(fmt.Errorf("duplicated request ID %v", string(id)), "jsonrpchttp.Client", "CallBatch", nil, "Request")
+		}
+		ids[string(id)] = elem
+		reqs[i] = elem.Req
+	}
+
+	req, err := newBatchCallRequest(ctx, reqs)
+	if err != nil {
+		return autorest.NewErrorWithError(err, "jsonrpchttp.Client", "CallBatch", nil, "Request")
+	}
+
+	resp, err := c.client.Do(req)
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return autorest.NewErrorWithError(withContextErr(ctx, err), "jsonrpchttp.Client", "CallBatch", resp, "Do")
+	}
+
+	var msgs []*responseMsg
+	err = autorest.Respond(
+		resp,
+		autorest.WithErrorUnlessOK(),
+		autorest.ByUnmarshallingJSON(&msgs),
+		autorest.ByClosing(),
+	)
+	if err != nil {
+		return autorest.NewErrorWithError(withContextErr(ctx, err), "jsonrpchttp.Client", "CallBatch", resp, "Response")
+	}
+
+	for _, msg := range msgs {
+		if msg.ID == nil {
+			continue
+		}
+		elem, ok := ids[string(*msg.ID)]
+		if !ok {
+			continue
+		}
+		elem.Error = inspectCallResponseMsg(msg, elem.Result)
+		delete(ids, string(*msg.ID))
+	}
+
+	// requests with no response
+	for id, elem := range ids {
+		elem.Error = fmt.Errorf("missing JSON-RPC response for request %v", id)
 	}
 
 	return nil
@@ -105,6 +183,15 @@ func newCallRequest(ctx context.Context, req *jsonrpc.Request) (*http.Request, e
 	).Prepare(newRequest(ctx))
 }
 
+func newBatchCallRequest(ctx context.Context, reqs []*jsonrpc.Request) (*http.Request, error) {
+	return autorest.CreatePreparer(
+		autorest.AsPost(),
+		autorest.WithPath("/"),
+		autorest.AsJSON(),
+		autorest.WithJSON(reqs),
+	).Prepare(newRequest(ctx))
+}
+
 func newRequest(ctx context.Context) *http.Request {
 	req, _ := http.NewRequestWithContext(ctx, "", "", http.NoBody)
 	if h := tracing.GetOutboundHeaders(ctx); h != nil {
@@ -120,6 +207,15 @@ func newRequest(ctx context.Context) *http.Request {
 	return req
 }
 
+// withContextErr wraps the context error if the HTTP call failed because the context is done
+// (e.g. its deadline expired while reading the response), so callers can check it with errors.Is
+func withContextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		return fmt.Errorf("%w: %w", ctxErr, err)
+	}
+	return err
+}
+
 // responseMsg is a struct allowing to encode/decode a JSON-RPC response body
 type responseMsg struct {
 	Version string           `json:"jsonrpc"`
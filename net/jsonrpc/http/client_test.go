@@ -4,6 +4,7 @@
 package jsonrpchttp
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -121,3 +122,45 @@ func testCallStatus400(t *testing.T, c *Client, mockCli *httptestutils.MockSende
 
 	require.Error(t, err)
 }
+
+func TestCallBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCli := httptestutils.NewMockSender(ctrl)
+	c := NewClientFromClient(mockCli)
+
+	req := httptestutils.NewGockRequest()
+	req.Post("/").
+		JSON([]byte(`[{"jsonrpc":"2.0","method":"concat","params":["a","b"],"id":0},{"jsonrpc":"2.0","method":"fail","params":null,"id":1},{"jsonrpc":"2.0","method":"missing","params":null,"id":2}]`)).
+		Reply(200).
+		JSON([]byte(`[{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found"},"id":1},{"jsonrpc":"2.0","result":"ab","id":0}]`))
+
+	mockCli.EXPECT().Gock(req)
+
+	var res string
+	elems := []*jsonrpc.BatchElem{
+		{Req: &jsonrpc.Request{Version: "2.0", Method: "concat", Params: []string{"a", "b"}}, Result: &res},
+		{Req: &jsonrpc.Request{Version: "2.0", Method: "fail"}},
+		{Req: &jsonrpc.Request{Version: "2.0", Method: "missing"}},
+	}
+	err := c.CallBatch(t.Context(), elems)
+
+	require.NoError(t, err)
+	require.NoError(t, elems[0].Error)
+	assert.Equal(t, "ab", res)
+	assert.Equal(t, &jsonrpc.ErrorMsg{Code: -32601, Message: "method not found"}, elems[1].Error)
+	require.Error(t, elems[2].Error)
+}
+
+func TestWithContextErr(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 0)
+	defer cancel()
+	<-ctx.Done()
+
+	err := withContextErr(ctx, errors.New("unexpected EOF"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = withContextErr(t.Context(), errors.New("unexpected EOF"))
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -2,13 +2,22 @@
 package jsonrpchttp
 
 import (
+	kilntypes "github.com/kilnfi/go-utils/common/types"
 	kilnhttp "github.com/kilnfi/go-utils/net/http"
+	"github.com/kilnfi/go-utils/net/jsonrpc"
 )
 
 type Config struct {
 	Address string
 
 	HTTP *kilnhttp.ClientConfig
+
+	// Optional client decorators, disabled if nil
+
+	Timeout   *kilntypes.Duration // Timeout of each call attempt
+	Retry     *jsonrpc.RetryConfig
+	RateLimit *jsonrpc.RateLimitConfig
+	Log       *jsonrpc.LogConfig
 }
 
 func (cfg *Config) SetDefault() *Config {
@@ -18,5 +27,17 @@ func (cfg *Config) SetDefault() *Config {
 
 	cfg.HTTP.SetDefault()
 
+	if cfg.Retry != nil {
+		cfg.Retry.SetDefault()
+	}
+
+	if cfg.RateLimit != nil {
+		cfg.RateLimit.SetDefault()
+	}
+
+	if cfg.Log != nil {
+		cfg.Log.SetDefault()
+	}
+
 	return cfg
 }
@@ -0,0 +1,102 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogConfig configures debug logging of JSON-RPC calls
+type LogConfig struct {
+	// MaxSize is the maximum number of bytes of params and result that are logged
+	MaxSize int
+}
+
+func (cfg *LogConfig) SetDefault() *LogConfig {
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = 1024
+	}
+
+	return cfg
+}
+
+// WithLogging logs calls at debug level, truncating params and result to cfg.MaxSize bytes
+func WithLogging(logger logrus.FieldLogger, cfg *LogConfig) ClientDecorator {
+	return func(c Client) Client {
+		return Decorate(
+			c,
+			func(ctx context.Context, req *Request, res interface{}) error {
+				if !isDebugEnabled(logger) {
+					return c.Call(ctx, req, res)
+				}
+
+				start := time.Now()
+				err := c.Call(ctx, req, res)
+
+				entry := logger.
+					WithField("req.method", req.Method).
+					WithField("req.id", req.ID).
+					WithField("req.params", truncateJSON(req.Params, cfg.MaxSize)).
+					WithField("duration", time.Since(start))
+				if err != nil {
+					entry.WithError(err).Debugf("jsonrpc call failed")
+				} else {
+					entry.WithField("res", truncateJSON(res, cfg.MaxSize)).Debugf("jsonrpc call")
+				}
+
+				return err
+			},
+			func(ctx context.Context, elems []*BatchElem, next BatchClient) error {
+				if !isDebugEnabled(logger) {
+					return next.CallBatch(ctx, elems)
+				}
+
+				start := time.Now()
+				err := next.CallBatch(ctx, elems)
+
+				methods := make([]string, len(elems))
+				for i, elem := range elems {
+					methods[i] = elem.Req.Method
+				}
+
+				entry := logger.
+					WithField("batch.size", len(elems)).
+					WithField("batch.methods", truncateJSON(methods, cfg.MaxSize)).
+					WithField("duration", time.Since(start))
+				if err != nil {
+					entry.WithError(err).Debugf("jsonrpc batch call failed")
+				} else {
+					entry.Debugf("jsonrpc batch call")
+				}
+
+				return err
+			},
+		)
+	}
+}
+
+func isDebugEnabled(logger logrus.FieldLogger) bool {
+	switch l := logger.(type) {
+	case *logrus.Entry:
+		return l.Logger.IsLevelEnabled(logrus.DebugLevel)
+	case *logrus.Logger:
+		return l.IsLevelEnabled(logrus.DebugLevel)
+	default:
+		return true
+	}
+}
+
+func truncateJSON(v interface{}, maxSize int) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err.Error()
+	}
+
+	if len(b) > maxSize {
+		return string(b[:maxSize]) + "...(truncated)"
+	}
+
+	return string(b)
+}
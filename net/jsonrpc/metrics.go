@@ -0,0 +1,124 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds metrics of JSON-RPC calls labelled by method
+type Metrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewMetrics creates JSON-RPC call metrics
+func NewMetrics() *Metrics {
+	return &Metrics{
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "jsonrpc_request_duration_seconds",
+				Help:    "Duration of JSON-RPC calls",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"method", "status"},
+		),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "jsonrpc_request_errors_total",
+				Help: "Number of failed JSON-RPC calls by error code",
+			},
+			[]string{"method", "code"},
+		),
+	}
+}
+
+// RegisterMetrics registers metrics
+//
+// If metrics are already registered (e.g. by another client) the registered ones are re-used,
+// so several clients can share the same registry.
+func (m *Metrics) RegisterMetrics(reg prometheus.Registerer) error {
+	if err := reg.Register(m.duration); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return err
+		}
+		m.duration = are.ExistingCollector.(*prometheus.HistogramVec)
+	}
+
+	if err := reg.Register(m.errors); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return err
+		}
+		m.errors = are.ExistingCollector.(*prometheus.CounterVec)
+	}
+
+	return nil
+}
+
+// WithMetrics records duration and errors of calls
+//
+// Each request of a batch is recorded with the duration of the whole batch and fails
+// if the batch or the request failed.
+func WithMetrics(m *Metrics) ClientDecorator {
+	return func(c Client) Client {
+		return Decorate(
+			c,
+			func(ctx context.Context, req *Request, res interface{}) error {
+				start := time.Now()
+				err := c.Call(ctx, req, res)
+				m.observe(req.Method, err, time.Since(start))
+				return err
+			},
+			func(ctx context.Context, elems []*BatchElem, next BatchClient) error {
+				start := time.Now()
+				err := next.CallBatch(ctx, elems)
+				duration := time.Since(start)
+				for _, elem := range elems {
+					elemErr := err
+					if elemErr == nil {
+						elemErr = elem.Error
+					}
+					m.observe(elem.Req.Method, elemErr, duration)
+				}
+				return err
+			},
+		)
+	}
+}
+
+func (m *Metrics) observe(method string, err error, duration time.Duration) {
+	status := "success"
+	if err != nil {
+		status = "error"
+		m.errors.WithLabelValues(method, errorCode(err)).Inc()
+	}
+	m.duration.WithLabelValues(method, status).Observe(duration.Seconds())
+}
+
+// errorCode returns a label with bounded cardinality describing err
+func errorCode(err error) string {
+	var errMsg *ErrorMsg
+	switch {
+	case errors.As(err, &errMsg):
+		return strconv.Itoa(errMsg.Code)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case httpStatusCode(err) != 0:
+		return "http_" + strconv.Itoa(httpStatusCode(err))
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return "transport"
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,92 @@
+package jsonrpc
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimit is a token bucket rate limit
+type RateLimit struct {
+	Rate  float64 // Number of calls per second (0 means unlimited)
+	Burst int
+}
+
+// RateLimitConfig configures rate limiting of JSON-RPC calls
+//
+// Each method has its own token bucket, configured by Methods or by Default
+// if the method is not listed.
+type RateLimitConfig struct {
+	Default *RateLimit
+	Methods map[string]*RateLimit
+}
+
+func (cfg *RateLimitConfig) SetDefault() *RateLimitConfig {
+	if cfg.Default == nil {
+		cfg.Default = &RateLimit{}
+	}
+
+	return cfg
+}
+
+func (l *RateLimit) newLimiter() *rate.Limiter {
+	if l.Rate <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+
+	burst := l.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return rate.NewLimiter(rate.Limit(l.Rate), burst)
+}
+
+// WithRateLimit waits for the token bucket of the method to allow a call before sending it
+//
+// A batch waits for a token of the method of each of its requests.
+// It returns an error without sending the call if the context is done before a token is available.
+func WithRateLimit(cfg *RateLimitConfig) ClientDecorator {
+	var (
+		mu       sync.Mutex
+		limiters = make(map[string]*rate.Limiter)
+	)
+
+	limiter := func(method string) *rate.Limiter {
+		mu.Lock()
+		defer mu.Unlock()
+
+		l, ok := limiters[method]
+		if !ok {
+			limit, ok := cfg.Methods[method]
+			if !ok {
+				limit = cfg.Default
+			}
+			l = limit.newLimiter()
+			limiters[method] = l
+		}
+
+		return l
+	}
+
+	return func(c Client) Client {
+		return Decorate(
+			c,
+			func(ctx context.Context, req *Request, res interface{}) error {
+				if err := limiter(req.Method).Wait(ctx); err != nil {
+					return err
+				}
+				return c.Call(ctx, req, res)
+			},
+			func(ctx context.Context, elems []*BatchElem, next BatchClient) error {
+				for _, elem := range elems {
+					if err := limiter(elem.Req.Method).Wait(ctx); err != nil {
+						return err
+					}
+				}
+				return next.CallBatch(ctx, elems)
+			},
+		)
+	}
+}
@@ -0,0 +1,142 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	kilntypes "github.com/kilnfi/go-utils/common/types"
+)
+
+// RetryConfig configures retries of failed JSON-RPC calls
+type RetryConfig struct {
+	MaxRetries   int
+	MinRetryWait *kilntypes.Duration
+	MaxRetryWait *kilntypes.Duration
+
+	// Codes are the JSON-RPC error codes that are retried
+	Codes []int
+
+	// HTTPStatusCodes are the HTTP status codes that are retried
+	HTTPStatusCodes []int
+}
+
+func (cfg *RetryConfig) SetDefault() *RetryConfig {
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+
+	if cfg.MinRetryWait == nil {
+		cfg.MinRetryWait = &kilntypes.Duration{Duration: 100 * time.Millisecond}
+	}
+
+	if cfg.MaxRetryWait == nil {
+		cfg.MaxRetryWait = &kilntypes.Duration{Duration: 2 * time.Second}
+	}
+
+	if cfg.Codes == nil {
+		cfg.Codes = []int{
+			-32603, // Internal error
+			-32005, // Limit exceeded
+			-32002, // Resource unavailable (e.g. request timed out)
+		}
+	}
+
+	if cfg.HTTPStatusCodes == nil {
+		cfg.HTTPStatusCodes = []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+
+	return cfg
+}
+
+// WithRetry retries calls failing with a retryable JSON-RPC error code, HTTP status or a network error
+//
+// Retries use an exponential backoff between cfg.MinRetryWait and cfg.MaxRetryWait and stop as soon as
+// the context is done. An attempt timing out while the context is not done (c.f. WithTimeout) is retried. A batch is retried as a whole if it failed as a whole, errors of individual
+// requests are not retried.
+func WithRetry(cfg *RetryConfig) ClientDecorator {
+	return func(c Client) Client {
+		return Decorate(
+			c,
+			func(ctx context.Context, req *Request, res interface{}) error {
+				return cfg.retry(ctx, func() error { return c.Call(ctx, req, res) })
+			},
+			func(ctx context.Context, elems []*BatchElem, next BatchClient) error {
+				return cfg.retry(ctx, func() error { return next.CallBatch(ctx, elems) })
+			},
+		)
+	}
+}
+
+func (cfg *RetryConfig) retry(ctx context.Context, call func() error) error {
+	wait := cfg.MinRetryWait.Duration
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= cfg.MaxRetries || !cfg.isRetryable(ctx, err) {
+			return err
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+
+		wait *= 2
+		if wait > cfg.MaxRetryWait.Duration {
+			wait = cfg.MaxRetryWait.Duration
+		}
+	}
+}
+
+func (cfg *RetryConfig) isRetryable(ctx context.Context, err error) bool {
+	// the caller gave up, otherwise the deadline of the attempt expired
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var errMsg *ErrorMsg
+	if errors.As(err, &errMsg) {
+		return containsInt(cfg.Codes, errMsg.Code)
+	}
+
+	if status := httpStatusCode(err); status != 0 {
+		return containsInt(cfg.HTTPStatusCodes, status)
+	}
+
+	// errors returned by the HTTP client are transport errors (e.g. connection refused)
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// httpStatusCode returns the HTTP status code of the response that led to err, if any
+func httpStatusCode(err error) int {
+	var detailedErr autorest.DetailedError
+	if !errors.As(err, &detailedErr) || detailedErr.Response == nil {
+		return 0
+	}
+	return detailedErr.Response.StatusCode
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
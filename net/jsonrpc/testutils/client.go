@@ -48,3 +48,54 @@ func (mr *MockClientMockRecorder) Call(ctx, req, res interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Call", reflect.TypeOf((*MockClient)(nil).Call), ctx, req, res)
 }
+
+// MockBatchClient is a mock of BatchClient interface.
+type MockBatchClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatchClientMockRecorder
+}
+
+// MockBatchClientMockRecorder is the mock recorder for MockBatchClient.
+type MockBatchClientMockRecorder struct {
+	mock *MockBatchClient
+}
+
+// NewMockBatchClient creates a new mock instance.
+func NewMockBatchClient(ctrl *gomock.Controller) *MockBatchClient {
+	mock := &MockBatchClient{ctrl: ctrl}
+	mock.recorder = &MockBatchClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBatchClient) EXPECT() *MockBatchClientMockRecorder {
+	return m.recorder
+}
+
+// Call mocks base method.
+func (m *MockBatchClient) Call(ctx context.Context, req *jsonrpc.Request, res interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Call", ctx, req, res)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Call indicates an expected call of Call.
+func (mr *MockBatchClientMockRecorder) Call(ctx, req, res interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Call", reflect.TypeOf((*MockBatchClient)(nil).Call), ctx, req, res)
+}
+
+// CallBatch mocks base method.
+func (m *MockBatchClient) CallBatch(ctx context.Context, elems []*jsonrpc.BatchElem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CallBatch", ctx, elems)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CallBatch indicates an expected call of CallBatch.
+func (mr *MockBatchClientMockRecorder) CallBatch(ctx, elems interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CallBatch", reflect.TypeOf((*MockBatchClient)(nil).CallBatch), ctx, elems)
+}
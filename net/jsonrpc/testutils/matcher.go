@@ -38,3 +38,10 @@ func HasID(id interface{}) gomock.Matcher {
 		msg:   fmt.Sprintf("Request should have ID %v", id),
 	}
 }
+
+func HasMethod(method string) gomock.Matcher {
+	return &matcher{
+		match: func(req *jsonrpc.Request) bool { return req.Method == method },
+		msg:   fmt.Sprintf("Request should have method %q", method),
+	}
+}